@@ -82,7 +82,7 @@ export function GitHubTrendingTable() {
                 <td>{item.name || 'No name'}</td>
                 <td>{item.description || 'No description'}</td>
                 <td>{item.language || 'N/A'}</td>
-                <td>{item.stars || '0'}</td>
+                <td>{(item.stars ?? 0).toLocaleString()}</td>
                 <td>
                   <a href={item.url} target="_blank" rel="noopener noreferrer">
                     URL
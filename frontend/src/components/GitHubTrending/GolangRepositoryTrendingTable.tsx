@@ -75,7 +75,7 @@ export const GolangRepositoryTrendingTable = () => {
               <td>{item.name || 'No name'}</td>
               <td>{item.description || 'No description'}</td>
               <td>{item.language || 'N/A'}</td>
-              <td>{item.stars || '0'}</td>
+              <td>{(item.stars ?? 0).toLocaleString()}</td>
               <td>
                 <a href={item.url} target="_blank" rel="noopener noreferrer">
                   URL
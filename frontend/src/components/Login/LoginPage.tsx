@@ -1,14 +1,39 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { useAuth } from '../../contexts/AuthContext';
 import './LoginPage.css';
 
+interface LoginOptions {
+    oidc: boolean;
+    oidcName?: string;
+}
+
+// シングルサインオンの失敗時はサーバーが ?login_error= を付けてリダイレクトする
+function takeLoginError(): string {
+    const params = new URLSearchParams(window.location.search);
+    const message = params.get('login_error') ?? '';
+    if (message) {
+        params.delete('login_error');
+        const query = params.toString();
+        window.history.replaceState(null, '', window.location.pathname + (query ? `?${query}` : ''));
+    }
+    return message;
+}
+
 export function LoginPage() {
     const [username, setUsername] = useState('');
     const [password, setPassword] = useState('');
-    const [error, setError] = useState('');
+    const [error, setError] = useState(takeLoginError);
     const [isLoading, setIsLoading] = useState(false);
+    const [options, setOptions] = useState<LoginOptions>({ oidc: false });
     const { login } = useAuth();
 
+    useEffect(() => {
+        fetch('/trends-summary/api/login-options')
+            .then((response) => (response.ok ? response.json() : { oidc: false }))
+            .then(setOptions)
+            .catch((err) => console.error('ログイン方法の取得エラー:', err));
+    }, []);
+
     const handleSubmit = async (e: React.FormEvent) => {
         e.preventDefault();
         setError('');
@@ -67,6 +92,15 @@ export function LoginPage() {
                         {isLoading ? 'ログイン中...' : 'ログイン'}
                     </button>
                 </form>
+
+                {options.oidc && (
+                    <>
+                        <div className="login-divider">または</div>
+                        <a href="/trends-summary/api/oidc/login" className="login-button sso-button">
+                            {options.oidcName || 'SSO'} でログイン
+                        </a>
+                    </>
+                )}
             </div>
         </div>
     );
@@ -8,17 +8,38 @@ interface AuthContextType {
     checkAuth: () => Promise<void>;
 }
 
+const REFRESH_INTERVAL_MS = 10 * 60 * 1000;
+
 const AuthContext = createContext<AuthContextType | undefined>(undefined);
 
 export function AuthProvider({ children }: { children: ReactNode }) {
     const [isAuthenticated, setIsAuthenticated] = useState(false);
     const [isLoading, setIsLoading] = useState(true);
 
+    // リフレッシュトークンでアクセストークンを再発行する
+    const refresh = async (): Promise<boolean> => {
+        try {
+            const response = await fetch('/trends-summary/api/refresh', {
+                method: 'POST',
+                credentials: 'include',
+            });
+            return response.ok;
+        } catch (error) {
+            console.error('トークン更新エラー:', error);
+            return false;
+        }
+    };
+
     const checkAuth = async () => {
         try {
-            const response = await fetch('/trends-summary/api/check-auth', {
+            let response = await fetch('/trends-summary/api/check-auth', {
                 credentials: 'include',
             });
+            if (response.status === 401 && await refresh()) {
+                response = await fetch('/trends-summary/api/check-auth', {
+                    credentials: 'include',
+                });
+            }
             setIsAuthenticated(response.ok);
         } catch (error) {
             console.error('認証チェックエラー:', error);
@@ -62,6 +83,19 @@ export function AuthProvider({ children }: { children: ReactNode }) {
         checkAuth();
     }, []);
 
+    // アクセストークン（既定15分）の有効期限が切れる前に定期的に更新する
+    useEffect(() => {
+        if (!isAuthenticated) {
+            return;
+        }
+        const timer = setInterval(async () => {
+            if (!await refresh()) {
+                setIsAuthenticated(false);
+            }
+        }, REFRESH_INTERVAL_MS);
+        return () => clearInterval(timer);
+    }, [isAuthenticated]);
+
     return (
         <AuthContext.Provider value={{ isAuthenticated, isLoading, login, logout, checkAuth }}>
             {children}
@@ -1,4 +1,7 @@
-import { createContext, useContext, useState, type ReactNode } from 'react';
+import { createContext, useContext, useEffect, useState, type ReactNode } from 'react';
+import { useAuth } from './AuthContext';
+import { preferencesApi } from '../services/api';
+import type { UserPreferences } from '../types';
 
 type Language = 'ja' | 'en';
 
@@ -9,8 +12,32 @@ interface LanguageContextType {
 
 const LanguageContext = createContext<LanguageContextType | undefined>(undefined);
 
+// 言語はサーバーのユーザー表示設定に保存し、ログインした端末間で共有する
 export function LanguageProvider({ children }: { children: ReactNode }) {
-  const [language, setLanguage] = useState<Language>('ja'); // デフォルトは日本語
+  const { isAuthenticated } = useAuth();
+  const [preferences, setPreferences] = useState<UserPreferences | null>(null);
+
+  useEffect(() => {
+    if (!isAuthenticated) {
+      setPreferences(null);
+      return;
+    }
+    preferencesApi.get()
+      .then(setPreferences)
+      .catch((error) => console.error('表示設定の取得エラー:', error));
+  }, [isAuthenticated]);
+
+  const language: Language = preferences?.language || 'ja'; // 未設定の場合は日本語
+
+  const setLanguage = (lang: Language) => {
+    if (!preferences) {
+      return;
+    }
+    // 保存が完了してから切り替え、サーバー側の言語版の選択と表示を一致させる
+    preferencesApi.update({ ...preferences, language: lang })
+      .then(setPreferences)
+      .catch((error) => console.error('表示設定の保存エラー:', error));
+  };
 
   return (
     <LanguageContext.Provider value={{ language, setLanguage }}>
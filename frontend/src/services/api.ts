@@ -1,5 +1,6 @@
 import type { GitHubTrendingItem, SummaryResponse } from '../types/github';
 import type { RSSFeedResponse, XMLRSSItem } from '../types/rss';
+import type { UserPreferences } from '../types';
 
 // 開発環境ではViteのプロキシを使用するため、相対パスを使用
 const API_BASE_URL = import.meta.env.BASE_URL.replace(/\/$/, '');
@@ -121,3 +122,32 @@ function parseXMLRSS(xmlText: string): XMLRSSItem[] {
 
     return result;
 }
+
+// ユーザーの表示設定
+export const preferencesApi = {
+    get: async (): Promise<UserPreferences> => {
+        const response = await fetch(`${API_BASE_URL}/api/me/preferences`, {
+            credentials: 'include',
+        });
+        if (!response.ok) {
+            throw new Error(`HTTPエラー: ${response.status}`);
+        }
+        return response.json();
+    },
+
+    update: async (preferences: UserPreferences): Promise<UserPreferences> => {
+        const response = await fetch(`${API_BASE_URL}/api/me/preferences`, {
+            method: 'PUT',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify(preferences),
+            credentials: 'include',
+        });
+        if (!response.ok) {
+            const data = await response.json();
+            throw new Error(data.error || `HTTPエラー: ${response.status}`);
+        }
+        return response.json();
+    },
+};
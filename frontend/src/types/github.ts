@@ -1,8 +1,20 @@
+export interface GitHubTrendingContributor {
+  username: string;
+  url: string;
+  avatarUrl: string;
+}
+
 export interface GitHubTrendingItem {
+  rank: number;
   name: string;
+  owner: string;
+  repo: string;
   description: string;
   language: string;
-  stars: string;
+  stars: number;
+  forks: number;
+  starsGained: number;
+  builtBy: GitHubTrendingContributor[];
   url: string;
 }
 
@@ -23,3 +23,12 @@ export interface RSSFeedItem {
 export interface RSSFeedResponse {
   items: RSSFeedItem[];
 }
+
+// ユーザーの表示設定の型定義
+export interface UserPreferences {
+  sources: string[];
+  language: '' | 'ja' | 'en';
+  keywords: string[];
+  githubLanguages: string[];
+  updatedAt?: string;
+}
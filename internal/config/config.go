@@ -0,0 +1,238 @@
+package config
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"trends-summary/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default_config.yaml
+var defaultConfig []byte
+
+// Config アプリケーション設定
+type Config struct {
+	Sources      []models.FeedSource `yaml:"sources"`
+	Store        StoreConfig         `yaml:"store"`
+	Poller       PollerConfig        `yaml:"poller"`
+	Trending     TrendingConfig      `yaml:"trending"`
+	LLM          LLMConfig           `yaml:"llm"`
+	SummaryCache SummaryCacheConfig  `yaml:"summary_cache"`
+	Fetcher      FetcherConfig       `yaml:"fetcher"`
+	Extraction   ExtractionConfig    `yaml:"extraction"`
+	AIUsage      AIUsageConfig       `yaml:"ai_usage"`
+	Digest       DigestConfig        `yaml:"digest"`
+	Notifier     NotifierConfig      `yaml:"notifier"`
+	OutgoingFeed OutgoingFeedConfig  `yaml:"outgoing_feed"`
+	Auth         AuthConfig          `yaml:"auth"`
+	OIDC         OIDCConfig          `yaml:"oidc"`
+}
+
+// StoreConfig 記事ストアの設定
+type StoreConfig struct {
+	Path      string `yaml:"path"`
+	ListLimit int    `yaml:"list_limit"` // 1ソースあたりに返却する最大件数
+}
+
+// PollerConfig バックグラウンドのフィード取得設定
+type PollerConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// LLMConfig AI要約に使用するLLMの設定
+type LLMConfig struct {
+	Default   string                       `yaml:"default"`   // endpointsに割り当てのないエンドポイントで使うプロバイダー名
+	Providers map[string]LLMProviderConfig `yaml:"providers"` // プロバイダー名 → 設定
+	Endpoints map[string]string            `yaml:"endpoints"` // エンドポイント名 → プロバイダー名
+}
+
+// SummaryCacheConfig AI要約キャッシュの設定
+type SummaryCacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+// FetcherConfig 外部コンテンツ（AI要約する記事・中継するフィード）の取得設定
+type FetcherConfig struct {
+	Timeout         time.Duration `yaml:"timeout"`
+	MaxBodyBytes    int64         `yaml:"max_body_bytes"`
+	MaxRedirects    int           `yaml:"max_redirects"`
+	AllowedSchemes  []string      `yaml:"allowed_schemes"`  // http / https
+	AllowedDomains  []string      `yaml:"allowed_domains"`  // 空の場合はすべて（サブドメインを含む）
+	AllowedNetworks []string      `yaml:"allowed_networks"` // 例外として取得を許可する内部ネットワークのCIDR
+}
+
+// ExtractionConfig AI要約する記事の本文抽出の設定
+type ExtractionConfig struct {
+	MaxTextLength int                    `yaml:"max_text_length"` // 要約に渡す本文の最大文字数
+	Sites         []SiteExtractionConfig `yaml:"sites"`
+}
+
+// SiteExtractionConfig ドメインごとの抽出方法（空の項目は自動で判定）
+type SiteExtractionConfig struct {
+	Domain    string   `yaml:"domain"`    // サブドメインを含む
+	Content   string   `yaml:"content"`   // 本文のCSSセレクタ（複数一致した場合は連結）
+	Title     string   `yaml:"title"`     // タイトルのCSSセレクタ
+	Author    string   `yaml:"author"`    // 著者のCSSセレクタ
+	Published string   `yaml:"published"` // 公開日時のCSSセレクタ（datetime・content属性があれば優先）
+	Remove    []string `yaml:"remove"`    // 抽出前に取り除く要素のCSSセレクタ
+}
+
+// AIUsageConfig AI要約の利用量の上限（USDの推定コスト、0は無制限）
+// 日・月の区切りはサーバーのローカルタイムゾーンです
+type AIUsageConfig struct {
+	UserDailyBudgetUSD     float64 `yaml:"user_daily_budget_usd"`
+	UserMonthlyBudgetUSD   float64 `yaml:"user_monthly_budget_usd"`
+	GlobalDailyBudgetUSD   float64 `yaml:"global_daily_budget_usd"`
+	GlobalMonthlyBudgetUSD float64 `yaml:"global_monthly_budget_usd"`
+	// 生成前に上限から仮押さえする出力トークン数（実際のコストは生成後に記録）
+	ReservedOutputTokens int `yaml:"reserved_output_tokens"`
+}
+
+// LLMProviderConfig LLMプロバイダーの設定
+type LLMProviderConfig struct {
+	Type      string        `yaml:"type"` // gemini / openai / ollama
+	Model     string        `yaml:"model"`
+	BaseURL   string        `yaml:"base_url"`    // openai / ollama のみ
+	APIKeyEnv string        `yaml:"api_key_env"` // APIキーを格納した環境変数名
+	Timeout   time.Duration `yaml:"timeout"`
+	// ストリーミング生成全体のタイムアウト（長いトレンド要約向け）
+	StreamTimeout time.Duration `yaml:"stream_timeout"`
+	// 推定コストの計算に使う100万トークンあたりの料金（USD、未設定の場合はコスト0として記録）
+	InputPricePerMTok  float64 `yaml:"input_price_per_mtok"`
+	OutputPricePerMTok float64 `yaml:"output_price_per_mtok"`
+}
+
+// TrendingConfig GitHub Trendingの日次スナップショット設定
+type TrendingConfig struct {
+	SnapshotEnabled bool          `yaml:"snapshot_enabled"`
+	CheckInterval   time.Duration `yaml:"check_interval"` // 今日のスナップショットの有無を確認する間隔
+	Scopes          []string      `yaml:"scopes"`         // 対象の言語（空文字は全言語）
+}
+
+// DigestConfig 日次トレンドダイジェストの自動作成設定
+type DigestConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	At            string        `yaml:"at"`             // 作成時刻（HH:MM、サーバーのローカルタイムゾーン）
+	CheckInterval time.Duration `yaml:"check_interval"` // 今日のダイジェストの有無を確認する間隔
+	Sources       []string      `yaml:"sources"`        // infoq / github-trending / golang-weekly（空は全て）
+}
+
+// NotifierConfig ダイジェスト・新着記事の通知設定
+type NotifierConfig struct {
+	MaxRetries     int             `yaml:"max_retries"`     // 失敗時の再試行回数
+	InitialBackoff time.Duration   `yaml:"initial_backoff"` // 初回の再試行までの待ち時間（以降は2倍ずつ増加）
+	Timeout        time.Duration   `yaml:"timeout"`         // 1回の送信のタイムアウト
+	Webhooks       []WebhookConfig `yaml:"webhooks"`
+	Email          EmailConfig     `yaml:"email"`
+}
+
+// WebhookConfig Webhookの配信先
+type WebhookConfig struct {
+	Name    string   `yaml:"name"`
+	Type    string   `yaml:"type"`    // slack / discord / json
+	URL     string   `yaml:"url"`     // url_env が指定された場合はそちらを優先
+	URLEnv  string   `yaml:"url_env"` // Webhook URLを格納した環境変数名
+	Events  []string `yaml:"events"`  // digest / new_items（空は全て）
+	Sources []string `yaml:"sources"` // new_items の対象フィードID（空は全て）
+}
+
+// EmailConfig SMTPによるダイジェストのメール配信設定（宛先は購読者API で管理）
+type EmailConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port"`
+	Security    string `yaml:"security"` // starttls / tls（SMTPS）/ none
+	Username    string `yaml:"username"`
+	PasswordEnv string `yaml:"password_env"` // SMTPパスワードを格納した環境変数名
+	From        string `yaml:"from"`
+	BaseURL     string `yaml:"base_url"`  // 配信停止リンクに使う公開URL（例: https://example.com/trends-summary）
+	TopRepos    int    `yaml:"top_repos"` // メールに載せるトレンドリポジトリの件数
+}
+
+// OutgoingFeedConfig 全ソースを集約した配信用フィード（/feed.xml, /feed.atom, /feed.json）の設定
+type OutgoingFeedConfig struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Limit       int    `yaml:"limit"`     // 返却する最大件数
+	TokenEnv    string `yaml:"token_env"` // フィードリーダー用のアクセストークンを格納した環境変数名
+}
+
+// AuthConfig ログインセッションの設定（署名鍵は JWT_SECRET / JWT_PREVIOUS_SECRETS 環境変数）
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration       `yaml:"access_token_ttl"`  // アクセストークンの有効期間
+	RefreshTokenTTL time.Duration       `yaml:"refresh_token_ttl"` // 最後のリフレッシュからセッションが失効するまでの期間
+	AuditRetention  time.Duration       `yaml:"audit_retention"`   // 監査ログの保存期間（0は無期限）
+	LoginThrottle   LoginThrottleConfig `yaml:"login_throttle"`
+	TrustedProxies  []string            `yaml:"trusted_proxies"` // X-Forwarded-Forを信頼するリバースプロキシのCIDR（空の場合は接続元のアドレスを使用）
+}
+
+// LoginThrottleConfig パスワードログインの失敗回数による一時停止の設定
+type LoginThrottleConfig struct {
+	MaxFailuresPerUser int           `yaml:"max_failures_per_user"` // ユーザー名ごとの失敗回数の上限
+	MaxFailuresPerIP   int           `yaml:"max_failures_per_ip"`   // IPアドレスごとの失敗回数の上限
+	Window             time.Duration `yaml:"window"`                // 最後の失敗からこの期間が過ぎると回数をリセット
+	BaseLockout        time.Duration `yaml:"base_lockout"`          // 最初の停止時間（以降は停止のたびに2倍）
+	MaxLockout         time.Duration `yaml:"max_lockout"`           // 停止時間の上限
+}
+
+// OIDCConfig OIDC（認可コード + PKCE）によるシングルサインオンの設定
+type OIDCConfig struct {
+	Enabled         bool     `yaml:"enabled"`
+	Name            string   `yaml:"name"`   // ログイン画面のボタンに表示するIdPの名前
+	Issuer          string   `yaml:"issuer"` // /.well-known/openid-configuration を提供するURL
+	ClientID        string   `yaml:"client_id"`
+	ClientSecretEnv string   `yaml:"client_secret_env"` // クライアントシークレットを格納した環境変数名（パブリッククライアントは空）
+	RedirectURL     string   `yaml:"redirect_url"`      // IdPに登録したコールバックURL（…/trends-summary/api/oidc/callback）
+	Scopes          []string `yaml:"scopes"`
+	UsernameClaim   string   `yaml:"username_claim"`  // auto_create で作成するユーザーのユーザー名に使うクレーム（email / preferred_username など）
+	GroupsClaim     string   `yaml:"groups_claim"`    // 所属グループを含むクレーム
+	AllowedDomains  []string `yaml:"allowed_domains"` // ログインを許可するメールアドレスのドメイン（空は制限なし）
+	AllowedGroups   []string `yaml:"allowed_groups"`  // いずれかへの所属を要求するグループ（空は制限なし）
+	AutoCreate      bool     `yaml:"auto_create"`     // 未登録のユーザーを作成する
+	DefaultRole     string   `yaml:"default_role"`    // auto_create で作成するユーザーのロール（viewer / summarizer / admin）
+}
+
+// Load デフォルト設定を読み込み、CONFIG_PATH（未指定時は ./config.yaml）の内容で上書きします
+func Load() (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.Unmarshal(defaultConfig, cfg); err != nil {
+		return nil, fmt.Errorf("デフォルト設定の解析に失敗しました: %w", err)
+	}
+
+	path := os.Getenv("CONFIG_PATH")
+	if path == "" {
+		path = "config.yaml"
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logrus.WithFields(logrus.Fields{
+				"function": "config.Load",
+				"path":     path,
+			}).Info("設定ファイルが存在しないためデフォルト設定を使用します")
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("設定ファイルの読み込みに失敗しました: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("設定ファイルの解析に失敗しました: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"function":    "config.Load",
+		"path":        path,
+		"sourceCount": len(cfg.Sources),
+	}).Info("設定ファイルを読み込みました")
+
+	return cfg, nil
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// summaryRequest AI要約の生成に必要な情報
+type summaryRequest struct {
+	handler       string // ログ出力用のハンドラー名
+	endpoint      string // LLMの割り当てに使うエンドポイント名
+	target        string // 要約対象のURL（検索インデックス登録用、空の場合は登録しない）
+	title         string // 要約対象のタイトル（検索インデックス登録用）
+	subject       string // キャッシュキーに使う要約対象（空の場合はキャッシュしない）
+	promptVersion string // プロンプトを変更した場合に更新してキャッシュを無効化する
+	prompt        string
+}
+
+// summaryResponse AI要約のJSONレスポンス
+type summaryResponse struct {
+	Summary  string `json:"summary"`
+	Model    string `json:"model,omitempty"`
+	Cached   bool   `json:"cached"`
+	CachedAt string `json:"cachedAt,omitempty"`
+}
+
+// summaryUsage SSEの完了イベントで返すトークン使用量
+type summaryUsage struct {
+	PromptTokens int `json:"promptTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+// lookupSummaryCache キャッシュを検索します
+// キャッシュ対象外の場合はentryがnil、?refresh=true の場合は検索せずに新しいentryのみ返します
+func lookupSummaryCache(c echo.Context, req *summaryRequest) (entry *models.SummaryCacheEntry, cached *models.SummaryCacheEntry) {
+	if summaryCache == nil || req.subject == "" {
+		return nil, nil
+	}
+
+	e := summaryCache.NewEntry(req.endpoint, req.subject, req.promptVersion, llmRouter.Provider(req.endpoint).Model(), req.prompt)
+	if c.QueryParam("refresh") == "true" {
+		return &e, nil
+	}
+
+	cached, err := summaryCache.Get(e)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler": req.handler,
+			"subject": req.subject,
+			"error":   err.Error(),
+		}).Warn("要約キャッシュの取得に失敗しました")
+		return &e, nil
+	}
+	if cached != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":  req.handler,
+			"subject":  req.subject,
+			"cachedAt": cached.CachedAt,
+		}).Info("要約キャッシュを使用します")
+	}
+	return &e, cached
+}
+
+// saveSummaryCache 生成した要約をキャッシュに保存し、保存日時を返します（失敗時は空文字）
+func saveSummaryCache(req *summaryRequest, entry *models.SummaryCacheEntry, summary string) string {
+	if entry == nil {
+		return ""
+	}
+	saved, err := summaryCache.Put(*entry, summary)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler": req.handler,
+			"subject": req.subject,
+			"error":   err.Error(),
+		}).Warn("要約キャッシュの保存に失敗しました")
+		return ""
+	}
+	return saved.CachedAt
+}
+
+// llmContext LLMの呼び出しに使うコンテキストを返します（利用量はログイン中のユーザーで記録します）
+func llmContext(c echo.Context) context.Context {
+	userID, _ := c.Get("userID").(int64)
+	username, _ := c.Get("username").(string)
+	return usecase.WithLLMCaller(c.Request().Context(), usecase.LLMCaller{UserID: userID, Username: username})
+}
+
+// reserveBudget 要約1回分の見積もりコストを利用量の上限から仮押さえします
+// 上限を超える場合は429を返してtrueを返します。仮押さえは生成の完了後に Release で解放してください
+// 利用量の取得に失敗した場合は要約の生成を止めずに続行します
+func reserveBudget(c echo.Context, req *summaryRequest) (*usecase.AIUsageReservation, bool, error) {
+	if aiUsage == nil {
+		return nil, false, nil
+	}
+	userID, _ := c.Get("userID").(int64)
+	reservation, err := aiUsage.Reserve(userID, llmRouter.Provider(req.endpoint).Name(), req.prompt)
+	var budgetErr *usecase.AIBudgetError
+	if errors.As(err, &budgetErr) {
+		logrus.WithFields(logrus.Fields{
+			"handler":     req.handler,
+			"username":    c.Get("username"),
+			"scope":       budgetErr.Status.Scope,
+			"period":      budgetErr.Status.Period,
+			"spentUsd":    budgetErr.Status.SpentUSD,
+			"reservedUsd": budgetErr.Status.ReservedUSD,
+		}).Warn("AI要約の利用上限に達したためリクエストを拒否しました")
+		retryAfter := int(math.Ceil(time.Until(budgetErr.Reset).Seconds()))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		return nil, true, c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+			"error":  budgetErr.Error(),
+			"budget": budgetErr.Status,
+		})
+	}
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   req.handler,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("AI利用量の取得に失敗しました")
+	}
+	return reservation, false, nil
+}
+
+// respondSummary 要約を生成してJSONで返却します（キャッシュがあればそれを返します）
+func respondSummary(c echo.Context, req *summaryRequest) error {
+	entry, cached := lookupSummaryCache(c, req)
+	if cached != nil {
+		return c.JSON(http.StatusOK, summaryResponse{
+			Summary:  cached.Summary,
+			Model:    cached.Model,
+			Cached:   true,
+			CachedAt: cached.CachedAt,
+		})
+	}
+
+	reservation, rejected, err := reserveBudget(c, req)
+	if rejected {
+		return err
+	}
+	// 生成後に実際の利用量が記録されてから仮押さえを解放する
+	defer reservation.Release()
+
+	result, err := llmRouter.Generate(llmContext(c), req.endpoint, req.prompt)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   req.handler,
+			"target":    req.target,
+			"error":     err.Error(),
+			"errorType": "LLM APIエラー",
+		}).Error("LLM APIリクエストに失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "AI要約の生成に失敗しました。"})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"handler":    req.handler,
+		"target":     req.target,
+		"summaryLen": len(result.Text),
+	}).Info("AI要約生成成功")
+
+	if req.target != "" {
+		indexSummary(req.endpoint, req.target, req.title, result.Text)
+	}
+
+	// JSONオブジェクトとしてサマリーを返す
+	return c.JSON(http.StatusOK, summaryResponse{
+		Summary:  result.Text,
+		Model:    result.Model,
+		CachedAt: saveSummaryCache(req, entry, result.Text),
+	})
+}
+
+// streamSummary 要約をServer-Sent Eventsでストリーミング返却します
+// 生成中は chunk イベントで部分テキスト、完了時は done イベントでモデル・終了理由・トークン使用量を送信します
+func streamSummary(c echo.Context, req *summaryRequest) error {
+	res := c.Response()
+
+	// キャッシュがなく利用上限に達している場合はSSEを開始せずに429を返す
+	entry, cached := lookupSummaryCache(c, req)
+	if cached == nil {
+		reservation, rejected, err := reserveBudget(c, req)
+		if rejected {
+			return err
+		}
+		// 生成後（途中で切断した場合も）に実際の利用量が記録されてから仮押さえを解放する
+		defer reservation.Release()
+	}
+
+	// 長い要約でもサーバーのWriteTimeoutで切断されないよう書き込み期限を解除
+	if err := http.NewResponseController(res.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler": req.handler,
+			"error":   err.Error(),
+		}).Warn("書き込み期限の解除に失敗しました")
+	}
+
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no") // リバースプロキシのバッファリングを無効化
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	// キャッシュがある場合は全文を1つのchunkで返す
+	if cached != nil {
+		if err := writeSSE(res, "chunk", map[string]string{"text": cached.Summary}); err != nil {
+			return err
+		}
+		return writeSSE(res, "done", map[string]interface{}{
+			"model":    cached.Model,
+			"cached":   true,
+			"cachedAt": cached.CachedAt,
+		})
+	}
+
+	result, err := llmRouter.GenerateStream(llmContext(c), req.endpoint, req.prompt, func(text string) error {
+		return writeSSE(res, "chunk", map[string]string{"text": text})
+	})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   req.handler,
+			"target":    req.target,
+			"error":     err.Error(),
+			"errorType": "LLM APIエラー",
+		}).Error("LLM APIストリーミングに失敗しました")
+		// ヘッダー送信済みのためステータスコードではなくerrorイベントで通知
+		return writeSSE(res, "error", map[string]string{"error": "AI要約の生成に失敗しました。"})
+	}
+
+	if req.target != "" {
+		indexSummary(req.endpoint, req.target, req.title, result.Text)
+	}
+	cachedAt := saveSummaryCache(req, entry, result.Text)
+
+	return writeSSE(res, "done", map[string]interface{}{
+		"model":        result.Model,
+		"cached":       false,
+		"cachedAt":     cachedAt,
+		"finishReason": result.FinishReason,
+		"usage": summaryUsage{
+			PromptTokens: result.PromptTokens,
+			OutputTokens: result.OutputTokens,
+			TotalTokens:  result.TotalTokens,
+		},
+	})
+}
+
+// writeSSE SSEのイベントを1件書き込んで送信します
+func writeSSE(res *echo.Response, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// aiUsageGroups AI利用量の集計に指定できる単位
+var aiUsageGroups = []string{
+	store.AIUsageGroupUser,
+	store.AIUsageGroupEndpoint,
+	store.AIUsageGroupModel,
+	store.AIUsageGroupProvider,
+	store.AIUsageGroupDay,
+}
+
+// AIUsage はAI要約の利用量と推定コストの集計を返すハンドラーです（管理者のみ）
+// since / until（RFC3339または日付、省略時は今月）/ username で絞り込み、groupBy（省略時は user）ごとに集計します
+func AIUsage(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "AIUsage",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	now := time.Now()
+	q := models.AIUsageQuery{
+		Since:    time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).UTC().Format(time.RFC3339),
+		Username: c.QueryParam("username"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  *string
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		v := c.QueryParam(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02", v, time.Local)
+		}
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": p.name + "パラメータはRFC3339またはYYYY-MM-DD形式で指定してください"})
+		}
+		*p.dst = t.UTC().Format(time.RFC3339)
+	}
+
+	groupBy := c.QueryParam("groupBy")
+	if groupBy == "" {
+		groupBy = store.AIUsageGroupUser
+	}
+	if !slices.Contains(aiUsageGroups, groupBy) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "groupByパラメータは " + strings.Join(aiUsageGroups, " / ") + " のいずれかを指定してください"})
+	}
+
+	total, err := articleStore.SumAIUsage(q)
+	var rows []models.AIUsageTotals
+	if err == nil {
+		rows, err = articleStore.SummarizeAIUsage(q, groupBy)
+	}
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "AIUsage",
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("AI利用量の取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "AI利用量の取得に失敗しました"})
+	}
+
+	var budgets []models.AIBudgetStatus
+	if aiUsage != nil {
+		if budgets, err = aiUsage.Status(0); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"handler":   "AIUsage",
+				"error":     err.Error(),
+				"errorType": "DB取得エラー",
+			}).Error("AI利用量の上限の取得に失敗しました")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "AI利用量の取得に失敗しました"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"since":   q.Since,
+		"until":   q.Until,
+		"groupBy": groupBy,
+		"total":   total,
+		"rows":    rows,
+		"budgets": budgets,
+	})
+}
+
+// MyAIUsage はログイン中のユーザーの今日・今月のAI要約の利用量と上限を返すハンドラーです
+func MyAIUsage(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "MyAIUsage",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	userID, _ := c.Get("userID").(int64)
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+
+	today, err := articleStore.SumAIUsage(models.AIUsageQuery{UserID: userID, Since: dayStart.UTC().Format(time.RFC3339)})
+	var month models.AIUsageTotals
+	if err == nil {
+		month, err = articleStore.SumAIUsage(models.AIUsageQuery{UserID: userID, Since: monthStart.UTC().Format(time.RFC3339)})
+	}
+	budgets := []models.AIBudgetStatus{}
+	if err == nil && aiUsage != nil {
+		budgets, err = aiUsage.Status(userID)
+	}
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "MyAIUsage",
+			"userID":    userID,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("AI利用量の取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "AI利用量の取得に失敗しました"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"today":   today,
+		"month":   month,
+		"budgets": budgets,
+	})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// createAPITokenRequest APIトークン発行のリクエストボディ
+type createAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"` // 0は無期限
+}
+
+// createAPITokenResponse 発行したAPIトークン（平文のトークンはこのレスポンスでのみ返す）
+type createAPITokenResponse struct {
+	models.APIToken
+	Token string `json:"token"`
+}
+
+// APITokens はログイン中のユーザーのAPIトークンを返すハンドラーです
+func APITokens(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "APITokens",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	userID, _ := c.Get("userID").(int64)
+	tokens, err := articleStore.ListAPITokens(userID)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "APITokens",
+			"userID":    userID,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("APIトークンの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "APIトークンの取得に失敗しました"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"tokens": tokens})
+}
+
+// CreateAPIToken はログイン中のユーザーのAPIトークンを発行するハンドラーです
+func CreateAPIToken(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "CreateAPIToken",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	var req createAPITokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "リクエストデータが無効です"})
+	}
+	if req.ExpiresInDays < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "expiresInDaysは0以上で指定してください"})
+	}
+
+	userID, _ := c.Get("userID").(int64)
+	user, err := articleStore.GetUser(userID)
+	if err != nil || user == nil {
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"handler":   "CreateAPIToken",
+				"userID":    userID,
+				"error":     err.Error(),
+				"errorType": "DB取得エラー",
+			}).Error("ユーザーの取得に失敗しました")
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "APIトークンの発行に失敗しました"})
+	}
+
+	expiresIn := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	token, raw, err := usecase.CreateAPIToken(articleStore, *user, req.Name, req.Scopes, expiresIn)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"handler":  "CreateAPIToken",
+		"username": user.Username,
+		"tokenID":  token.ID,
+		"scopes":   token.Scopes,
+	}).Info("APIトークンを発行しました")
+	recordAudit(c, models.AuditEvent{
+		Event:    models.AuditEventAPITokenCreated,
+		UserID:   user.ID,
+		Username: user.Username,
+		Detail:   fmt.Sprintf("id=%d name=%s scopes=%s", token.ID, token.Name, strings.Join(token.Scopes, ",")),
+	})
+	return c.JSON(http.StatusCreated, createAPITokenResponse{APIToken: token, Token: raw})
+}
+
+// RevokeAPIToken はログイン中のユーザーのAPIトークンを失効させるハンドラーです
+func RevokeAPIToken(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "RevokeAPIToken",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"id":      c.Param("id"),
+	}).Info("ハンドラー呼び出し")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "idが不正です"})
+	}
+
+	userID, _ := c.Get("userID").(int64)
+	revoked, err := articleStore.RevokeAPIToken(userID, id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "RevokeAPIToken",
+			"id":        id,
+			"error":     err.Error(),
+			"errorType": "DB更新エラー",
+		}).Error("APIトークンの失効に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "APIトークンの失効に失敗しました"})
+	}
+	if !revoked {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "APIトークンが見つかりません"})
+	}
+	username, _ := c.Get("username").(string)
+	recordAudit(c, models.AuditEvent{Event: models.AuditEventAPITokenRevoked, UserID: userID, Username: username, Detail: fmt.Sprintf("id=%d", id)})
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"trends-summary/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditEvents は監査ログを新しい順に返すハンドラーです（管理者のみ）
+// event / username / ip / since / until（RFC3339または日付）/ before（ID）/ limit で絞り込めます
+func AuditEvents(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "AuditEvents",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	q := models.AuditQuery{
+		Event:    c.QueryParam("event"),
+		Username: c.QueryParam("username"),
+		IP:       c.QueryParam("ip"),
+		Limit:    100,
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "limitパラメータは1〜1000で指定してください"})
+		}
+		q.Limit = n
+	}
+	if v := c.QueryParam("before"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "beforeパラメータが不正です"})
+		}
+		q.BeforeID = id
+	}
+	for _, p := range []struct {
+		name string
+		dst  *string
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		v := c.QueryParam(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02", v, time.Local)
+		}
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": p.name + "パラメータはRFC3339またはYYYY-MM-DD形式で指定してください"})
+		}
+		*p.dst = t.UTC().Format(time.RFC3339)
+	}
+
+	events, err := articleStore.ListAuditEvents(q)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "AuditEvents",
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("監査ログの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "監査ログの取得に失敗しました"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"events": events})
+}
+
+// recordAudit リクエスト元のIPアドレス・User-Agentを付けて監査ログを記録します
+// 操作したユーザーが対象のユーザーと異なる場合は actor に記録します
+func recordAudit(c echo.Context, e models.AuditEvent) {
+	if auditLog == nil {
+		return
+	}
+	e.IP = c.RealIP()
+	e.UserAgent = c.Request().UserAgent()
+	if actor, _ := c.Get("username").(string); actor != "" && actor != e.Username {
+		e.Actor = actor
+	}
+	auditLog.Record(e)
+}
@@ -1,16 +1,27 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
 	"trends-summary/internal/middleware"
+	"trends-summary/internal/models"
+	"trends-summary/internal/usecase"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
 
+// 認証Cookie
+const (
+	accessCookieName  = "auth_token"
+	refreshCookieName = "refresh_token"
+	refreshCookiePath = "/trends-summary/api"
+)
+
 // LoginRequest ログインリクエスト
 type LoginRequest struct {
 	Username string `json:"username"`
@@ -26,81 +37,221 @@ func Login(c echo.Context) error {
 		})
 	}
 
-	// 環境変数から認証情報を取得（必須）
-	validUsername := os.Getenv("AUTH_USERNAME")
-	validPassword := os.Getenv("AUTH_PASSWORD")
-
-	if validUsername == "" || validPassword == "" {
-		logrus.Error("AUTH_USERNAME または AUTH_PASSWORD 環境変数が設定されていません")
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "サーバー設定エラー",
-		})
+	// 失敗が続いているユーザー名・IPアドレスはパスワードを照合せずに拒否
+	ip := c.RealIP()
+	if loginThrottle != nil {
+		if wait := loginThrottle.RetryAfter(ip, req.Username); wait > 0 {
+			logrus.WithFields(logrus.Fields{
+				"username": req.Username,
+				"ip":       ip,
+			}).Warn("ログイン停止中のログイン試行")
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+1)))
+			return c.JSON(http.StatusTooManyRequests, map[string]string{
+				"error": "ログインの試行回数が多すぎます。しばらくしてから再度お試しください",
+			})
+		}
 	}
 
-	// 認証チェック
-	if req.Username != validUsername || req.Password != validPassword {
+	// ユーザーストアで認証
+	user, err := usecase.Authenticate(articleStore, req.Username, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			logrus.WithFields(logrus.Fields{
+				"username": req.Username,
+				"ip":       ip,
+			}).Warn("ログイン失敗")
+			recordAudit(c, models.AuditEvent{Event: models.AuditEventLoginFailure, Username: req.Username, Detail: "invalid_credentials"})
+			recordLoginFailure(c, req.Username)
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "ユーザー名またはパスワードが正しくありません",
+			})
+		case errors.Is(err, usecase.ErrUserDisabled):
+			logrus.WithFields(logrus.Fields{
+				"username": req.Username,
+			}).Warn("無効化されたユーザーのログイン")
+			recordAudit(c, models.AuditEvent{Event: models.AuditEventLoginFailure, Username: req.Username, Detail: "disabled"})
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": err.Error(),
+			})
+		}
 		logrus.WithFields(logrus.Fields{
 			"username": req.Username,
-		}).Warn("ログイン失敗")
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "ユーザー名またはパスワードが正しくありません",
+			"error":    err.Error(),
+		}).Error("ログイン処理エラー")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ログインに失敗しました",
 		})
 	}
 
-	// JWTトークン生成
-	token, err := middleware.GenerateToken(req.Username)
+	// セッションを作成してトークンを発行
+	session, refreshToken, err := usecase.StartSession(articleStore, user.ID, c.Request().UserAgent(), c.RealIP(), refreshTokenTTL)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"error": err.Error(),
-		}).Error("JWT生成エラー")
+		}).Error("セッション作成エラー")
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "認証トークンの生成に失敗しました",
 		})
 	}
+	expiresAt, ok, err := issueTokens(c, user, session, refreshToken)
+	if !ok {
+		return err
+	}
 
-	// Cookieにトークンをセット（24時間有効）
-	cookie := new(http.Cookie)
-	cookie.Name = "auth_token"
-	cookie.Value = token
-	cookie.Expires = time.Now().Add(24 * time.Hour)
-	cookie.Path = "/"
-	cookie.HttpOnly = true
-	cookie.Secure = true // HTTPSのみ
-	cookie.SameSite = http.SameSiteStrictMode
-	c.SetCookie(cookie)
-
+	if loginThrottle != nil {
+		loginThrottle.Success(user.Username)
+	}
+	recordAudit(c, models.AuditEvent{Event: models.AuditEventLogin, UserID: user.ID, Username: user.Username, Detail: "password"})
 	logrus.WithFields(logrus.Fields{
-		"username": req.Username,
+		"username":  user.Username,
+		"sessionID": session.ID,
 	}).Info("ログイン成功")
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "ログインに成功しました",
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":   "ログインに成功しました",
+		"expiresAt": expiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// Refresh リフレッシュトークンを新しいものに置き換え、アクセストークンを再発行するハンドラー
+func Refresh(c echo.Context) error {
+	cookie, err := c.Cookie(refreshCookieName)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "認証が必要です",
+		})
+	}
+
+	session, user, refreshToken, err := usecase.RefreshSession(articleStore, cookie.Value, refreshTokenTTL)
+	if errors.Is(err, usecase.ErrRefreshTokenReused) {
+		recordAudit(c, models.AuditEvent{Event: models.AuditEventSessionRevoked, UserID: session.UserID, Username: usernameOf(session.UserID), Detail: "refresh_token_reuse session=" + session.ID})
+	}
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidRefreshToken) || errors.Is(err, usecase.ErrUserDisabled) {
+			logrus.WithFields(logrus.Fields{
+				"error": err.Error(),
+			}).Warn("トークンのリフレッシュ失敗")
+			clearAuthCookies(c)
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		logrus.WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Error("トークンのリフレッシュ処理エラー")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "認証トークンの更新に失敗しました",
+		})
+	}
+
+	expiresAt, ok, err := issueTokens(c, user, session, refreshToken)
+	if !ok {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":   "認証トークンを更新しました",
+		"expiresAt": expiresAt.UTC().Format(time.RFC3339),
 	})
 }
 
-// Logout ログアウトハンドラー
+// Logout ログアウトハンドラー（セッションを失効させてCookieを削除）
 func Logout(c echo.Context) error {
-	// Cookieを削除
-	cookie := new(http.Cookie)
-	cookie.Name = "auth_token"
-	cookie.Value = ""
-	cookie.Expires = time.Now().Add(-1 * time.Hour) // 過去の時刻を設定
-	cookie.Path = "/"
-	cookie.HttpOnly = true
-	cookie.Secure = true
-	cookie.SameSite = http.SameSiteStrictMode
-	c.SetCookie(cookie)
+	if cookie, err := c.Cookie(refreshCookieName); err == nil {
+		session, err := usecase.EndSession(articleStore, cookie.Value)
+		switch {
+		case err == nil:
+			recordAudit(c, models.AuditEvent{Event: models.AuditEventLogout, UserID: session.UserID, Username: usernameOf(session.UserID), Detail: "session=" + session.ID})
+		case !errors.Is(err, usecase.ErrInvalidRefreshToken):
+			logrus.WithFields(logrus.Fields{
+				"error": err.Error(),
+			}).Error("セッションの失効に失敗しました")
+		}
+	}
+	clearAuthCookies(c)
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "ログアウトしました",
 	})
 }
 
+// recordLoginFailure ログイン失敗を試行回数の制限に記録し、ログインの停止が始まった場合は監査ログに残します
+func recordLoginFailure(c echo.Context, username string) {
+	if loginThrottle == nil {
+		return
+	}
+	for _, lockout := range loginThrottle.Failure(c.RealIP(), username) {
+		logrus.WithFields(logrus.Fields{
+			"scope":    lockout.Scope,
+			"key":      lockout.Key,
+			"failures": lockout.Failures,
+			"duration": lockout.Duration.String(),
+		}).Warn("ログイン失敗が続いたためログインを一時停止します")
+		recordAudit(c, models.AuditEvent{
+			Event:    models.AuditEventLockout,
+			Username: username,
+			Detail:   fmt.Sprintf("%s=%s failures=%d duration=%s", lockout.Scope, lockout.Key, lockout.Failures, lockout.Duration),
+		})
+	}
+}
+
+// usernameOf 監査ログ用にユーザー名を返します（取得できない場合は空）
+func usernameOf(userID int64) string {
+	user, err := articleStore.GetUser(userID)
+	if err != nil || user == nil {
+		return ""
+	}
+	return user.Username
+}
+
+// issueTokens アクセストークンを生成し、リフレッシュトークンとともにCookieにセットします
+// （falseの場合はエラーレスポンスを書き込み済み）
+func issueTokens(c echo.Context, user *models.User, session models.Session, refreshToken string) (time.Time, bool, error) {
+	token, expiresAt, err := middleware.GenerateToken(user.ID, user.Username, user.Role, session.ID)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Error("JWT生成エラー")
+		return time.Time{}, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "認証トークンの生成に失敗しました",
+		})
+	}
+	refreshExpiresAt, _ := time.Parse(time.RFC3339, session.ExpiresAt)
+
+	c.SetCookie(authCookie(accessCookieName, token, "/", expiresAt))
+	// リフレッシュトークンはリフレッシュ・ログアウトのAPIにのみ送信させる
+	// （空の場合は同時に行われた別のリフレッシュで設定済みのCookieをそのまま使う）
+	if refreshToken != "" {
+		c.SetCookie(authCookie(refreshCookieName, refreshToken, refreshCookiePath, refreshExpiresAt))
+	}
+	return expiresAt, true, nil
+}
+
+// clearAuthCookies アクセストークンとリフレッシュトークンのCookieを削除します
+func clearAuthCookies(c echo.Context) {
+	past := time.Now().Add(-1 * time.Hour) // 過去の時刻を設定
+	c.SetCookie(authCookie(accessCookieName, "", "/", past))
+	c.SetCookie(authCookie(refreshCookieName, "", refreshCookiePath, past))
+}
+
+func authCookie(name, value, path string, expires time.Time) *http.Cookie {
+	cookie := new(http.Cookie)
+	cookie.Name = name
+	cookie.Value = value
+	cookie.Expires = expires
+	cookie.Path = path
+	cookie.HttpOnly = true
+	cookie.Secure = true // HTTPSのみ
+	cookie.SameSite = http.SameSiteStrictMode
+	return cookie
+}
+
 // CheckAuth 認証状態確認ハンドラー
 func CheckAuth(c echo.Context) error {
-	username := c.Get("username")
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"authenticated": true,
-		"username":      username,
+		"username":      c.Get("username"),
+		"role":          c.Get("role"),
+		"isAdmin":       c.Get("isAdmin"),
 	})
 }
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Deliveries は通知の配信ログを新しい順に返すハンドラーです
+// クエリパラメータ: target(配信先の名前, 空は全て), limit(既定50)
+func Deliveries(c echo.Context) error {
+	target := c.QueryParam("target")
+	logrus.WithFields(logrus.Fields{
+		"handler": "Deliveries",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"target":  target,
+	}).Info("ハンドラー呼び出し")
+
+	limit := 50
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "limitパラメータは1〜500で指定してください"})
+		}
+		limit = n
+	}
+
+	deliveries, err := articleStore.ListDeliveries(target, limit)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "Deliveries",
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("配信ログの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "配信ログの取得に失敗しました"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Digests は保存済みの日次ダイジェストを新しい順に返すハンドラーです
+// クエリパラメータ: from, to(YYYY-MM-DD, 既定は直近30日), limit(既定30)
+func Digests(c echo.Context) error {
+	from := c.QueryParam("from")
+	to := c.QueryParam("to")
+	logrus.WithFields(logrus.Fields{
+		"handler": "Digests",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"from":    from,
+		"to":      to,
+	}).Info("ハンドラー呼び出し")
+
+	if to == "" {
+		to = time.Now().Format("2006-01-02")
+	}
+	if from == "" {
+		from = time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+	}
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "日付はYYYY-MM-DD形式で指定してください"})
+		}
+	}
+
+	limit := 30
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "limitパラメータは1〜366で指定してください"})
+		}
+		limit = n
+	}
+
+	digests, err := articleStore.ListDigests(from, to, limit)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "Digests",
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("ダイジェスト一覧の取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ダイジェスト一覧の取得に失敗しました"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"digests": digests,
+	})
+}
+
+// DigestByDate は指定日のダイジェストを元データ付きで返すハンドラーです
+func DigestByDate(c echo.Context) error {
+	date := c.Param("date")
+	logrus.WithFields(logrus.Fields{
+		"handler": "DigestByDate",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"date":    date,
+	}).Info("ハンドラー呼び出し")
+
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "日付はYYYY-MM-DD形式で指定してください"})
+	}
+
+	digest, err := articleStore.GetDigest(date)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "DigestByDate",
+			"date":      date,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("ダイジェストの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ダイジェストの取得に失敗しました"})
+	}
+	if digest == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "指定日のダイジェストはありません"})
+	}
+
+	return c.JSON(http.StatusOK, digest)
+}
+
+// DeliverDigest は保存済みのダイジェストを設定された配信先に再送信するハンドラーです
+func DeliverDigest(c echo.Context) error {
+	date := c.Param("date")
+	logrus.WithFields(logrus.Fields{
+		"handler": "DeliverDigest",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"date":    date,
+	}).Info("ハンドラー呼び出し")
+
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "日付はYYYY-MM-DD形式で指定してください"})
+	}
+
+	digest, err := articleStore.GetDigest(date)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "DeliverDigest",
+			"date":      date,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("ダイジェストの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ダイジェストの取得に失敗しました"})
+	}
+	if digest == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "指定日のダイジェストはありません"})
+	}
+
+	deliveries := notifier.NotifyDigest(c.Request().Context(), *digest)
+	if len(deliveries) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ダイジェストの配信先が設定されていません"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"date":       date,
+		"deliveries": deliveries,
+	})
+}
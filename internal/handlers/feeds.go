@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// FeedSources 登録済みのフィードソース一覧を返すハンドラーです
+// ユーザーの表示設定で有効にしたソースのみを返します（?all=true で全件）
+func FeedSources(c echo.Context) error {
+	sources := feedRegistry.List()
+	if c.QueryParam("all") != "true" {
+		sources = usecase.FilterSources(currentPreferences(c), sources)
+	}
+	return c.JSON(http.StatusOK, sources)
+}
+
+// FeedContent はレジストリに登録されたフィードを :id で取得するハンドラーです
+// ?format=normalized で共通のFeedモデル、?format=rss でRSS 2.0、?format=raw で上流のフィードをそのまま返します
+// raw 以外はバックグラウンドで取得済みのストアの記事から返却します
+func FeedContent(c echo.Context) error {
+	return serveFeed(c, c.Param("id"))
+}
+
+// FeedAlias 既存のエンドポイントをレジストリのソースに割り当てるハンドラーを返します
+// ?lang=en|ja に対応する言語版がある場合はそちらを返します
+// ユーザーの表示設定の言語は、言語の決まっていないソースのエンドポイントで ?lang がない場合のみ使います
+func FeedAlias(id string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		language := c.QueryParam("lang")
+		if src, ok := feedRegistry.Get(id); language == "" && ok && src.Language == "" {
+			language = currentPreferences(c).Language
+		}
+		sourceID := id
+		if src, ok := feedRegistry.LanguageVariant(id, language); ok {
+			sourceID = src.ID
+		}
+		return serveFeed(c, sourceID)
+	}
+}
+
+func serveFeed(c echo.Context, id string) error {
+	logrus.WithFields(logrus.Fields{
+		"handler":  "FeedContent",
+		"method":   c.Request().Method,
+		"path":     c.Request().URL.Path,
+		"sourceID": id,
+	}).Info("ハンドラー呼び出し")
+
+	src, ok := feedRegistry.Get(id)
+	if !ok {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "FeedContent",
+			"sourceID":  id,
+			"errorType": "パラメータバリデーションエラー",
+		}).Error("フィードソースが見つかりません")
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "フィードソースが見つかりません"})
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = src.Output
+	}
+
+	switch format {
+	case models.FeedOutputRaw:
+		if len(src.URLs) > 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "複数URLを統合したフィードはformat=rawに対応していません"})
+		}
+		return serveRawFeed(c, src)
+	case models.FeedOutputNormalized:
+		return serveNormalizedFeed(c, src)
+	case models.FeedOutputJSON:
+		return serveJSONFeed(c, src)
+	case models.FeedOutputRSS:
+		return serveRSSFeed(c, src)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "formatパラメータが不正です"})
+	}
+}
+
+// loadFeed ストアからフィードを読み込みます（falseの場合はエラーレスポンスを書き込み済み）
+// ユーザーの表示設定にキーワードがある場合はいずれかを含む記事に絞り込みます（?filter=false で無効）
+func loadFeed(c echo.Context, src models.FeedSource) (models.Feed, bool, error) {
+	feed, err := usecase.LoadFeed(c.Request().Context(), fetcher, articleStore, src, feedListLimit)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "FeedContent",
+			"sourceID":  src.ID,
+			"error":     err.Error(),
+			"errorType": "RSSフィード取得エラー",
+		}).Error("RSSフィードの取得に失敗しました")
+		return models.Feed{}, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": fmt.Sprintf("RSSフィードの取得に失敗しました: %v", err),
+		})
+	}
+
+	if c.QueryParam("filter") != "false" {
+		feed.Items = usecase.FilterItemsByKeywords(feed.Items, currentPreferences(c).Keywords)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"handler":    "FeedContent",
+		"sourceID":   src.ID,
+		"totalItems": len(feed.Items),
+	}).Info("RSSフィード取得成功")
+
+	return feed, true, nil
+}
+
+// serveNormalizedFeed 共通のFeedモデルで返却します
+func serveNormalizedFeed(c echo.Context, src models.FeedSource) error {
+	feed, ok, err := loadFeed(c, src)
+	if !ok {
+		return err
+	}
+	return c.JSON(http.StatusOK, feed)
+}
+
+// serveJSONFeed title/link/published/description のJSONで返却します
+func serveJSONFeed(c echo.Context, src models.FeedSource) error {
+	feed, ok, err := loadFeed(c, src)
+	if !ok {
+		return err
+	}
+
+	// フィード情報を整形
+	feedItems := []map[string]interface{}{}
+	for _, item := range feed.Items {
+		feedItems = append(feedItems, map[string]interface{}{
+			"title":       item.Title,
+			"link":        item.Link,
+			"published":   item.Published,
+			"description": item.Description,
+		})
+	}
+
+	// JSONレスポンスを返却
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"title":       feed.Title,
+		"description": feed.Description,
+		"items":       feedItems,
+	})
+}
+
+// serveRSSFeed RSS 2.0のXMLで返却します
+func serveRSSFeed(c echo.Context, src models.FeedSource) error {
+	feed, ok, err := loadFeed(c, src)
+	if !ok {
+		return err
+	}
+
+	body, err := usecase.RenderRSS(feed, src.URLs[0])
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "FeedContent",
+			"sourceID":  src.ID,
+			"error":     err.Error(),
+			"errorType": "XML生成エラー",
+		}).Error("RSSの生成に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "RSSの生成に失敗しました"})
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, body)
+}
+
+// serveRawFeed 上流のフィードをそのまま返却します
+func serveRawFeed(c echo.Context, src models.FeedSource) error {
+	targetURL := src.URLs[0]
+
+	feed, err := fetcher.Get(c.Request().Context(), targetURL, usecase.FetchFeedTypes)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "FeedContent",
+			"sourceID":  src.ID,
+			"targetURL": targetURL,
+			"error":     err.Error(),
+			"errorType": "HTTPリクエストエラー",
+		}).Error("RSSフィードの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch feed"})
+	}
+
+	// 上流のContent-Typeを引き継ぐ
+	return c.Blob(http.StatusOK, feed.ContentType, feed.Body)
+}
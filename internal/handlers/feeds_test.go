@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestFeedAliasLanguage(t *testing.T) {
+	st, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.Open: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	sources := []models.FeedSource{
+		{ID: "aws", Language: models.PreferenceLanguageEN},
+		{ID: "aws-ja", Language: models.PreferenceLanguageJA},
+		{ID: "news"}, // 言語の決まっていないソース
+		{ID: "news-en", Language: models.PreferenceLanguageEN},
+		{ID: "news-ja", Language: models.PreferenceLanguageJA},
+	}
+	var items []models.FeedItem
+	for i := range sources {
+		sources[i].URLs = []string{"https://example.com/" + sources[i].ID}
+		sources[i].Output = models.FeedOutputNormalized
+		items = append(items, models.FeedItem{GUID: sources[i].ID + "-1", SourceID: sources[i].ID})
+	}
+	registry, err := usecase.NewFeedRegistry(sources)
+	if err != nil {
+		t.Fatalf("NewFeedRegistry: %v", err)
+	}
+	if _, err := st.SaveArticles(items); err != nil {
+		t.Fatalf("SaveArticles: %v", err)
+	}
+
+	user, err := st.CreateUser("alice", "", models.RoleViewer)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := st.SavePreferences(user.ID, models.UserPreferences{Language: models.PreferenceLanguageEN}); err != nil {
+		t.Fatalf("SavePreferences: %v", err)
+	}
+
+	prevRegistry, prevStore := feedRegistry, articleStore
+	SetFeedRegistry(registry)
+	SetStore(st, 0)
+	t.Cleanup(func() { feedRegistry, articleStore = prevRegistry, prevStore })
+
+	tests := []struct {
+		name  string
+		alias string
+		query string
+		want  string
+	}{
+		{name: "日本語版のエンドポイントは表示設定がenでも日本語", alias: "aws-ja", want: "aws-ja"},
+		{name: "英語版のエンドポイントは英語のまま", alias: "aws", want: "aws"},
+		{name: "?langの指定は言語版を切り替える", alias: "aws-ja", query: "?lang=en", want: "aws"},
+		{name: "言語の決まっていないソースは表示設定の言語", alias: "news", want: "news-en"},
+		{name: "言語の決まっていないソースでも?langを優先", alias: "news", query: "?lang=ja", want: "news-ja"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/feed"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			c.Set("userID", user.ID)
+
+			if err := FeedAlias(tt.alias)(c); err != nil {
+				t.Fatalf("FeedAlias: %v", err)
+			}
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+			}
+			var feed models.Feed
+			if err := json.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+			if feed.SourceID != tt.want {
+				t.Errorf("sourceId = %q, want %q", feed.SourceID, tt.want)
+			}
+		})
+	}
+}
@@ -2,161 +2,24 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"os"
-	"sort"
 	"strings"
 	"time"
 
+	"trends-summary/internal/models"
 	"trends-summary/internal/usecase"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/chromedp"
 	"github.com/google/go-github/github"
 	"github.com/labstack/echo/v4"
-	"github.com/mmcdole/gofeed"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
 )
 
-// Index はRSSフィードを取得して、HTMLまたはJSONで出力するハンドラーです。（英語版）
-func Index(c echo.Context) error {
-	logrus.WithFields(logrus.Fields{
-		"handler": "Index",
-		"method":  c.Request().Method,
-		"path":    c.Request().URL.Path,
-	}).Info("ハンドラー呼び出し")
-
-	// RSSフィードURL
-	rssURL := "https://feed.infoq.com"
-
-	// パーサーの作成
-	fp := gofeed.NewParser()
-
-	// RSSフィードを取得して解析
-	feed, err := fp.ParseURL(rssURL)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "Index",
-			"rssURL":    rssURL,
-			"error":     err.Error(),
-			"errorType": "RSSフィード取得エラー",
-		}).Error("RSSフィードの取得に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("RSSフィードの取得に失敗しました: %v", err),
-		})
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"handler":   "Index",
-		"feedTitle": feed.Title,
-		"itemCount": len(feed.Items),
-	}).Info("RSSフィード取得成功")
-
-	// フィード情報を整形
-	feedItems := []map[string]interface{}{}
-	for _, item := range feed.Items {
-		feedItems = append(feedItems, map[string]interface{}{
-			"title":       item.Title,
-			"link":        item.Link,
-			"published":   item.Published,
-			"description": item.Description,
-		})
-	}
-
-	// JSONレスポンスを返却
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"title":       feed.Title,
-		"description": feed.Description,
-		"items":       feedItems,
-	})
-}
-
-// IndexJA はRSSフィードを取得して、HTMLまたはJSONで出力するハンドラーです。（日本語版）
-func IndexJA(c echo.Context) error {
-	logrus.WithFields(logrus.Fields{
-		"handler": "IndexJA",
-		"method":  c.Request().Method,
-		"path":    c.Request().URL.Path,
-	}).Info("ハンドラー呼び出し（日本語版）")
-
-	// 複数のRSSフィードURL（日本語版トピック別）
-	rssURLs := []string{
-		"https://feed.infoq.com/jp/ai-ml-data-eng/",
-		"https://feed.infoq.com/jp/development/",
-		"https://feed.infoq.com/jp/architecture-design/",
-		"https://feed.infoq.com/jp/devops/",
-		"https://feed.infoq.com/jp/culture-methods/",
-	}
-
-	// パーサーの作成
-	fp := gofeed.NewParser()
-
-	// 全フィードからアイテムを収集
-	var allItems []*gofeed.Item
-	for _, rssURL := range rssURLs {
-		feed, err := fp.ParseURL(rssURL)
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"handler": "IndexJA",
-				"rssURL":  rssURL,
-				"error":   err.Error(),
-			}).Warn("一部のRSSフィード取得に失敗しました（スキップ）")
-			continue
-		}
-
-		logrus.WithFields(logrus.Fields{
-			"handler":   "IndexJA",
-			"rssURL":    rssURL,
-			"feedTitle": feed.Title,
-			"itemCount": len(feed.Items),
-		}).Info("RSSフィード取得成功")
-
-		allItems = append(allItems, feed.Items...)
-	}
-
-	if len(allItems) == 0 {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "すべてのRSSフィード取得に失敗しました",
-		})
-	}
-
-	// 公開日時でソート（新しい順）
-	sort.Slice(allItems, func(i, j int) bool {
-		if allItems[i].PublishedParsed == nil || allItems[j].PublishedParsed == nil {
-			return false
-		}
-		return allItems[i].PublishedParsed.After(*allItems[j].PublishedParsed)
-	})
-
-	// フィード情報を整形
-	feedItems := []map[string]interface{}{}
-	for _, item := range allItems {
-		feedItems = append(feedItems, map[string]interface{}{
-			"title":       item.Title,
-			"link":        item.Link,
-			"published":   item.Published,
-			"description": item.Description,
-		})
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"handler":       "IndexJA",
-		"totalItems":    len(allItems),
-		"feedsProcessed": len(rssURLs),
-	}).Info("全RSSフィード統合完了（日本語版）")
-
-	// JSONレスポンスを返却
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"title":       "InfoQ 日本語版（統合フィード）",
-		"description": "AI/ML、開発、アーキテクチャ、DevOps、カルチャー・メソッドの統合フィード",
-		"items":       feedItems,
-	})
-}
-
 // Repository represents a GitHub repository
 type Repository struct {
 	Name        string
@@ -165,181 +28,112 @@ type Repository struct {
 }
 
 // GitHubTrendingHandler fetches trending repositories from GitHub
+// クエリパラメータ: language（空は全言語）, since（daily/weekly/monthly）, spoken_language_code（ISO 639-1）
+// languageが未指定でユーザーの表示設定にGitHubの言語がある場合は、各言語のトレンドを設定順に連結して返します
 func GitHubTrendingHandler(c echo.Context) error {
-	targetURL := "https://github.com/trending"
+	q := trendingQueryFromRequest(c)
+	if q.Language == "" {
+		q.Languages = currentPreferences(c).GitHubLanguages
+	}
+	return serveTrending(c, q)
+}
+
+// GolangRepsitoryTrendingHandler fetches trending repositories from GitHub
+// GitHubTrendingHandler の language=go の別名です
+func GolangRepsitoryTrendingHandler(c echo.Context) error {
+	q := trendingQueryFromRequest(c)
+	q.Language = "go"
+	return serveTrending(c, q)
+}
+
+// GitHubTrendingDevelopersHandler fetches trending developers from GitHub
+func GitHubTrendingDevelopersHandler(c echo.Context) error {
+	q := trendingQueryFromRequest(c)
 	logrus.WithFields(logrus.Fields{
-		"handler":   "GitHubTrendingHandler",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
+		"handler":  "GitHubTrendingDevelopersHandler",
+		"method":   c.Request().Method,
+		"path":     c.Request().URL.Path,
+		"language": q.Language,
+		"since":    q.Since,
 	}).Info("ハンドラー呼び出し")
 
-	var trendingRepos []map[string]string
-
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	if _, err := usecase.ValidateTrendingQuery(q); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	// GitHub Trendingページをスクレイピング
-	res, err := client.Get(targetURL)
+	developers, err := usecase.ScrapeGitHubTrendingDevelopers(c.Request().Context(), fetcher, q)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "GitHubTrendingHandler",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("GitHub Trendingページの取得に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch GitHub Trending"})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch GitHub Trending developers"})
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		logrus.WithFields(logrus.Fields{
-			"handler":    "GitHubTrendingHandler",
-			"targetURL":  targetURL,
-			"statusCode": res.StatusCode,
-			"status":     res.Status,
-			"errorType":  "HTTPステータスコードエラー",
-		}).Error("GitHub Trendingページのステータスコードエラー")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "GitHub Trending page returned non-200 status"})
-	}
+	// JSONで返却
+	return c.JSON(http.StatusOK, developers)
+}
 
-	// HTMLドキュメントをパース
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "GitHubTrendingHandler",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTMLパースエラー",
-		}).Error("GitHub Trendingページのパースに失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to parse GitHub Trending page"})
+func trendingQueryFromRequest(c echo.Context) models.TrendingQuery {
+	return models.TrendingQuery{
+		Language:           c.QueryParam("language"),
+		Since:              c.QueryParam("since"),
+		SpokenLanguageCode: c.QueryParam("spoken_language_code"),
 	}
-
-	// トレンドリポジトリを抽出
-	doc.Find("article.Box-row").Each(func(i int, s *goquery.Selection) {
-		// リポジトリ名とURL
-		repoAnchor := s.Find("h2.h3 a")
-		repoName := strings.TrimSpace(repoAnchor.Text())
-		repoName = strings.ReplaceAll(repoName, "\n", " / ") // 改行を " / " に置換
-		repoURL, _ := repoAnchor.Attr("href")
-
-		// 説明
-		repoDescription := strings.TrimSpace(s.Find("p").Text())
-
-		// 言語
-		repoLanguage := strings.TrimSpace(s.Find("[itemprop='programmingLanguage']").Text())
-
-		// スター数
-		repoStars := strings.TrimSpace(s.Find("a.Link--muted").First().Text())
-
-		// リポジトリ情報を配列に追加
-		trendingRepos = append(trendingRepos, map[string]string{
-			"name":        repoName,
-			"url":         "https://github.com" + strings.TrimSpace(repoURL),
-			"description": repoDescription,
-			"language":    repoLanguage,
-			"stars":       repoStars,
-		})
-	})
-
-	logrus.WithFields(logrus.Fields{
-		"handler":           "GitHubTrendingHandler",
-		"repositoriesCount": len(trendingRepos),
-	}).Info("GitHubトレンドの取得に成功しました")
-
-	// JSONで返却
-	return c.JSON(http.StatusOK, trendingRepos)
 }
 
-// GolangRepsitoryTrendingHandler fetches trending repositories from GitHub
-func GolangRepsitoryTrendingHandler(c echo.Context) error {
-	targetURL := "https://github.com/trending/go"
+// serveTrending トレンドを取得して返却します
+func serveTrending(c echo.Context, q models.TrendingQuery) error {
 	logrus.WithFields(logrus.Fields{
-		"handler":   "GolangRepsitoryTrendingHandler",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
+		"handler":            "GitHubTrendingHandler",
+		"method":             c.Request().Method,
+		"path":               c.Request().URL.Path,
+		"language":           q.Language,
+		"languages":          q.Languages,
+		"since":              q.Since,
+		"spokenLanguageCode": q.SpokenLanguageCode,
 	}).Info("ハンドラー呼び出し")
 
-	var trendingRepos []map[string]string
-
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	q, err := usecase.ValidateTrendingQuery(q)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	// GitHub Trendingページをスクレイピング
-	res, err := client.Get(targetURL)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "GolangRepsitoryTrendingHandler",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("GitHub Trendingページの取得に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch GitHub Trending"})
+	if len(q.Languages) == 0 {
+		trendingRepos, err := fetchTrending(c.Request().Context(), q)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch GitHub Trending"})
+		}
+		return c.JSON(http.StatusOK, trendingRepos)
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		logrus.WithFields(logrus.Fields{
-			"handler":    "GolangRepsitoryTrendingHandler",
-			"targetURL":  targetURL,
-			"statusCode": res.StatusCode,
-			"status":     res.Status,
-			"errorType":  "HTTPステータスコードエラー",
-		}).Error("GitHub Trendingページのステータスコードエラー")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "GitHub Trending page returned non-200 status"})
+	// 表示設定の言語ごとに取得して1つの順位にまとめる（一部の言語の失敗はスキップ）
+	lists := [][]models.TrendingRepository{}
+	for _, language := range q.Languages {
+		lq := q
+		lq.Language = language
+		repos, err := fetchTrending(c.Request().Context(), lq)
+		if err != nil {
+			continue
+		}
+		lists = append(lists, repos)
+	}
+	if len(lists) == 0 {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch GitHub Trending"})
 	}
+	return c.JSON(http.StatusOK, usecase.MergeTrending(lists...))
+}
 
-	// HTMLドキュメントをパース
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+// fetchTrending トレンドを取得して検索インデックスに登録します
+// スナップショットは TrendingSnapshotJob が1日1回保存するため、ここでは保存しません
+func fetchTrending(ctx context.Context, q models.TrendingQuery) ([]models.TrendingRepository, error) {
+	trendingRepos, err := usecase.ScrapeGitHubTrending(ctx, fetcher, q)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "GolangRepsitoryTrendingHandler",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTMLパースエラー",
-		}).Error("GitHub Trendingページのパースに失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to parse GitHub Trending page"})
+		return nil, err
 	}
 
-	// トレンドリポジトリを抽出
-	doc.Find("article.Box-row").Each(func(i int, s *goquery.Selection) {
-		// リポジトリ名とURL
-		repoAnchor := s.Find("h2.h3 a")
-		repoName := strings.TrimSpace(repoAnchor.Text())
-		repoName = strings.ReplaceAll(repoName, "\n", " / ") // 改行を " / " に置換
-		repoURL, _ := repoAnchor.Attr("href")
-
-		// 説明
-		repoDescription := strings.TrimSpace(s.Find("p").Text())
-
-		// 言語
-		repoLanguage := strings.TrimSpace(s.Find("[itemprop='programmingLanguage']").Text())
-
-		// スター数
-		repoStars := strings.TrimSpace(s.Find("a.Link--muted").First().Text())
-
-		// リポジトリ情報を配列に追加
-		trendingRepos = append(trendingRepos, map[string]string{
-			"name":        repoName,
-			"url":         "https://github.com" + strings.TrimSpace(repoURL),
-			"description": repoDescription,
-			"language":    repoLanguage,
-			"stars":       repoStars,
-		})
-	})
-
-	logrus.WithFields(logrus.Fields{
-		"handler":           "GolangRepsitoryTrendingHandler",
-		"repositoriesCount": len(trendingRepos),
-	}).Info("Golangトレンドの取得に成功しました")
-
-	// JSONで返却
-	return c.JSON(http.StatusOK, trendingRepos)
+	sourceID := "github-trending"
+	if q.Language != "" {
+		sourceID += "-" + q.Language
+	}
+	indexRepositories(sourceID, trendingRepos)
+	return trendingRepos, nil
 }
 
 func TiobeGraph(c echo.Context) error {
@@ -418,7 +212,27 @@ func TiobeGraph(c echo.Context) error {
 	return c.JSON(http.StatusOK, graphHTML)
 }
 
+// AIArticleSummary は記事URLの内容をAIで要約するハンドラーです
 func AIArticleSummary(c echo.Context) error {
+	req, ok, err := prepareArticleSummary(c)
+	if !ok {
+		return err
+	}
+	return respondSummary(c, req)
+}
+
+// AIArticleSummaryStream は AIArticleSummary のSSE版です
+func AIArticleSummaryStream(c echo.Context) error {
+	req, ok, err := prepareArticleSummary(c)
+	if !ok {
+		return err
+	}
+	return streamSummary(c, req)
+}
+
+// prepareArticleSummary 記事を取得・本文を抽出して要約プロンプトを組み立てます
+// falseの場合はエラーレスポンスを書き込み済みです
+func prepareArticleSummary(c echo.Context) (*summaryRequest, bool, error) {
 	// クエリパラメータからURLを取得
 	urlData := c.QueryParam("url")
 	logrus.WithFields(logrus.Fields{
@@ -433,47 +247,79 @@ func AIArticleSummary(c echo.Context) error {
 			"handler":   "AIArticleSummary",
 			"errorType": "パラメータバリデーションエラー",
 		}).Error("URLパラメータが必要です")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "URLパラメータが必要です。"})
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": "URLパラメータが必要です。"})
 	}
 
-	tags := []string{".article__content"}
-	htmlData, err := usecase.ScrapeStaticPage(c, urlData, tags)
+	// 内部アドレスやサイズ超過・HTML以外のレスポンスは取得しない
+	page, err := fetcher.Get(c.Request().Context(), urlData, usecase.FetchHTMLTypes)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"handler":   "AIArticleSummary",
 			"url":       urlData,
-			"tags":      tags,
 			"error":     err.Error(),
-			"errorType": "スクレイピングエラー",
-		}).Error("記事のスクレイピングに失敗しました")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "scraping error"})
+			"errorType": "HTTPリクエストエラー",
+		}).Error("記事の取得に失敗しました")
+		if errors.Is(err, usecase.ErrFetchNotAllowed) || errors.Is(err, usecase.ErrFetchTooLarge) || errors.Is(err, usecase.ErrFetchContentType) {
+			return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": "scraping error"})
 	}
 
-	// リクエストURLの構築
-	requestText := "下記の記事の内容を簡潔に日本語で要約してください。その際に、結果から記載してください。\n" + htmlData
-	logrus.WithFields(logrus.Fields{
-		"handler":        "AIArticleSummary",
-		"url":            urlData,
-		"requestTextLen": len(requestText),
-		"scrapedDataLen": len(htmlData),
-	}).Info("Gemini APIリクエスト準備完了")
-
-	summary, err := usecase.RequestGemini(c, requestText)
+	article, err := contentExtractor.Extract(page.URL, page.ContentType, page.Body)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"handler":   "AIArticleSummary",
-			"url":       urlData,
+			"url":       page.URL,
 			"error":     err.Error(),
-			"errorType": "Gemini APIエラー",
-		}).Error("Gemini APIリクエストに失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gemini APIリクエストに失敗しました。"})
+			"errorType": "本文抽出エラー",
+		}).Error("記事の本文の抽出に失敗しました")
+		if errors.Is(err, usecase.ErrNoArticleContent) {
+			return nil, false, c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": "scraping error"})
 	}
 
-	// JSONオブジェクトとしてサマリーを返す
-	return c.JSON(http.StatusOK, map[string]string{"summary": summary})
+	requestText := usecase.BuildArticlePrompt(*article)
+	logrus.WithFields(logrus.Fields{
+		"handler":        "AIArticleSummary",
+		"url":            page.URL,
+		"title":          article.Title,
+		"requestTextLen": len(requestText),
+		"articleTextLen": len(article.Text),
+	}).Info("LLM APIリクエスト準備完了")
+
+	return &summaryRequest{
+		handler:       "AIArticleSummary",
+		endpoint:      endpointArticleSummary,
+		target:        urlData,
+		title:         article.Title,
+		subject:       urlData,
+		promptVersion: usecase.ArticlePromptVersion,
+		prompt:        requestText,
+	}, true, nil
 }
 
+// AIRepositorySummary はGitHubリポジトリのREADMEをAIで要約するハンドラーです
 func AIRepositorySummary(c echo.Context) error {
+	req, ok, err := prepareRepositorySummary(c)
+	if !ok {
+		return err
+	}
+	return respondSummary(c, req)
+}
+
+// AIRepositorySummaryStream は AIRepositorySummary のSSE版です
+func AIRepositorySummaryStream(c echo.Context) error {
+	req, ok, err := prepareRepositorySummary(c)
+	if !ok {
+		return err
+	}
+	return streamSummary(c, req)
+}
+
+// prepareRepositorySummary リポジトリ情報とREADMEを取得して要約プロンプトを組み立てます
+// falseの場合はエラーレスポンスを書き込み済みです
+func prepareRepositorySummary(c echo.Context) (*summaryRequest, bool, error) {
 	// クエリパラメータからURLを取得
 	urlData := c.QueryParam("url")
 	logrus.WithFields(logrus.Fields{
@@ -488,7 +334,7 @@ func AIRepositorySummary(c echo.Context) error {
 			"handler":   "AIRepositorySummary",
 			"errorType": "パラメータバリデーションエラー",
 		}).Error("URLパラメータが必要です")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "URLパラメータが必要です。"})
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": "URLパラメータが必要です。"})
 	}
 
 	parsedURL, err := url.Parse(urlData)
@@ -499,7 +345,7 @@ func AIRepositorySummary(c echo.Context) error {
 			"error":     err.Error(),
 			"errorType": "URL解析エラー",
 		}).Error("URLの解析に失敗しました")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "URL解析エラー"})
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": "URL解析エラー"})
 	}
 
 	parts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
@@ -510,7 +356,7 @@ func AIRepositorySummary(c echo.Context) error {
 			"parts":     parts,
 			"errorType": "URLフォーマットエラー",
 		}).Error("URLが正しい形式ではありません（owner/repo形式が必要）")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "URLが正しい形式ではありません"})
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": "URLが正しい形式ではありません"})
 	}
 	owner := parts[0]
 	repoName := parts[1]
@@ -532,7 +378,7 @@ func AIRepositorySummary(c echo.Context) error {
 			"handler":   "AIRepositorySummary",
 			"errorType": "環境変数エラー",
 		}).Error("環境変数 GITHUB_OAUTH_TOKEN が設定されていません")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "GitHub OAuth トークンが設定されていません"})
+		return nil, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "GitHub OAuth トークンが設定されていません"})
 	}
 
 	// OAuth2 クライアントの作成
@@ -552,7 +398,7 @@ func AIRepositorySummary(c echo.Context) error {
 			"error":     err.Error(),
 			"errorType": "GitHub APIエラー",
 		}).Error("リポジトリ情報の取得に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "リポジトリ情報の取得に失敗しました"})
+		return nil, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "リポジトリ情報の取得に失敗しました"})
 	}
 
 	var builder strings.Builder
@@ -577,7 +423,7 @@ func AIRepositorySummary(c echo.Context) error {
 			"error":     err.Error(),
 			"errorType": "GitHub APIエラー",
 		}).Error("READMEの取得に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "README の取得に失敗しました"})
+		return nil, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "README の取得に失敗しました"})
 	}
 
 	// READMEの内容をデコード（go-github の GetContent メソッドを使用）
@@ -590,7 +436,7 @@ func AIRepositorySummary(c echo.Context) error {
 			"error":     err.Error(),
 			"errorType": "READMEデコードエラー",
 		}).Error("READMEのデコードに失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "README のデコードに失敗しました"})
+		return nil, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "README のデコードに失敗しました"})
 	}
 
 	builder.WriteString(fmt.Sprintf("==== README 内容 ==== %s", readmeContent))
@@ -604,479 +450,161 @@ func AIRepositorySummary(c echo.Context) error {
 		"repoName":       repoName,
 		"requestTextLen": len(requestText),
 		"repoDataLen":    len(repoData),
-	}).Info("Gemini APIリクエスト準備完了")
+	}).Info("LLM APIリクエスト準備完了")
+
+	return &summaryRequest{
+		handler:       "AIRepositorySummary",
+		endpoint:      endpointRepositorySummary,
+		target:        urlData,
+		title:         repo.GetFullName(),
+		subject:       strings.ToLower(owner + "/" + repoName),
+		promptVersion: "v1",
+		prompt:        requestText,
+	}, true, nil
+}
 
-	summary, err := usecase.RequestGemini(c, requestText)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AIRepositorySummary",
-			"owner":     owner,
-			"repoName":  repoName,
-			"error":     err.Error(),
-			"errorType": "Gemini APIエラー",
-		}).Error("Gemini APIリクエストに失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gemini APIリクエストに失敗しました。"})
+// AITrendsSummary はトレンド全体をAIで要約するハンドラーです
+// クエリパラメータ: date(YYYY-MM-DD, 既定は今日), sources(infoq,github-trending,golang-weekly のカンマ区切り, 既定は全て)
+// 元データはサーバー側で収集します（POSTのリクエストボディは互換性のため受け付けますが使用しません）
+func AITrendsSummary(c echo.Context) error {
+	req, ok, err := prepareTrendsSummary(c)
+	if !ok {
+		return err
 	}
-
-	logrus.WithFields(logrus.Fields{
-		"handler":    "AIRepositorySummary",
-		"owner":      owner,
-		"repoName":   repoName,
-		"summaryLen": len(summary),
-	}).Info("リポジトリ要約生成成功")
-
-	// JSONオブジェクトとしてサマリーを返す
-	return c.JSON(http.StatusOK, map[string]string{"summary": summary})
+	return respondSummary(c, req)
 }
 
-// リクエストボディの構造体を定義
-type RequestData struct {
-	Data string `json:"data"`
+// AITrendsSummaryStream は AITrendsSummary のSSE版です
+func AITrendsSummaryStream(c echo.Context) error {
+	req, ok, err := prepareTrendsSummary(c)
+	if !ok {
+		return err
+	}
+	return streamSummary(c, req)
 }
 
-func AITrendsSummary(c echo.Context) error {
+// prepareTrendsSummary 指定日のトレンドデータをサーバー側で集めて要約プロンプトを組み立てます
+// falseの場合はエラーレスポンスを書き込み済みです
+func prepareTrendsSummary(c echo.Context) (*summaryRequest, bool, error) {
 	logrus.WithFields(logrus.Fields{
 		"handler": "AITrendsSummary",
 		"method":  c.Request().Method,
 		"path":    c.Request().URL.Path,
+		"date":    c.QueryParam("date"),
+		"sources": c.QueryParam("sources"),
 	}).Info("ハンドラー呼び出し")
 
-	// JSONボディを構造体にバインドする
-	var reqData RequestData
-	if err := c.Bind(&reqData); err != nil {
+	q, err := usecase.ParseDigestQuery(c.QueryParam("date"), c.QueryParam("sources"))
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"handler":   "AITrendsSummary",
 			"error":     err.Error(),
-			"errorType": "リクエストバインドエラー",
-		}).Error("リクエストデータのバインドに失敗しました")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "リクエストデータが無効です"})
-	}
-	pageData := reqData.Data
-
-	logrus.WithFields(logrus.Fields{
-		"handler":     "AITrendsSummary",
-		"pageDataLen": len(pageData),
-	}).Info("リクエストデータ受信")
-
-	if pageData == "" {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AITrendsSummary",
 			"errorType": "パラメータバリデーションエラー",
-		}).Error("dataパラメータが必要です")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dataパラメータが必要です。"})
+		}).Error("ダイジェストの条件が不正です")
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	// pageData を goquery.Document に変換
-	reader := strings.NewReader(pageData)
-	doc, err := goquery.NewDocumentFromReader(reader)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AITrendsSummary",
-			"error":     err.Error(),
-			"errorType": "HTMLパースエラー",
-		}).Error("goqueryドキュメント作成に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "HTMLの解析に失敗しました"})
+	input, err := usecase.BuildDigestInput(c.Request().Context(), fetcher, articleStore, feedRegistry, q)
+	if errors.Is(err, usecase.ErrDigestNoData) {
+		return nil, false, c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
 	}
-
-	tags := []string{"#rss-feed-table tr", "#github-trending-table tr", "#golangweekly-container"}
-	getData, err := usecase.GetTagDataFromHTML(doc, tags)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"handler":   "AITrendsSummary",
-			"tags":      tags,
+			"date":      q.Date,
 			"error":     err.Error(),
-			"errorType": "HTMLタグ抽出エラー",
-		}).Error("HTMLタグデータの抽出に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "HTMLの解析に失敗しました。2"})
+			"errorType": "データ収集エラー",
+		}).Error("ダイジェストの元データの収集に失敗しました")
+		return nil, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "トレンドデータの収集に失敗しました"})
 	}
 
-	requestText := "下記は最新のIT業界のNews一覧です。後述の項目に沿って要約してMarkdown形式で回答してください。・全てのデータから読み取れる傾向と推測される理由 ・InfoQから読み取れる傾向と推測される理由 ・Github daily trendsから読み取れる傾向と推測される理由・golangWeeklyから読み取れる傾向と推測される理由\n" + getData
+	requestText := usecase.BuildDigestPrompt(input)
 	logrus.WithFields(logrus.Fields{
-		"handler":        "AITrendsSummary",
-		"requestTextLen": len(requestText),
-		"extractedLen":   len(getData),
-	}).Info("Gemini APIリクエスト準備完了")
-
-	summary, err := usecase.RequestGemini(c, requestText)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AITrendsSummary",
-			"error":     err.Error(),
-			"errorType": "Gemini APIエラー",
-		}).Error("Gemini APIリクエストに失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gemini APIリクエストに失敗しました。"})
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"handler":    "AITrendsSummary",
-		"summaryLen": len(summary),
-	}).Info("トレンド要約生成成功")
-
-	// JSONオブジェクトとしてサマリーを返す
-	return c.JSON(http.StatusOK, map[string]string{"summary": summary})
+		"handler":             "AITrendsSummary",
+		"date":                q.Date,
+		"requestTextLen":      len(requestText),
+		"infoqCount":          len(input.InfoQ),
+		"githubTrendingCount": len(input.GitHubTrending),
+		"golangWeeklyCount":   len(input.GolangWeekly),
+	}).Info("LLM APIリクエスト準備完了")
+
+	return &summaryRequest{
+		handler:       "AITrendsSummary",
+		endpoint:      endpointTrendsSummary,
+		subject:       "digest:" + q.Date + ":" + strings.Join(q.Sources, ","),
+		promptVersion: usecase.DigestPromptVersion,
+		prompt:        requestText,
+	}, true, nil
 }
 
-func GolangWeeklyContent(c echo.Context) error {
-	targetURL := "https://golangweekly.com/rss/"
-	logrus.WithFields(logrus.Fields{
-		"handler":   "GolangWeeklyContent",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
-	}).Info("ハンドラー呼び出し")
-
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	resp, err := client.Get(targetURL)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "GolangWeeklyContent",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("GolangWeekly RSSフィードの取得に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch feed"})
-	}
-	defer resp.Body.Close()
-
-	// Echo の Response を取得
-	res := c.Response()
-
-	// 取得した RSS のレスポンスヘッダーをそのまま転送
-	for k, values := range resp.Header {
-		for _, v := range values {
-			res.Header().Add(k, v)
-		}
-	}
-	res.WriteHeader(resp.StatusCode)
-
-	// Body の内容を転送
-	if _, err := io.Copy(res, resp.Body); err != nil {
+// PersonalDigest はログイン中のユーザーの表示設定（ソース・言語・キーワード・GitHubの言語）に合わせたダイジェストを返すハンドラーです
+// クエリパラメータ: date(YYYY-MM-DD, 既定は今日)
+func PersonalDigest(c echo.Context) error {
+	req, ok, err := preparePersonalDigest(c)
+	if !ok {
 		return err
 	}
-	return nil
+	return respondSummary(c, req)
 }
 
-func GoogleCloudContent(c echo.Context) error {
-	targetURL := "https://cloudblog.withgoogle.com/products/gcp/rss/"
-	logrus.WithFields(logrus.Fields{
-		"handler":   "GoogleCloudContent",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
-	}).Info("ハンドラー呼び出し")
-
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	resp, err := client.Get(targetURL)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "GoogleCloudContent",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("Google Cloud RSSフィードの取得に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch feed"})
-	}
-	defer resp.Body.Close()
-
-	// Echo の Response を取得
-	res := c.Response()
-
-	// 取得した RSS のレスポンスヘッダーをそのまま転送
-	for k, values := range resp.Header {
-		for _, v := range values {
-			res.Header().Add(k, v)
-		}
-	}
-	res.WriteHeader(resp.StatusCode)
-
-	// Body の内容を転送
-	if _, err := io.Copy(res, resp.Body); err != nil {
+// PersonalDigestStream は表示設定に合わせたダイジェストをServer-Sent Eventsで返すハンドラーです
+func PersonalDigestStream(c echo.Context) error {
+	req, ok, err := preparePersonalDigest(c)
+	if !ok {
 		return err
 	}
-	return nil
+	return streamSummary(c, req)
 }
-func AWSContent(c echo.Context) error {
-	targetURL := "https://aws.amazon.com/blogs/aws/feed/"
-	logrus.WithFields(logrus.Fields{
-		"handler":   "AWSContent",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
-	}).Info("ハンドラー呼び出し")
-
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	// AWSのRSSフィードを取得
-	resp, err := client.Get(targetURL)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AWSContent",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("AWS RSSフィードの取得に失敗しました")
-		return c.String(http.StatusInternalServerError, "Error fetching AWS feed")
-	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		logrus.WithFields(logrus.Fields{
-			"handler":    "AWSContent",
-			"targetURL":  targetURL,
-			"statusCode": resp.StatusCode,
-			"status":     resp.Status,
-			"errorType":  "HTTPステータスコードエラー",
-		}).Error("AWS RSSフィードのステータスコードエラー")
-		return c.String(http.StatusInternalServerError, "Error fetching AWS feed: "+resp.Status)
-	}
-
-	// レスポンスボディを読み込み
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AWSContent",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "レスポンス読み込みエラー",
-		}).Error("AWS RSSフィードの読み込みに失敗しました")
-		return c.String(http.StatusInternalServerError, "Error reading feed")
-	}
-
-	// XMLとして返す（Content-Typeをapplication/xmlに設定）
-	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXML)
-	return c.String(http.StatusOK, string(body))
-}
-// GoogleCloudContentJA は日本語版のGCP RSSフィードを取得します
-func GoogleCloudContentJA(c echo.Context) error {
-	targetURL := "https://cloudblog.withgoogle.com/ja/products/gcp/rss/"
+func preparePersonalDigest(c echo.Context) (*summaryRequest, bool, error) {
 	logrus.WithFields(logrus.Fields{
-		"handler":   "GoogleCloudContentJA",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
-	}).Info("ハンドラー呼び出し（日本語版）")
-
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	// GCP RSSフィードを取得
-	resp, err := client.Get(targetURL)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "GoogleCloudContentJA",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("Google Cloud RSSフィードの取得に失敗しました")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch feed"})
-	}
-	defer resp.Body.Close()
-
-	// Echo の Response を取得
-	res := c.Response()
-
-	// 取得した RSS のレスポンスヘッダーをそのまま転送
-	for k, values := range resp.Header {
-		for _, v := range values {
-			res.Header().Add(k, v)
-		}
-	}
-	res.WriteHeader(resp.StatusCode)
-
-	// Body の内容を転送
-	if _, err := io.Copy(res, resp.Body); err != nil {
-		return err
-	}
-	return nil
-}
-
-// AWSContentJA は日本語版のAWS RSSフィードを取得します
-func AWSContentJA(c echo.Context) error {
-	targetURL := "https://aws.amazon.com/jp/blogs/news/feed/"
-	logrus.WithFields(logrus.Fields{
-		"handler":   "AWSContentJA",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
-	}).Info("ハンドラー呼び出し（日本語版）")
-
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	// AWSのRSSフィードを取得
-	resp, err := client.Get(targetURL)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AWSContentJA",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("AWS RSSフィードの取得に失敗しました")
-		return c.String(http.StatusInternalServerError, "Error fetching AWS feed")
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logrus.WithFields(logrus.Fields{
-			"handler":    "AWSContentJA",
-			"targetURL":  targetURL,
-			"statusCode": resp.StatusCode,
-			"status":     resp.Status,
-			"errorType":  "HTTPステータスコードエラー",
-		}).Error("AWS RSSフィードのステータスコードエラー")
-		return c.String(http.StatusInternalServerError, "Error fetching AWS feed: "+resp.Status)
-	}
-
-	// レスポンスボディを読み込み
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AWSContentJA",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "レスポンス読み込みエラー",
-		}).Error("AWS RSSフィードの読み込みに失敗しました")
-		return c.String(http.StatusInternalServerError, "Error reading feed")
-	}
-
-	// XMLとして返す（Content-Typeをapplication/xmlに設定）
-	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXML)
-	return c.String(http.StatusOK, string(body))
-}
-
-// AzureContent は英語版のAzure RSSフィードを取得します
-func AzureContent(c echo.Context) error {
-	targetURL := "https://azure.microsoft.com/en-us/blog/feed/"
-	logrus.WithFields(logrus.Fields{
-		"handler":   "AzureContent",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
+		"handler": "PersonalDigest",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"date":    c.QueryParam("date"),
 	}).Info("ハンドラー呼び出し")
 
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	// AzureRSSフィードを取得
-	resp, err := client.Get(targetURL)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AzureContent",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("Azure RSSフィードの取得に失敗しました")
-		return c.String(http.StatusInternalServerError, "Error fetching Azure feed")
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logrus.WithFields(logrus.Fields{
-			"handler":    "AzureContent",
-			"targetURL":  targetURL,
-			"statusCode": resp.StatusCode,
-			"status":     resp.Status,
-			"errorType":  "HTTPステータスコードエラー",
-		}).Error("Azure RSSフィードのステータスコードエラー")
-		return c.String(http.StatusInternalServerError, "Error fetching Azure feed: "+resp.Status)
-	}
-
-	// レスポンスボディを読み込み
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"handler":   "AzureContent",
-			"targetURL": targetURL,
-			"error":     err.Error(),
-			"errorType": "レスポンス読み込みエラー",
-		}).Error("Azure RSSフィードの読み込みに失敗しました")
-		return c.String(http.StatusInternalServerError, "Error reading feed")
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"handler":  "AzureContent",
-		"bodySize": len(body),
-	}).Info("Azure RSSフィード取得成功")
-
-	// XMLとして返す（Content-Typeをapplication/xmlに設定）
-	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXML)
-	return c.String(http.StatusOK, string(body))
-}
-
-// AzureContentJA は日本語版のAzure RSSフィードを取得します
-func AzureContentJA(c echo.Context) error {
-	targetURL := "https://news.microsoft.com/ja-jp?feed=rss2"
-	logrus.WithFields(logrus.Fields{
-		"handler":   "AzureContentJA",
-		"method":    c.Request().Method,
-		"path":      c.Request().URL.Path,
-		"targetURL": targetURL,
-	}).Info("ハンドラー呼び出し（日本語版）")
-
-	// タイムアウト付きHTTPクライアントを作成
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	// AzureRSSフィードを取得
-	resp, err := client.Get(targetURL)
+	q, err := usecase.ParseDigestQuery(c.QueryParam("date"), "")
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"handler":   "AzureContentJA",
-			"targetURL": targetURL,
+			"handler":   "PersonalDigest",
 			"error":     err.Error(),
-			"errorType": "HTTPリクエストエラー",
-		}).Error("Azure RSSフィードの取得に失敗しました")
-		return c.String(http.StatusInternalServerError, "Error fetching Azure feed")
+			"errorType": "パラメータバリデーションエラー",
+		}).Error("ダイジェストの条件が不正です")
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		logrus.WithFields(logrus.Fields{
-			"handler":    "AzureContentJA",
-			"targetURL":  targetURL,
-			"statusCode": resp.StatusCode,
-			"status":     resp.Status,
-			"errorType":  "HTTPステータスコードエラー",
-		}).Error("Azure RSSフィードのステータスコードエラー")
-		return c.String(http.StatusInternalServerError, "Error fetching Azure feed: "+resp.Status)
+	prefs := currentPreferences(c)
+	input, err := usecase.BuildPersonalDigestInput(c.Request().Context(), fetcher, articleStore, feedRegistry, q, prefs)
+	if errors.Is(err, usecase.ErrDigestNoData) {
+		return nil, false, c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
 	}
-
-	// レスポンスボディを読み込み
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"handler":   "AzureContentJA",
-			"targetURL": targetURL,
+			"handler":   "PersonalDigest",
+			"date":      q.Date,
 			"error":     err.Error(),
-			"errorType": "レスポンス読み込みエラー",
-		}).Error("Azure RSSフィードの読み込みに失敗しました")
-		return c.String(http.StatusInternalServerError, "Error reading feed")
+			"errorType": "データ収集エラー",
+		}).Error("ダイジェストの元データの収集に失敗しました")
+		return nil, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "トレンドデータの収集に失敗しました"})
 	}
 
+	requestText := usecase.BuildPersonalDigestPrompt(input, prefs)
 	logrus.WithFields(logrus.Fields{
-		"handler":  "AzureContentJA",
-		"bodySize": len(body),
-	}).Info("Azure RSSフィード取得成功（日本語版）")
-
-	// XMLとして返す（Content-Typeをapplication/xmlに設定）
-	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXML)
-	return c.String(http.StatusOK, string(body))
+		"handler":             "PersonalDigest",
+		"date":                input.Date,
+		"requestTextLen":      len(requestText),
+		"infoqCount":          len(input.InfoQ),
+		"githubTrendingCount": len(input.GitHubTrending),
+		"golangWeeklyCount":   len(input.GolangWeekly),
+	}).Info("LLM APIリクエスト準備完了")
+
+	// 同じ内容のプロンプトであればユーザーをまたいでキャッシュを共有する
+	return &summaryRequest{
+		handler:       "PersonalDigest",
+		endpoint:      endpointTrendsSummary,
+		subject:       "digest:" + input.Date + ":personal",
+		promptVersion: usecase.DigestPromptVersion,
+		prompt:        requestText,
+	}, true, nil
 }
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// OIDCのログイン開始からコールバックまでstate・nonce・code_verifierを保持するCookie
+const (
+	oidcStateCookieName = "oidc_state"
+	oidcStateCookiePath = "/trends-summary/api/oidc"
+	oidcStateTTL        = 10 * time.Minute
+)
+
+// oidcLoginRedirect ログイン完了・失敗後に戻る画面
+const oidcLoginRedirect = "/trends-summary/"
+
+// LoginOptions ログイン画面に表示するログイン方法を返すハンドラー
+func LoginOptions(c echo.Context) error {
+	options := map[string]interface{}{
+		"oidc": oidcClient != nil,
+	}
+	if oidcClient != nil {
+		options["oidcName"] = oidcClient.Name()
+	}
+	return c.JSON(http.StatusOK, options)
+}
+
+// OIDCLogin IdPの認可画面へリダイレクトしてシングルサインオンを開始するハンドラー
+func OIDCLogin(c echo.Context) error {
+	if oidcClient == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "シングルサインオンは有効になっていません"})
+	}
+
+	authURL, saved, err := oidcClient.Begin()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "OIDCLogin",
+			"error":     err.Error(),
+			"errorType": "IdP接続エラー",
+		}).Error("シングルサインオンの開始に失敗しました")
+		return oidcLoginFailed(c, "シングルサインオンを開始できませんでした")
+	}
+
+	// IdPからのリダイレクト（クロスサイトのトップレベル遷移）でも送信されるようLaxにする
+	cookie := authCookie(oidcStateCookieName, saved, oidcStateCookiePath, time.Now().Add(oidcStateTTL))
+	cookie.SameSite = http.SameSiteLaxMode
+	c.SetCookie(cookie)
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback IdPからのリダイレクトを受けて認可コードを検証し、
+// 対応するローカルユーザーでパスワードログインと同じ認証Cookieを発行するハンドラー
+func OIDCCallback(c echo.Context) error {
+	if oidcClient == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "シングルサインオンは有効になっていません"})
+	}
+
+	var saved string
+	if cookie, err := c.Cookie(oidcStateCookieName); err == nil {
+		saved = cookie.Value
+	}
+	// stateは1回限り有効
+	c.SetCookie(authCookie(oidcStateCookieName, "", oidcStateCookiePath, time.Now().Add(-1*time.Hour)))
+
+	if idpError := c.QueryParam("error"); idpError != "" {
+		logrus.WithFields(logrus.Fields{
+			"handler":     "OIDCCallback",
+			"error":       idpError,
+			"description": c.QueryParam("error_description"),
+		}).Warn("IdPがログインを拒否しました")
+		return oidcLoginFailed(c, "シングルサインオンがキャンセルまたは拒否されました")
+	}
+
+	identity, err := oidcClient.Finish(c.Request().Context(), saved, c.QueryParam("state"), c.QueryParam("code"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrOIDCInvalidState) {
+			logrus.WithFields(logrus.Fields{
+				"handler": "OIDCCallback",
+				"ip":      c.RealIP(),
+			}).Warn("OIDCのstateが一致しません")
+			return oidcLoginFailed(c, err.Error())
+		}
+		logrus.WithFields(logrus.Fields{
+			"handler":   "OIDCCallback",
+			"error":     err.Error(),
+			"errorType": "IdP認証エラー",
+		}).Error("シングルサインオンの検証に失敗しました")
+		return oidcLoginFailed(c, "シングルサインオンに失敗しました")
+	}
+
+	user, err := oidcClient.ResolveUser(articleStore, identity)
+	if err != nil {
+		if errors.Is(err, usecase.ErrOIDCNotAllowed) || errors.Is(err, usecase.ErrOIDCUserNotFound) || errors.Is(err, usecase.ErrUserDisabled) {
+			logrus.WithFields(logrus.Fields{
+				"handler":  "OIDCCallback",
+				"issuer":   identity.Issuer,
+				"subject":  identity.Subject,
+				"username": identity.Username,
+				"error":    err.Error(),
+			}).Warn("シングルサインオンのログイン拒否")
+			recordAudit(c, models.AuditEvent{Event: models.AuditEventLoginFailure, Username: identity.Username, Detail: "oidc: " + err.Error()})
+			return oidcLoginFailed(c, err.Error())
+		}
+		logrus.WithFields(logrus.Fields{
+			"handler":   "OIDCCallback",
+			"username":  identity.Username,
+			"error":     err.Error(),
+			"errorType": "DB保存エラー",
+		}).Error("シングルサインオンのユーザー解決に失敗しました")
+		return oidcLoginFailed(c, "ログインに失敗しました")
+	}
+
+	session, refreshToken, err := usecase.StartSession(articleStore, user.ID, c.Request().UserAgent(), c.RealIP(), refreshTokenTTL)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler": "OIDCCallback",
+			"error":   err.Error(),
+		}).Error("セッション作成エラー")
+		return oidcLoginFailed(c, "認証トークンの生成に失敗しました")
+	}
+	if _, ok, err := issueTokens(c, user, session, refreshToken); !ok {
+		return err
+	}
+
+	recordAudit(c, models.AuditEvent{Event: models.AuditEventLogin, UserID: user.ID, Username: user.Username, Detail: "oidc " + identity.Issuer})
+	logrus.WithFields(logrus.Fields{
+		"handler":   "OIDCCallback",
+		"username":  user.Username,
+		"issuer":    identity.Issuer,
+		"sessionID": session.ID,
+	}).Info("シングルサインオンでログイン成功")
+	return c.Redirect(http.StatusFound, oidcLoginRedirect)
+}
+
+// oidcLoginFailed ログイン画面にエラーメッセージを渡してリダイレクトします
+func oidcLoginFailed(c echo.Context, message string) error {
+	return c.Redirect(http.StatusFound, oidcLoginRedirect+"?login_error="+url.QueryEscape(message))
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// 集約フィードの出力形式
+const (
+	outgoingFeedRSS  = "rss"
+	outgoingFeedAtom = "atom"
+	outgoingFeedJSON = "json"
+)
+
+// OutgoingFeed は全ソースを集約したフィードを指定の形式で返すハンドラーを返します
+// クエリパラメータ: source(フィードIDのカンマ区切り, 既定は全て), limit, summaries(false でAI要約を付与しない)
+func OutgoingFeed(format string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		logrus.WithFields(logrus.Fields{
+			"handler": "OutgoingFeed",
+			"method":  c.Request().Method,
+			"path":    c.Request().URL.Path,
+			"format":  format,
+			"source":  c.QueryParam("source"),
+		}).Info("ハンドラー呼び出し")
+
+		q := models.AggregatedFeedQuery{
+			Limit:     outgoingFeedLimit,
+			Summaries: c.QueryParam("summaries") != "false",
+		}
+		if v := c.QueryParam("source"); v != "" {
+			for _, id := range strings.Split(v, ",") {
+				id = strings.TrimSpace(id)
+				if _, ok := feedRegistry.Get(id); !ok {
+					return c.JSON(http.StatusBadRequest, map[string]string{"error": "フィードソースが見つかりません: " + id})
+				}
+				q.Sources = append(q.Sources, id)
+			}
+		}
+		if v := c.QueryParam("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 500 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "limitパラメータは1〜500で指定してください"})
+			}
+			q.Limit = n
+		}
+
+		items, err := usecase.BuildAggregatedFeed(articleStore, q)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"handler":   "OutgoingFeed",
+				"error":     err.Error(),
+				"errorType": "DB取得エラー",
+			}).Error("集約フィードの取得に失敗しました")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "フィードの取得に失敗しました"})
+		}
+		feed := models.Feed{
+			SourceID:    "trends-summary",
+			Title:       outgoingFeedTitle,
+			Description: outgoingFeedDescription,
+			Items:       items,
+		}
+
+		// トークンを含まない自身のURL
+		base := c.Scheme() + "://" + c.Request().Host
+		selfURL := base + c.Request().URL.Path
+		homeURL := base + "/trends-summary/"
+
+		var body []byte
+		contentType := echo.MIMEApplicationXMLCharsetUTF8
+		switch format {
+		case outgoingFeedAtom:
+			body, err = usecase.RenderAtom(feed, selfURL, homeURL)
+			contentType = "application/atom+xml; charset=UTF-8"
+		case outgoingFeedJSON:
+			body, err = usecase.RenderJSONFeed(feed, selfURL, homeURL)
+			contentType = "application/feed+json; charset=UTF-8"
+		default:
+			body, err = usecase.RenderRSS(feed, homeURL)
+		}
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"handler":   "OutgoingFeed",
+				"format":    format,
+				"error":     err.Error(),
+				"errorType": "フィード生成エラー",
+			}).Error("集約フィードの生成に失敗しました")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "フィードの生成に失敗しました"})
+		}
+
+		return c.Blob(http.StatusOK, contentType, body)
+	}
+}
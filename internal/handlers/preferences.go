@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Preferences はログイン中のユーザーの表示設定を返すハンドラーです
+func Preferences(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "Preferences",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	userID, _ := c.Get("userID").(int64)
+	prefs, err := articleStore.GetPreferences(userID)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "Preferences",
+			"userID":    userID,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("表示設定の取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "表示設定の取得に失敗しました"})
+	}
+	return c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences はログイン中のユーザーの表示設定を置き換えるハンドラーです
+func UpdatePreferences(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "UpdatePreferences",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	var req models.UserPreferences
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "リクエストデータが無効です"})
+	}
+	prefs, err := usecase.NormalizePreferences(feedRegistry, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	userID, _ := c.Get("userID").(int64)
+	prefs, err = articleStore.SavePreferences(userID, prefs)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "UpdatePreferences",
+			"userID":    userID,
+			"error":     err.Error(),
+			"errorType": "DB保存エラー",
+		}).Error("表示設定の保存に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "表示設定の保存に失敗しました"})
+	}
+	return c.JSON(http.StatusOK, prefs)
+}
+
+// currentPreferences ログイン中のユーザーの表示設定を返します
+// ユーザーに紐づかないアクセス（フィードトークン）や取得に失敗した場合は絞り込みなしの設定を返します
+func currentPreferences(c echo.Context) models.UserPreferences {
+	userID, ok := c.Get("userID").(int64)
+	if !ok || articleStore == nil {
+		return models.UserPreferences{}
+	}
+	prefs, err := articleStore.GetPreferences(userID)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"userID": userID,
+			"path":   c.Request().URL.Path,
+			"error":  err.Error(),
+		}).Warn("表示設定の取得に失敗したため絞り込みなしで返します")
+		return models.UserPreferences{}
+	}
+	return prefs
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Search は収集済みの記事・リポジトリ・AI要約を全文検索するハンドラーです
+// クエリパラメータ: q, kind(article|repository|summary), source, language(en|ja), from, to(YYYY-MM-DD), page, per_page
+func Search(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "Search",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"query":   c.QueryParam("q"),
+	}).Info("ハンドラー呼び出し")
+
+	q := models.SearchQuery{
+		Query:    strings.TrimSpace(c.QueryParam("q")),
+		Kind:     c.QueryParam("kind"),
+		SourceID: c.QueryParam("source"),
+		Language: c.QueryParam("language"),
+		From:     c.QueryParam("from"),
+		To:       c.QueryParam("to"),
+		Page:     1,
+		PerPage:  20,
+	}
+
+	for _, d := range []string{q.From, q.To} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "日付はYYYY-MM-DD形式で指定してください"})
+		}
+	}
+	if v := c.QueryParam("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "pageパラメータが不正です"})
+		}
+		q.Page = page
+	}
+	if v := c.QueryParam("per_page"); v != "" {
+		perPage, err := strconv.Atoi(v)
+		if err != nil || perPage < 1 || perPage > 100 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "per_pageパラメータは1〜100で指定してください"})
+		}
+		q.PerPage = perPage
+	}
+
+	result, err := articleStore.Search(q)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "Search",
+			"query":     q.Query,
+			"error":     err.Error(),
+			"errorType": "検索エラー",
+		}).Error("検索に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "検索に失敗しました"})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// indexRepositories トレンドリポジトリを検索インデックスに登録します
+func indexRepositories(sourceID string, repos []models.TrendingRepository) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, repo := range repos {
+		err := articleStore.IndexDocument(models.SearchDocument{
+			Kind:      models.SearchKindRepository,
+			Ref:       sourceID + ":" + repo.Name,
+			SourceID:  sourceID,
+			Link:      repo.URL,
+			Published: now,
+			Title:     repo.Name,
+			Body:      repo.Description,
+		})
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "indexRepositories",
+				"url":      repo.URL,
+				"error":    err.Error(),
+			}).Warn("リポジトリの検索インデックス登録に失敗しました")
+		}
+	}
+}
+
+// indexSummary AI要約を要約対象のタイトルで検索インデックスに登録します（要約は日本語で生成される）
+func indexSummary(sourceID, link, title, summary string) {
+	err := articleStore.IndexDocument(models.SearchDocument{
+		Kind:      models.SearchKindSummary,
+		Ref:       sourceID + ":" + link,
+		SourceID:  sourceID,
+		Language:  "ja",
+		Link:      link,
+		Published: time.Now().UTC().Format(time.RFC3339),
+		Title:     title,
+		Body:      summary,
+	})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "indexSummary",
+			"link":     link,
+			"error":    err.Error(),
+		}).Warn("AI要約の検索インデックス登録に失敗しました")
+	}
+}
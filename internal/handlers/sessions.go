@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"trends-summary/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Sessions はログイン中のユーザーの有効なセッションを返すハンドラーです（リクエスト元は current=true）
+func Sessions(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "Sessions",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	userID, _ := c.Get("userID").(int64)
+	sessions, err := articleStore.ListSessions(userID)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "Sessions",
+			"userID":    userID,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("セッションの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "セッションの取得に失敗しました"})
+	}
+
+	current, _ := c.Get("sessionID").(string)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// RevokeSession はログイン中のユーザーのセッションを失効させるハンドラーです
+// 失効したセッションのアクセストークンとリフレッシュトークンは直ちに使えなくなります
+func RevokeSession(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "RevokeSession",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	userID, _ := c.Get("userID").(int64)
+	revoked, err := articleStore.RevokeSession(userID, c.Param("id"))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "RevokeSession",
+			"userID":    userID,
+			"error":     err.Error(),
+			"errorType": "DB更新エラー",
+		}).Error("セッションの失効に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "セッションの失効に失敗しました"})
+	}
+	if !revoked {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "セッションが見つかりません"})
+	}
+	username, _ := c.Get("username").(string)
+	recordAudit(c, models.AuditEvent{Event: models.AuditEventSessionRevoked, UserID: userID, Username: username, Detail: "session=" + c.Param("id")})
+	if current, _ := c.Get("sessionID").(string); current == c.Param("id") {
+		clearAuthCookies(c)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeOtherSessions はリクエスト元以外のセッションをすべて失効させるハンドラーです
+func RevokeOtherSessions(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "RevokeOtherSessions",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	userID, _ := c.Get("userID").(int64)
+	current, _ := c.Get("sessionID").(string)
+	revoked, err := articleStore.RevokeUserSessions(userID, current)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "RevokeOtherSessions",
+			"userID":    userID,
+			"error":     err.Error(),
+			"errorType": "DB更新エラー",
+		}).Error("セッションの失効に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "セッションの失効に失敗しました"})
+	}
+	if revoked > 0 {
+		username, _ := c.Get("username").(string)
+		recordAudit(c, models.AuditEvent{Event: models.AuditEventSessionRevoked, UserID: userID, Username: username, Detail: fmt.Sprintf("others=%d", revoked)})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"revoked": revoked})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"time"
+
+	"trends-summary/internal/store"
+	"trends-summary/internal/usecase"
+)
+
+// AI要約のエンドポイント名（設定ファイルの llm.endpoints のキー）
+const (
+	endpointArticleSummary    = usecase.ArticleSummaryEndpoint
+	endpointRepositorySummary = "ai-repository-summary"
+	endpointTrendsSummary     = usecase.DigestLLMEndpoint
+)
+
+var (
+	feedRegistry     *usecase.FeedRegistry
+	llmRouter        *usecase.LLMRouter
+	summaryCache     *usecase.SummaryCache
+	fetcher          *usecase.Fetcher
+	contentExtractor *usecase.ContentExtractor
+	aiUsage          *usecase.AIUsageTracker
+	notifier         *usecase.Notifier
+	articleStore     *store.Store
+	feedListLimit    = 50
+
+	outgoingFeedTitle       = "trends-summary"
+	outgoingFeedDescription = ""
+	outgoingFeedLimit       = 50
+
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	oidcClient *usecase.OIDCClient
+
+	loginThrottle *usecase.LoginThrottle
+	auditLog      *usecase.AuditLog
+)
+
+// SetFeedRegistry フィードハンドラーが参照するレジストリを設定します
+func SetFeedRegistry(r *usecase.FeedRegistry) {
+	feedRegistry = r
+}
+
+// SetStore ハンドラーが参照するストアと1ソースあたりの返却件数を設定します
+func SetStore(st *store.Store, listLimit int) {
+	articleStore = st
+	if listLimit > 0 {
+		feedListLimit = listLimit
+	}
+}
+
+// SetLLMRouter AI要約ハンドラーが使用するLLMルーターを設定します
+func SetLLMRouter(r *usecase.LLMRouter) {
+	llmRouter = r
+}
+
+// SetFetcher 記事やフィードなど外部のコンテンツの取得に使うクライアントを設定します
+func SetFetcher(f *usecase.Fetcher) {
+	fetcher = f
+}
+
+// SetContentExtractor AI要約する記事の本文抽出器を設定します
+func SetContentExtractor(e *usecase.ContentExtractor) {
+	contentExtractor = e
+}
+
+// SetSummaryCache AI要約のキャッシュを設定します（nilの場合はキャッシュしない）
+func SetSummaryCache(cache *usecase.SummaryCache) {
+	summaryCache = cache
+}
+
+// SetAIUsageTracker AI要約の利用量の上限判定と集計に使う記録先を設定します（nilの場合は上限なし）
+func SetAIUsageTracker(t *usecase.AIUsageTracker) {
+	aiUsage = t
+}
+
+// SetNotifier ダイジェストの再配信に使う通知先を設定します
+func SetNotifier(n *usecase.Notifier) {
+	notifier = n
+}
+
+// SetOutgoingFeed 集約フィードのタイトル・説明と既定の件数を設定します
+func SetOutgoingFeed(title, description string, limit int) {
+	if title != "" {
+		outgoingFeedTitle = title
+	}
+	outgoingFeedDescription = description
+	if limit > 0 {
+		outgoingFeedLimit = limit
+	}
+}
+
+// SetRefreshTokenTTL リフレッシュトークン（ログインセッション）の有効期間を設定します
+func SetRefreshTokenTTL(ttl time.Duration) {
+	if ttl > 0 {
+		refreshTokenTTL = ttl
+	}
+}
+
+// SetOIDCClient OIDCによるシングルサインオンのクライアントを設定します（nilの場合は無効）
+func SetOIDCClient(c *usecase.OIDCClient) {
+	oidcClient = c
+}
+
+// SetLoginThrottle パスワードログインの試行回数の制限を設定します（nilの場合は制限しない）
+func SetLoginThrottle(t *usecase.LoginThrottle) {
+	loginThrottle = t
+}
+
+// SetAuditLog 認証イベントを記録する監査ログを設定します（nilの場合は記録しない）
+func SetAuditLog(a *usecase.AuditLog) {
+	auditLog = a
+}
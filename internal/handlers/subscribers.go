@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"html"
+	"net/http"
+	"net/mail"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// subscriberRequest 購読者追加のリクエストボディ
+type subscriberRequest struct {
+	Email string `json:"email"`
+}
+
+// Subscribers はメール購読者の一覧を返すハンドラーです
+func Subscribers(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "Subscribers",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	subscribers, err := articleStore.ListSubscribers(false)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "Subscribers",
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("購読者の取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "購読者の取得に失敗しました"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"subscribers": subscribers})
+}
+
+// AddSubscriber はメール購読者を追加するハンドラーです（配信停止済みのアドレスは再開します）
+func AddSubscriber(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "AddSubscriber",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	var req subscriberRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "リクエストデータが無効です"})
+	}
+	addr, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "メールアドレスが正しくありません"})
+	}
+
+	subscriber, err := articleStore.AddSubscriber(addr.Address)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "AddSubscriber",
+			"error":     err.Error(),
+			"errorType": "DB保存エラー",
+		}).Error("購読者の追加に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "購読者の追加に失敗しました"})
+	}
+	return c.JSON(http.StatusCreated, subscriber)
+}
+
+// DeleteSubscriber はメール購読者を削除するハンドラーです
+func DeleteSubscriber(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "DeleteSubscriber",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"id":      c.Param("id"),
+	}).Info("ハンドラー呼び出し")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "idが不正です"})
+	}
+
+	deleted, err := articleStore.DeleteSubscriber(id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "DeleteSubscriber",
+			"id":        id,
+			"error":     err.Error(),
+			"errorType": "DB削除エラー",
+		}).Error("購読者の削除に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "購読者の削除に失敗しました"})
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "購読者が見つかりません"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UnsubscribeConfirm はメールの配信停止リンクから開く確認ページを返すハンドラーです（認証不要）
+// メールのセキュリティスキャナーなどによるリンクの先読みで配信停止にならないよう、GETでは状態を変更しません
+func UnsubscribeConfirm(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "UnsubscribeConfirm",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.HTML(http.StatusBadRequest, unsubscribePage("配信停止リンクが正しくありません。", ""))
+	}
+
+	subscriber, err := articleStore.GetSubscriberByToken(token)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "UnsubscribeConfirm",
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("購読者の取得に失敗しました")
+		return c.HTML(http.StatusInternalServerError, unsubscribePage("購読者の取得に失敗しました。時間をおいて再度お試しください。", ""))
+	}
+	if subscriber == nil {
+		return c.HTML(http.StatusNotFound, unsubscribePage("配信停止リンクが正しくありません。", ""))
+	}
+	if subscriber.UnsubscribedAt != "" {
+		return c.HTML(http.StatusOK, unsubscribePage(subscriber.Email+" へのダイジェストの配信は停止済みです。", ""))
+	}
+	return c.HTML(http.StatusOK, unsubscribePage(subscriber.Email+" へのダイジェストの配信を停止しますか？", token))
+}
+
+// Unsubscribe はメールの配信を停止するハンドラーです（認証不要）
+// 確認ページのフォームと、メールクライアントのワンクリック配信停止（RFC 8058）のPOSTに対応します
+func Unsubscribe(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "Unsubscribe",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	// ワンクリック配信停止ではトークンはURLのクエリ、確認ページではフォームで送られる
+	token := c.FormValue("token")
+	if token == "" {
+		return c.HTML(http.StatusBadRequest, unsubscribePage("配信停止リンクが正しくありません。", ""))
+	}
+
+	subscriber, err := articleStore.Unsubscribe(token)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "Unsubscribe",
+			"error":     err.Error(),
+			"errorType": "DB更新エラー",
+		}).Error("配信停止に失敗しました")
+		return c.HTML(http.StatusInternalServerError, unsubscribePage("配信停止に失敗しました。時間をおいて再度お試しください。", ""))
+	}
+	if subscriber == nil {
+		return c.HTML(http.StatusNotFound, unsubscribePage("配信停止リンクが正しくありません。", ""))
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"handler": "Unsubscribe",
+		"id":      subscriber.ID,
+	}).Info("メール配信を停止しました")
+	return c.HTML(http.StatusOK, unsubscribePage(subscriber.Email+" へのダイジェストの配信を停止しました。", ""))
+}
+
+// unsubscribePage 配信停止のページを返します（tokenを指定した場合は配信停止を確定するフォームを含めます）
+func unsubscribePage(message, token string) string {
+	var form string
+	if token != "" {
+		form = `<form method="post" action="unsubscribe"><input type="hidden" name="token" value="` + html.EscapeString(token) +
+			`"><button type="submit">配信を停止する</button></form>`
+	}
+	return `<!DOCTYPE html><html lang="ja"><head><meta charset="UTF-8"><title>配信停止</title></head><body><p>` +
+		html.EscapeString(message) + `</p>` + form + `</body></html>`
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// TrendingHistory はリポジトリのトレンド順位履歴を返すハンドラーです
+// クエリパラメータ: repo(owner/repo, 必須), scope(言語, 空は全言語), days(既定30)
+func TrendingHistory(c echo.Context) error {
+	repo := c.QueryParam("repo")
+	scope := c.QueryParam("scope")
+	logrus.WithFields(logrus.Fields{
+		"handler": "TrendingHistory",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"repo":    repo,
+		"scope":   scope,
+	}).Info("ハンドラー呼び出し")
+
+	if repo == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "repoパラメータが必要です（owner/repo形式）"})
+	}
+
+	days := 30
+	if v := c.QueryParam("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "daysパラメータが不正です"})
+		}
+		days = n
+	}
+	since := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+
+	history, err := articleStore.TrendingHistory(repo, scope, since)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "TrendingHistory",
+			"repo":      repo,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("トレンド履歴の取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "トレンド履歴の取得に失敗しました"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"repo":    repo,
+		"scope":   scope,
+		"history": history,
+	})
+}
+
+// TrendingDiff は指定日（既定は今日）のトレンドを直前のスナップショットと比較するハンドラーです
+// 新規ランクイン・ランク外・順位上昇・順位下降を返します
+func TrendingDiff(c echo.Context) error {
+	scope := c.QueryParam("scope")
+	date := c.QueryParam("date")
+	logrus.WithFields(logrus.Fields{
+		"handler": "TrendingDiff",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"scope":   scope,
+		"date":    date,
+	}).Info("ハンドラー呼び出し")
+
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "日付はYYYY-MM-DD形式で指定してください"})
+		}
+	}
+
+	diff, err := usecase.DiffTrendingSnapshot(articleStore, scope, date)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "TrendingDiff",
+			"scope":     scope,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("トレンド差分の取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "トレンド差分の取得に失敗しました"})
+	}
+	if diff.Date == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "スナップショットがありません"})
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+	"trends-summary/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// createUserRequest ユーザー作成のリクエストボディ
+type createUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"` // 省略時は viewer
+}
+
+// updateUserRequest ユーザー更新のリクエストボディ（指定した項目のみ更新）
+type updateUserRequest struct {
+	Password *string `json:"password"`
+	Role     *string `json:"role"`
+	Disabled *bool   `json:"disabled"`
+}
+
+// changePasswordRequest 自分のパスワード変更のリクエストボディ
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// invalidRoleMessage ロールの指定が不正な場合のエラーメッセージ
+var invalidRoleMessage = "roleは " + strings.Join(models.Roles, " / ") + " のいずれかを指定してください"
+
+// Users はユーザーの一覧を返すハンドラーです（管理者のみ）
+func Users(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "Users",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	users, err := articleStore.ListUsers()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "Users",
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("ユーザーの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ユーザーの取得に失敗しました"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"users": users})
+}
+
+// CreateUser はユーザーを作成するハンドラーです（管理者のみ）
+func CreateUser(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "CreateUser",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	var req createUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "リクエストデータが無効です"})
+	}
+	if err := usecase.ValidateUsername(req.Username); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if !models.ValidRole(req.Role) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": invalidRoleMessage})
+	}
+	hash, err := usecase.HashPassword(req.Password)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	user, err := articleStore.CreateUser(req.Username, hash, req.Role)
+	if errors.Is(err, store.ErrUserExists) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "CreateUser",
+			"error":     err.Error(),
+			"errorType": "DB保存エラー",
+		}).Error("ユーザーの作成に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ユーザーの作成に失敗しました"})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"handler":  "CreateUser",
+		"by":       c.Get("username"),
+		"username": user.Username,
+		"role":     user.Role,
+	}).Info("ユーザーを作成しました")
+	recordAudit(c, models.AuditEvent{Event: models.AuditEventUserCreated, UserID: user.ID, Username: user.Username, Detail: "role=" + user.Role})
+	return c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUser はユーザーのパスワード・ロール・無効化を変更するハンドラーです（管理者のみ）
+// 自分自身の無効化・降格と、最後の有効な管理者の無効化・降格はできません
+func UpdateUser(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "UpdateUser",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+		"id":      c.Param("id"),
+	}).Info("ハンドラー呼び出し")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "idが不正です"})
+	}
+	var req updateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "リクエストデータが無効です"})
+	}
+	if req.Role != nil && !models.ValidRole(*req.Role) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": invalidRoleMessage})
+	}
+
+	user, err := articleStore.GetUser(id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "UpdateUser",
+			"id":        id,
+			"error":     err.Error(),
+			"errorType": "DB取得エラー",
+		}).Error("ユーザーの取得に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ユーザーの取得に失敗しました"})
+	}
+	if user == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "ユーザーが見つかりません"})
+	}
+
+	// 管理者でなくなる変更は自分自身と最後の管理者に対しては行えない
+	losesAdmin := user.IsAdmin() && !user.Disabled &&
+		((req.Role != nil && *req.Role != models.RoleAdmin) || (req.Disabled != nil && *req.Disabled))
+	if losesAdmin {
+		if selfID, _ := c.Get("userID").(int64); selfID == user.ID {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "自分自身を無効化・降格することはできません"})
+		}
+		admins, err := articleStore.CountActiveAdmins()
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"handler":   "UpdateUser",
+				"error":     err.Error(),
+				"errorType": "DB取得エラー",
+			}).Error("管理者数の取得に失敗しました")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ユーザーの更新に失敗しました"})
+		}
+		if admins <= 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "最後の管理者を無効化・降格することはできません"})
+		}
+	}
+
+	if req.Password != nil {
+		hash, err := usecase.HashPassword(*req.Password)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		user.PasswordHash = hash
+	}
+	if req.Role != nil {
+		user.Role = *req.Role
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+
+	if err := articleStore.UpdateUser(*user); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "UpdateUser",
+			"id":        id,
+			"error":     err.Error(),
+			"errorType": "DB保存エラー",
+		}).Error("ユーザーの更新に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ユーザーの更新に失敗しました"})
+	}
+
+	// パスワードを変更・無効化したユーザーのログイン中のセッションは失効させる
+	if req.Password != nil || user.Disabled {
+		revokeUserSessions("UpdateUser", user.ID, "")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"handler":         "UpdateUser",
+		"by":              c.Get("username"),
+		"username":        user.Username,
+		"role":            user.Role,
+		"disabled":        user.Disabled,
+		"passwordChanged": req.Password != nil,
+	}).Info("ユーザーを更新しました")
+	recordAudit(c, models.AuditEvent{
+		Event:    models.AuditEventUserUpdated,
+		UserID:   user.ID,
+		Username: user.Username,
+		Detail:   fmt.Sprintf("role=%s disabled=%t passwordChanged=%t", user.Role, user.Disabled, req.Password != nil),
+	})
+	return c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword はログイン中のユーザーが自分のパスワードを変更するハンドラーです
+func ChangePassword(c echo.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"handler": "ChangePassword",
+		"method":  c.Request().Method,
+		"path":    c.Request().URL.Path,
+	}).Info("ハンドラー呼び出し")
+
+	var req changePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "リクエストデータが無効です"})
+	}
+	userID, _ := c.Get("userID").(int64)
+	username, _ := c.Get("username").(string)
+
+	user, err := articleStore.GetUser(userID)
+	if err != nil || user == nil {
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"handler":   "ChangePassword",
+				"username":  username,
+				"error":     err.Error(),
+				"errorType": "DB取得エラー",
+			}).Error("ユーザーの取得に失敗しました")
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "パスワードの変更に失敗しました"})
+	}
+	if !usecase.CheckPassword(user.PasswordHash, req.CurrentPassword) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "現在のパスワードが正しくありません"})
+	}
+	hash, err := usecase.HashPassword(req.NewPassword)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	user.PasswordHash = hash
+	if err := articleStore.UpdateUser(*user); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler":   "ChangePassword",
+			"username":  username,
+			"error":     err.Error(),
+			"errorType": "DB保存エラー",
+		}).Error("パスワードの変更に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "パスワードの変更に失敗しました"})
+	}
+
+	// 他の端末のセッションは失効させる
+	sessionID, _ := c.Get("sessionID").(string)
+	revokeUserSessions("ChangePassword", userID, sessionID)
+
+	logrus.WithFields(logrus.Fields{
+		"handler":  "ChangePassword",
+		"username": username,
+	}).Info("パスワードを変更しました")
+	recordAudit(c, models.AuditEvent{Event: models.AuditEventPasswordChanged, UserID: userID, Username: username})
+	return c.JSON(http.StatusOK, map[string]string{"message": "パスワードを変更しました"})
+}
+
+// revokeUserSessions ユーザーのセッションをexceptID以外失効させます（失敗はログのみ）
+func revokeUserSessions(handler string, userID int64, exceptID string) {
+	if _, err := articleStore.RevokeUserSessions(userID, exceptID); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"handler": handler,
+			"userID":  userID,
+			"error":   err.Error(),
+		}).Error("セッションの失効に失敗しました")
+	}
+}
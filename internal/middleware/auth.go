@@ -1,48 +1,77 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+	"trends-summary/internal/usecase"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
 
-var jwtSecret []byte
+// userStore ログインユーザーとセッションの状態（無効化・ロール・失効）を確認するストア
+var userStore *store.Store
+
+// accessTokenTTL アクセストークンの有効期間（失効はセッションで管理するため短くする）
+var accessTokenTTL = 15 * time.Minute
+
+// SetUserStore 認証時にユーザーとセッションを確認するストアを設定します
+func SetUserStore(st *store.Store) {
+	userStore = st
+}
 
-func init() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		logrus.Fatal("JWT_SECRET 環境変数が設定されていません。サーバーを起動できません。")
+// SetAccessTokenTTL アクセストークンの有効期間を設定します
+func SetAccessTokenTTL(ttl time.Duration) {
+	if ttl > 0 {
+		accessTokenTTL = ttl
 	}
-	jwtSecret = []byte(secret)
 }
 
-// JWTClaims カスタムクレーム
+// JWTClaims カスタムクレーム（jtiはセッションID）
 type JWTClaims struct {
+	UserID   int64  `json:"uid"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken JWT生成
-func GenerateToken(username string) (string, error) {
+// GenerateToken セッションのアクセストークンを生成し、有効期限とともに返します
+func GenerateToken(userID int64, username, role, sessionID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(accessTokenTTL)
 	claims := &JWTClaims{
+		UserID:   userID,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	token.Header["kid"] = signingKeyID
+	signed, err := token.SignedString(signingSecret)
+	return signed, expiresAt, err
 }
 
 // AuthMiddleware 認証ミドルウェア
+// ブラウザはCookieのアクセストークン、スクリプトなどは Authorization: Bearer のAPIトークンで認証します
 func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if auth := c.Request().Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			return apiTokenAuth(c, next, strings.TrimPrefix(auth, "Bearer "))
+		}
+
 		// Cookieからトークンを取得
 		cookie, err := c.Cookie("auth_token")
 		if err != nil {
@@ -55,24 +84,182 @@ func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		}
 
 		// トークンを検証
-		token, err := jwt.ParseWithClaims(cookie.Value, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
+		claims := &JWTClaims{}
+		token, err := jwt.ParseWithClaims(cookie.Value, claims, keyFunc,
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 		if err != nil || !token.Valid {
 			logrus.WithFields(logrus.Fields{
-				"error": err.Error(),
+				"error": fmt.Sprint(err),
 			}).Warn("JWT検証失敗")
 			return c.JSON(http.StatusUnauthorized, map[string]string{
 				"error": "無効な認証トークンです",
 			})
 		}
 
-		// クレームを取得してContextに保存
-		if claims, ok := token.Claims.(*JWTClaims); ok {
-			c.Set("username", claims.Username)
+		// セッションが失効していないか確認
+		session, err := userStore.GetSession(claims.ID)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"sessionID": claims.ID,
+				"error":     err.Error(),
+			}).Error("セッションの取得に失敗しました")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "認証に失敗しました",
+			})
+		}
+		if session == nil || session.RevokedAt != "" || session.UserID != claims.UserID {
+			logrus.WithFields(logrus.Fields{
+				"sessionID": claims.ID,
+				"username":  claims.Username,
+			}).Warn("失効したセッションのトークンです")
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "セッションが失効しています",
+			})
+		}
+
+		// ユーザーが削除・無効化されていないか確認してContextに保存
+		user, err := userStore.GetUser(claims.UserID)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"userID": claims.UserID,
+				"error":  err.Error(),
+			}).Error("ユーザーの取得に失敗しました")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "認証に失敗しました",
+			})
+		}
+		if user == nil || user.Disabled {
+			logrus.WithFields(logrus.Fields{
+				"userID":   claims.UserID,
+				"username": claims.Username,
+			}).Warn("無効なユーザーのトークンです")
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "無効な認証トークンです",
+			})
+		}
+		// 発行後に降格された場合はトークンの有効期限を待たずに反映する（昇格はリフレッシュ時に反映）
+		role := user.Role
+		if claims.Role != "" {
+			role = models.LowerRole(claims.Role, user.Role)
+		}
+		setUser(c, user, role)
+		c.Set("sessionID", session.ID)
+
+		return next(c)
+	}
+}
+
+// apiTokenAuth APIトークンで認証し、トークンをContextに保存します
+func apiTokenAuth(c echo.Context, next echo.HandlerFunc, raw string) error {
+	token, user, err := usecase.AuthenticateAPIToken(userStore, raw)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidAPIToken) || errors.Is(err, usecase.ErrUserDisabled) {
+			logrus.WithFields(logrus.Fields{
+				"path":  c.Request().URL.Path,
+				"error": err.Error(),
+			}).Warn("APIトークン検証失敗")
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		logrus.WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Error("APIトークンの検証に失敗しました")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "認証に失敗しました",
+		})
+	}
+
+	setUser(c, user, user.Role)
+	c.Set("apiToken", token)
+	return next(c)
+}
+
+// RequireScope APIトークンでのアクセスにスコープを要求するミドルウェア（AuthMiddlewareの後に使用）
+// ログインセッション（Cookie）でのアクセスはすべてのスコープを持つものとして扱います
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if token, ok := c.Get("apiToken").(*models.APIToken); ok && !token.HasScope(scope) {
+				logrus.WithFields(logrus.Fields{
+					"tokenID": token.ID,
+					"scope":   scope,
+					"path":    c.Request().URL.Path,
+				}).Warn("APIトークンのスコープ不足")
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "APIトークンに " + scope + " スコープがありません",
+				})
+			}
+			return next(c)
 		}
+	}
+}
 
+// RequireSession ログインセッション（Cookie）でのアクセスのみ許可するミドルウェア（AuthMiddlewareの後に使用）
+// APIトークンやセッションの管理など、APIトークンから権限を広げられる操作に使用します
+func RequireSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := c.Get("apiToken").(*models.APIToken); ok {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "この操作はログインした画面からのみ実行できます",
+			})
+		}
 		return next(c)
 	}
 }
+
+// RequireRole 指定したロール以上のユーザーのみアクセスを許可するミドルウェア（AuthMiddlewareの後に使用）
+func RequireRole(required string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, _ := c.Get("role").(string)
+			if !models.RoleAtLeast(role, required) {
+				logrus.WithFields(logrus.Fields{
+					"username": c.Get("username"),
+					"role":     role,
+					"required": required,
+					"path":     c.Request().URL.Path,
+				}).Warn("ロールの権限不足")
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "この操作には " + required + " ロールが必要です",
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// setUser 認証済みユーザーと有効なロールをContextに保存します
+func setUser(c echo.Context, user *models.User, role string) {
+	c.Set("userID", user.ID)
+	c.Set("username", user.Username)
+	c.Set("role", role)
+	c.Set("isAdmin", role == models.RoleAdmin)
+}
+
+// FeedTokenAuth フィードリーダー向けに ?token= またはBearerトークンでのアクセスを許可する認証ミドルウェア
+// 共有のトークンと一致しない場合（tokenが空の場合を含む）は通常の認証（Cookie、またはread-feedsスコープのAPIトークン）を行います
+// Authorizationヘッダーを送れないフィードリーダー向けに、個人のAPIトークンも ?token= で受け付けます
+func FeedTokenAuth(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		scoped := RequireScope(models.APITokenScopeReadFeeds)(next)
+		cookieAuth := AuthMiddleware(scoped)
+		return func(c echo.Context) error {
+			provided := c.QueryParam("token")
+			bearer := false
+			if auth := c.Request().Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				provided = strings.TrimPrefix(auth, "Bearer ")
+				bearer = true
+			}
+			if token != "" && provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+				c.Set("username", "feed-token")
+				return next(c)
+			}
+			if provided != "" && !bearer {
+				return apiTokenAuth(c, scoped, provided)
+			}
+			return cookieAuth(c)
+		}
+	}
+}
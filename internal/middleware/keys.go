@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// JWTの署名鍵
+// JWT_SECRET で署名し、JWT_PREVIOUS_SECRETS（カンマ区切り）の鍵は検証のみに使います
+// 鍵を切り替える場合は新しい鍵を JWT_SECRET に、古い鍵を JWT_PREVIOUS_SECRETS に設定します
+var (
+	signingKeyID  string
+	signingSecret []byte
+	verifyingKeys = map[string][]byte{} // kid → 鍵
+)
+
+func init() {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" && testing.Testing() {
+		// go test では環境変数なしでハンドラーのテストを実行できるよう固定の鍵を使います
+		secret = "test-secret"
+	}
+	if secret == "" {
+		logrus.Fatal("JWT_SECRET 環境変数が設定されていません。サーバーを起動できません。")
+	}
+	signingKeyID = keyID(secret)
+	signingSecret = []byte(secret)
+	verifyingKeys[signingKeyID] = signingSecret
+
+	for _, previous := range strings.Split(os.Getenv("JWT_PREVIOUS_SECRETS"), ",") {
+		if previous = strings.TrimSpace(previous); previous != "" {
+			verifyingKeys[keyID(previous)] = []byte(previous)
+		}
+	}
+}
+
+// keyID 鍵を識別するkidを返します（鍵そのものを推測されないようSHA-256の先頭を使用）
+func keyID(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// keyFunc トークンのkidヘッダーに対応する検証用の鍵を返します
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := verifyingKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("不明な署名鍵です: kid=%q", kid)
+	}
+	return key, nil
+}
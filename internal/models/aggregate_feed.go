@@ -0,0 +1,8 @@
+package models
+
+// AggregatedFeedQuery 集約フィードの取得条件
+type AggregatedFeedQuery struct {
+	Sources   []string // 対象のフィードID（空は全ソース）
+	Limit     int
+	Summaries bool // AI要約済みの記事に要約を付与する
+}
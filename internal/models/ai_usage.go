@@ -0,0 +1,43 @@
+package models
+
+// AIUsageRecord AI要約1回分のLLMの利用量
+type AIUsageRecord struct {
+	ID           int64   `json:"id"`
+	UserID       int64   `json:"userId,omitempty"` // 0はユーザーによらない処理（日次ダイジェストなど）
+	Username     string  `json:"username"`
+	Endpoint     string  `json:"endpoint"`
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	PromptTokens int     `json:"promptTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	CostUSD      float64 `json:"costUsd"`   // 設定の料金から計算した推定コスト
+	CreatedAt    string  `json:"createdAt"` // RFC3339
+}
+
+// AIUsageQuery 利用量の集計条件（空の項目は条件にしない）
+type AIUsageQuery struct {
+	Since    string // RFC3339（この日時を含む）
+	Until    string // RFC3339（この日時を含まない）
+	UserID   int64
+	Username string
+}
+
+// AIUsageTotals 利用量の集計結果（Keyは集計の単位の値）
+type AIUsageTotals struct {
+	Key          string  `json:"key,omitempty"`
+	Requests     int     `json:"requests"`
+	PromptTokens int     `json:"promptTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	CostUSD      float64 `json:"costUsd"`
+}
+
+// AIBudgetStatus 利用量の上限に対する現在の利用状況
+type AIBudgetStatus struct {
+	Scope    string  `json:"scope"`  // user / global
+	Period   string  `json:"period"` // daily / monthly
+	LimitUSD float64 `json:"limitUsd"`
+	SpentUSD float64 `json:"spentUsd"`
+	// 生成中の呼び出しの見積もりコスト（完了すると実際のコストに置き換わる）
+	ReservedUSD float64 `json:"reservedUsd"`
+	ResetAt     string  `json:"resetAt"` // RFC3339
+}
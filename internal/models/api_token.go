@@ -0,0 +1,32 @@
+package models
+
+// APIトークンのスコープ
+const (
+	APITokenScopeReadFeeds    = "read-feeds"     // フィード・トレンド・検索・ダイジェストの参照
+	APITokenScopeRunAISummary = "run-ai-summary" // AI要約の生成
+	APITokenScopeAdmin        = "admin"          // ユーザー・購読者・配信の管理（管理者のみ作成可能）
+)
+
+// APIToken スクリプトやフィードリーダーなどブラウザ以外から使う個人用のAPIトークン
+type APIToken struct {
+	ID         int64    `json:"id"`
+	UserID     int64    `json:"userId"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"` // 識別用のトークンの先頭部分
+	TokenHash  string   `json:"-"`      // トークンのSHA-256
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"createdAt"`            // RFC3339
+	LastUsedAt string   `json:"lastUsedAt,omitempty"` // RFC3339
+	ExpiresAt  string   `json:"expiresAt,omitempty"`  // RFC3339（空は無期限）
+	RevokedAt  string   `json:"revokedAt,omitempty"`  // RFC3339
+}
+
+// HasScope トークンにスコープが付与されているか判定します
+func (t APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package models
+
+// 監査ログのイベント
+const (
+	AuditEventLogin           = "login"             // ログイン成功（detail はログイン方法）
+	AuditEventLoginFailure    = "login_failure"     // ログイン失敗（detail は理由）
+	AuditEventLockout         = "lockout"           // 失敗回数の超過によるログインの一時停止
+	AuditEventLogout          = "logout"            // ログアウト
+	AuditEventSessionRevoked  = "session_revoked"   // セッションの失効（本人による失効・リフレッシュトークンの再使用）
+	AuditEventAPITokenCreated = "api_token_created" // APIトークンの発行
+	AuditEventAPITokenRevoked = "api_token_revoked" // APIトークンの失効
+	AuditEventPasswordChanged = "password_changed"  // 本人によるパスワード変更
+	AuditEventUserCreated     = "user_created"      // 管理者によるユーザー作成
+	AuditEventUserUpdated     = "user_updated"      // 管理者によるユーザーの変更
+)
+
+// AuditEvent 認証に関する監査ログの1件
+type AuditEvent struct {
+	ID        int64  `json:"id"`
+	Event     string `json:"event"`
+	UserID    int64  `json:"userId,omitempty"` // 対象のユーザー（存在しないユーザー名でのログイン失敗は0）
+	Username  string `json:"username"`
+	Actor     string `json:"actor,omitempty"` // 操作したユーザー（本人以外の場合）
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+	Detail    string `json:"detail,omitempty"`
+	CreatedAt string `json:"createdAt"` // RFC3339
+}
+
+// AuditQuery 監査ログの検索条件（空の項目は条件にしない）
+type AuditQuery struct {
+	Event    string
+	Username string
+	IP       string
+	Since    string // RFC3339
+	Until    string // RFC3339
+	BeforeID int64  // ページング用（このIDより前のイベント）
+	Limit    int
+}
@@ -0,0 +1,34 @@
+package models
+
+// ダイジェストに含めるデータソース
+const (
+	DigestSourceInfoQ          = "infoq"
+	DigestSourceGitHubTrending = "github-trending"
+	DigestSourceGolangWeekly   = "golang-weekly"
+)
+
+// DigestQuery トレンドダイジェストの作成条件
+type DigestQuery struct {
+	Date    string   `json:"date"`    // YYYY-MM-DD（サーバーのローカルタイムゾーン）
+	Sources []string `json:"sources"` // DigestSource* のいずれか
+}
+
+// DigestInput ダイジェストの元データ
+type DigestInput struct {
+	Date           string                  `json:"date"`
+	Sources        []string                `json:"sources"`
+	InfoQ          []FeedItem              `json:"infoq,omitempty"`
+	GitHubTrending []TrendingSnapshotEntry `json:"githubTrending,omitempty"`
+	GolangWeekly   []FeedItem              `json:"golangWeekly,omitempty"`
+}
+
+// Digest 保存済みの日次トレンドダイジェスト
+type Digest struct {
+	Date          string       `json:"date"`
+	Sources       []string     `json:"sources"`
+	Summary       string       `json:"summary"`
+	Model         string       `json:"model"`
+	PromptVersion string       `json:"promptVersion"`
+	GeneratedAt   string       `json:"generatedAt"`     // RFC3339
+	Input         *DigestInput `json:"input,omitempty"` // 一覧では省略
+}
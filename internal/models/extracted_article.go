@@ -0,0 +1,11 @@
+package models
+
+// ExtractedArticle 記事ページから抽出した本文とメタデータ
+type ExtractedArticle struct {
+	URL         string `json:"url"` // リダイレクト後のURL
+	Title       string `json:"title,omitempty"`
+	Author      string `json:"author,omitempty"`
+	PublishedAt string `json:"publishedAt,omitempty"` // RFC3339（解析できない場合は空）
+	SiteName    string `json:"siteName,omitempty"`
+	Text        string `json:"text"` // 見出し・段落・リストの区切りを残したプレーンテキスト
+}
@@ -0,0 +1,27 @@
+package models
+
+// FeedItem 全フィードエンドポイント共通の正規化済み記事
+type FeedItem struct {
+	GUID        string   `json:"guid"`
+	Title       string   `json:"title"`
+	Link        string   `json:"link"`
+	Description string   `json:"description"`
+	Author      string   `json:"author,omitempty"`
+	Categories  []string `json:"categories"`
+	Image       string   `json:"image,omitempty"`
+	Published   string   `json:"published,omitempty"` // RFC3339
+	Updated     string   `json:"updated,omitempty"`   // RFC3339
+	SourceID    string   `json:"sourceId"`
+	Language    string   `json:"language"`
+	Summary     string   `json:"summary,omitempty"` // AI要約（集約フィードで要約済みの記事のみ）
+}
+
+// Feed 正規化済みフィード
+type Feed struct {
+	SourceID    string     `json:"sourceId"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Language    string     `json:"language"`
+	Category    string     `json:"category"`
+	Items       []FeedItem `json:"items"`
+}
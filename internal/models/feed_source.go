@@ -0,0 +1,21 @@
+package models
+
+// フィードソースの出力モード（?format= でも指定可能）
+const (
+	FeedOutputJSON       = "json"       // title/link/published/description に整形したJSON
+	FeedOutputRaw        = "raw"        // 上流のフィードをそのまま返却
+	FeedOutputNormalized = "normalized" // 共通のFeedモデルに正規化したJSON
+	FeedOutputRSS        = "rss"        // ストアの記事をRSS 2.0で返却
+)
+
+// FeedSource 設定ファイルで定義されるフィードソース
+type FeedSource struct {
+	ID          string   `json:"id" yaml:"id"`
+	Name        string   `json:"name" yaml:"name"`
+	Title       string   `json:"title,omitempty" yaml:"title"`
+	Description string   `json:"description,omitempty" yaml:"description"`
+	URLs        []string `json:"urls" yaml:"urls"`
+	Language    string   `json:"language" yaml:"language"`
+	Category    string   `json:"category" yaml:"category"`
+	Output      string   `json:"output" yaml:"output"`
+}
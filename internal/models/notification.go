@@ -0,0 +1,53 @@
+package models
+
+// 通知イベントの種類
+const (
+	NotificationEventDigest   = "digest"    // 日次ダイジェストの作成
+	NotificationEventNewItems = "new_items" // フィードの新着記事
+)
+
+// 配信結果
+const (
+	DeliveryStatusSuccess = "success"
+	DeliveryStatusFailed  = "failed"
+)
+
+// Notification 配信先に送る通知
+type Notification struct {
+	Event      string     `json:"event"`
+	Digest     *Digest    `json:"digest,omitempty"`     // digest のみ
+	SourceID   string     `json:"sourceId,omitempty"`   // new_items のみ
+	SourceName string     `json:"sourceName,omitempty"` // new_items のみ
+	Items      []FeedItem `json:"items,omitempty"`      // new_items のみ
+}
+
+// Ref 配信ログに記録する通知の識別子（ダイジェストの日付またはソースID）
+func (n Notification) Ref() string {
+	if n.Digest != nil {
+		return n.Digest.Date
+	}
+	return n.SourceID
+}
+
+// Delivery 配信ログ
+type Delivery struct {
+	ID         int64  `json:"id"`
+	Target     string `json:"target"` // 配信先の名前
+	Type       string `json:"type"`   // slack / discord / json など
+	Event      string `json:"event"`
+	Ref        string `json:"ref"`
+	Status     string `json:"status"`
+	Attempts   int    `json:"attempts"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	CreatedAt  string `json:"createdAt"` // RFC3339
+}
+
+// Subscriber メールでダイジェストを受け取る購読者
+type Subscriber struct {
+	ID             int64  `json:"id"`
+	Email          string `json:"email"`
+	Token          string `json:"-"`                        // 配信停止リンク用のトークン
+	CreatedAt      string `json:"createdAt"`                // RFC3339
+	UnsubscribedAt string `json:"unsubscribedAt,omitempty"` // RFC3339、配信停止済みの場合のみ
+}
@@ -0,0 +1,16 @@
+package models
+
+// 表示言語
+const (
+	PreferenceLanguageEN = "en"
+	PreferenceLanguageJA = "ja"
+)
+
+// UserPreferences ユーザーごとの表示設定（空のリストは絞り込みなし）
+type UserPreferences struct {
+	Sources         []string `json:"sources"`             // 表示するフィードソースのID
+	Language        string   `json:"language"`            // 優先する言語（en / ja、空は指定なし）
+	Keywords        []string `json:"keywords"`            // 記事のタイトル・説明にいずれかを含むものだけを表示
+	GitHubLanguages []string `json:"githubLanguages"`     // GitHub Trendingで追跡するプログラミング言語
+	UpdatedAt       string   `json:"updatedAt,omitempty"` // RFC3339
+}
@@ -0,0 +1,51 @@
+package models
+
+// 検索インデックスのドキュメント種別
+const (
+	SearchKindArticle    = "article"
+	SearchKindRepository = "repository"
+	SearchKindSummary    = "summary"
+)
+
+// SearchDocument 全文検索インデックスに登録するドキュメント
+type SearchDocument struct {
+	Kind      string
+	Ref       string // 種別内で一意なキー（記事は sourceID:guid、リポジトリは owner/repo など）
+	SourceID  string
+	Language  string
+	Link      string
+	Published string // RFC3339
+	Title     string
+	Body      string
+}
+
+// SearchQuery 検索条件
+type SearchQuery struct {
+	Query    string
+	Kind     string
+	SourceID string
+	Language string
+	From     string // YYYY-MM-DD（この日を含む）
+	To       string // YYYY-MM-DD（この日を含む）
+	Page     int
+	PerPage  int
+}
+
+// SearchHit 検索結果の1件
+type SearchHit struct {
+	Kind      string `json:"kind"`
+	SourceID  string `json:"sourceId"`
+	Language  string `json:"language"`
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	Snippet   string `json:"snippet"` // HTMLエスケープ済み（一致箇所は <mark> で囲む）
+	Published string `json:"published"`
+}
+
+// SearchResult 検索結果
+type SearchResult struct {
+	Total   int         `json:"total"`
+	Page    int         `json:"page"`
+	PerPage int         `json:"perPage"`
+	Items   []SearchHit `json:"items"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// Session ログインセッション（アクセストークンのjtiとリフレッシュトークンを紐づける）
+type Session struct {
+	ID          string `json:"id"`
+	UserID      int64  `json:"userId"`
+	RefreshHash string `json:"-"` // 現在有効なリフレッシュトークンのSHA-256
+	UserAgent   string `json:"userAgent"`
+	IP          string `json:"ip"`
+	CreatedAt   string `json:"createdAt"`           // RFC3339
+	LastUsedAt  string `json:"lastUsedAt"`          // RFC3339（最後にリフレッシュした日時）
+	ExpiresAt   string `json:"expiresAt"`           // RFC3339（リフレッシュトークンの有効期限）
+	RevokedAt   string `json:"revokedAt,omitempty"` // RFC3339
+	Current     bool   `json:"current"`             // リクエスト元のセッションかどうか
+
+	PrevRefreshHash string `json:"-"` // 直前のリフレッシュトークンのSHA-256
+	RotatedAt       string `json:"-"` // RFC3339（直前のリフレッシュトークンを置き換えた日時）
+}
@@ -0,0 +1,14 @@
+package models
+
+// SummaryCacheEntry AI要約のキャッシュ
+type SummaryCacheEntry struct {
+	Key           string `json:"-"`
+	Endpoint      string `json:"endpoint"`
+	Target        string `json:"target"` // 正規化済みの記事URL / リポジトリ（owner/repo）
+	PromptVersion string `json:"promptVersion"`
+	Model         string `json:"model"`
+	ContentHash   string `json:"contentHash"`
+	Summary       string `json:"summary"`
+	CachedAt      string `json:"cachedAt"`  // RFC3339
+	ExpiresAt     string `json:"expiresAt"` // RFC3339
+}
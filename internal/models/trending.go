@@ -0,0 +1,88 @@
+package models
+
+// GitHub Trendingの集計期間
+const (
+	TrendingSinceDaily   = "daily"
+	TrendingSinceWeekly  = "weekly"
+	TrendingSinceMonthly = "monthly"
+)
+
+// TrendingQuery GitHub Trendingの取得条件
+type TrendingQuery struct {
+	Language           string `json:"language"`           // プログラミング言語（空は全言語）
+	Since              string `json:"since"`              // daily / weekly / monthly
+	SpokenLanguageCode string `json:"spokenLanguageCode"` // 説明文の言語（ISO 639-1、空は全言語）
+
+	// Languages 複数の言語のトレンドを連結して取得する場合の言語（ユーザーの表示設定、Languageより優先）
+	Languages []string `json:"-"`
+}
+
+// TrendingContributor リポジトリの主なコントリビューター（Built by）
+type TrendingContributor struct {
+	Username  string `json:"username"`
+	URL       string `json:"url"`
+	AvatarURL string `json:"avatarUrl"`
+}
+
+// TrendingRepository GitHub Trendingのリポジトリ
+type TrendingRepository struct {
+	Rank        int                   `json:"rank"`
+	Name        string                `json:"name"` // owner/repo
+	Owner       string                `json:"owner"`
+	Repo        string                `json:"repo"`
+	URL         string                `json:"url"`
+	Description string                `json:"description"`
+	Language    string                `json:"language"`
+	Stars       int                   `json:"stars"`
+	Forks       int                   `json:"forks"`
+	StarsGained int                   `json:"starsGained"` // 集計期間中に増えたスター数
+	BuiltBy     []TrendingContributor `json:"builtBy"`
+}
+
+// TrendingDeveloper GitHub Trendingの開発者
+type TrendingDeveloper struct {
+	Rank        int                    `json:"rank"`
+	Name        string                 `json:"name"`
+	Username    string                 `json:"username"`
+	URL         string                 `json:"url"`
+	AvatarURL   string                 `json:"avatarUrl"`
+	PopularRepo *TrendingDeveloperRepo `json:"popularRepo,omitempty"`
+}
+
+// TrendingDeveloperRepo 開発者の代表的なリポジトリ
+type TrendingDeveloperRepo struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// TrendingSnapshotEntry 日次スナップショットに保存されるリポジトリの順位
+type TrendingSnapshotEntry struct {
+	Date        string `json:"date"` // YYYY-MM-DD
+	Scope       string `json:"scope"`
+	Rank        int    `json:"rank"`
+	FullName    string `json:"fullName"` // owner/repo
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	Stars       int    `json:"stars"`
+}
+
+// TrendingRankChange 前回スナップショットからの順位変動
+type TrendingRankChange struct {
+	FullName     string `json:"fullName"`
+	Rank         int    `json:"rank"`
+	PreviousRank int    `json:"previousRank"`
+	Change       int    `json:"change"` // 正の値は順位上昇
+	Stars        int    `json:"stars"`
+}
+
+// TrendingDiff 前回スナップショットとの比較結果
+type TrendingDiff struct {
+	Scope        string                  `json:"scope"`
+	Date         string                  `json:"date"`
+	PreviousDate string                  `json:"previousDate"`
+	NewEntries   []TrendingSnapshotEntry `json:"newEntries"`
+	DroppedOut   []TrendingSnapshotEntry `json:"droppedOut"`
+	Climbing     []TrendingRankChange    `json:"climbing"`
+	Falling      []TrendingRankChange    `json:"falling"`
+}
@@ -0,0 +1,56 @@
+package models
+
+// ユーザーのロール（後のロールほど権限が強く、前のロールの操作をすべて行えます）
+const (
+	RoleViewer     = "viewer"     // フィード・トレンド・ダイジェストの閲覧
+	RoleSummarizer = "summarizer" // 閲覧に加えてAI要約の実行（LLMの利用枠を消費する操作）
+	RoleAdmin      = "admin"      // ユーザー・購読者・配信の管理
+)
+
+// Roles 権限の弱い順のロール
+var Roles = []string{RoleViewer, RoleSummarizer, RoleAdmin}
+
+// User ログインユーザー
+type User struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"-"`
+	Role         string `json:"role"`
+	Disabled     bool   `json:"disabled"`
+	CreatedAt    string `json:"createdAt"`             // RFC3339
+	UpdatedAt    string `json:"updatedAt"`             // RFC3339
+	LastLoginAt  string `json:"lastLoginAt,omitempty"` // RFC3339
+}
+
+// IsAdmin 管理者かどうかを返します
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// RoleAtLeast roleがrequired以上の権限を持つかどうかを返します（不明なロールは権限なし）
+func RoleAtLeast(role, required string) bool {
+	rank, requiredRank := roleRank(role), roleRank(required)
+	return rank >= 0 && requiredRank >= 0 && rank >= requiredRank
+}
+
+// LowerRole 2つのロールのうち権限の弱い方を返します
+func LowerRole(a, b string) string {
+	if roleRank(a) <= roleRank(b) {
+		return a
+	}
+	return b
+}
+
+// ValidRole 定義済みのロールかどうかを返します
+func ValidRole(role string) bool {
+	return roleRank(role) >= 0
+}
+
+func roleRank(role string) int {
+	for i, r := range Roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// AI利用量の集計の単位
+const (
+	AIUsageGroupUser     = "user"
+	AIUsageGroupEndpoint = "endpoint"
+	AIUsageGroupModel    = "model"
+	AIUsageGroupProvider = "provider"
+	AIUsageGroupDay      = "day" // サーバーのローカルタイムゾーンの日付
+)
+
+// aiUsageGroupColumns 集計の単位ごとのGROUP BYの式
+var aiUsageGroupColumns = map[string]string{
+	AIUsageGroupUser:     "username",
+	AIUsageGroupEndpoint: "endpoint",
+	AIUsageGroupModel:    "model",
+	AIUsageGroupProvider: "provider",
+	AIUsageGroupDay:      "date(created_at, 'localtime')",
+}
+
+// AddAIUsage AI要約1回分の利用量を記録します（CreatedAtが空の場合は現在時刻）
+func (s *Store) AddAIUsage(r models.AIUsageRecord) error {
+	if r.CreatedAt == "" {
+		r.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO ai_usage (user_id, username, endpoint, provider, model, prompt_tokens, output_tokens, cost_usd, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.UserID, r.Username, r.Endpoint, r.Provider, r.Model, r.PromptTokens, r.OutputTokens, r.CostUSD, r.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("AI利用量の記録に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// SumAIUsage 条件に一致する利用量の合計を返します
+func (s *Store) SumAIUsage(q models.AIUsageQuery) (models.AIUsageTotals, error) {
+	totals, err := s.SummarizeAIUsage(q, "")
+	if err != nil {
+		return models.AIUsageTotals{}, err
+	}
+	return totals[0], nil
+}
+
+// SummarizeAIUsage 条件に一致する利用量を集計の単位ごとに合計し、コストの大きい順に返します
+// groupByが空の場合は全体の合計を1件返します
+func (s *Store) SummarizeAIUsage(q models.AIUsageQuery, groupBy string) ([]models.AIUsageTotals, error) {
+	key := "''"
+	if groupBy != "" {
+		column, ok := aiUsageGroupColumns[groupBy]
+		if !ok {
+			return nil, fmt.Errorf("AI利用量の集計単位が不正です: %s", groupBy)
+		}
+		key = column
+	}
+
+	query := `
+		SELECT ` + key + `, COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(output_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM ai_usage
+		WHERE (? = '' OR created_at >= ?)
+		  AND (? = '' OR created_at < ?)
+		  AND (? = 0 OR user_id = ?)
+		  AND (? = '' OR username = ? COLLATE NOCASE)`
+	if groupBy != "" {
+		query += ` GROUP BY 1 ORDER BY 5 DESC, 1`
+	}
+	rows, err := s.db.Query(query,
+		q.Since, q.Since, q.Until, q.Until, q.UserID, q.UserID, q.Username, q.Username)
+	if err != nil {
+		return nil, fmt.Errorf("AI利用量の集計に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	totals := []models.AIUsageTotals{}
+	for rows.Next() {
+		var t models.AIUsageTotals
+		if err := rows.Scan(&t.Key, &t.Requests, &t.PromptTokens, &t.OutputTokens, &t.CostUSD); err != nil {
+			return nil, fmt.Errorf("AI利用量の読み込みに失敗しました: %w", err)
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
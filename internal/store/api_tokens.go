@@ -0,0 +1,107 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+const apiTokenColumns = `id, user_id, name, prefix, token_hash, scopes, created_at, last_used_at, expires_at, revoked_at`
+
+// CreateAPIToken APIトークンを保存し、IDを設定して返します
+func (s *Store) CreateAPIToken(t models.APIToken) (models.APIToken, error) {
+	scopes, err := json.Marshal(t.Scopes)
+	if err != nil {
+		return t, fmt.Errorf("スコープのエンコードに失敗しました: %w", err)
+	}
+	res, err := s.db.Exec(`
+		INSERT INTO api_tokens (user_id, name, prefix, token_hash, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		t.UserID, t.Name, t.Prefix, t.TokenHash, string(scopes), t.CreatedAt, t.ExpiresAt)
+	if err != nil {
+		return t, fmt.Errorf("APIトークンの保存に失敗しました: %w", err)
+	}
+	t.ID, err = res.LastInsertId()
+	if err != nil {
+		return t, fmt.Errorf("APIトークンIDの取得に失敗しました: %w", err)
+	}
+	return t, nil
+}
+
+// GetAPITokenByHash トークンのハッシュでAPIトークンを返します（存在しない場合はnil）
+func (s *Store) GetAPITokenByHash(hash string) (*models.APIToken, error) {
+	t, err := scanAPIToken(s.db.QueryRow(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE token_hash = ?`, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return t, err
+}
+
+// ListAPITokens ユーザーの失効していないAPIトークンを作成順に返します
+func (s *Store) ListAPITokens(userID int64) ([]models.APIToken, error) {
+	rows, err := s.db.Query(`
+		SELECT `+apiTokenColumns+`
+		FROM api_tokens
+		WHERE user_id = ? AND revoked_at = ''
+		ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("APIトークン一覧の取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokeAPIToken ユーザーのAPIトークンを失効させます（対象が存在しない・失効済みの場合はfalse）
+func (s *Store) RevokeAPIToken(userID, id int64) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE api_tokens SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at = ''`,
+		time.Now().UTC().Format(time.RFC3339), id, userID)
+	if err != nil {
+		return false, fmt.Errorf("APIトークンの失効に失敗しました: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("APIトークンの失効結果の取得に失敗しました: %w", err)
+	}
+	return n > 0, nil
+}
+
+// TouchAPIToken APIトークンの最終使用日時を記録します
+func (s *Store) TouchAPIToken(id int64) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("APIトークンの最終使用日時の更新に失敗しました: %w", err)
+	}
+	return nil
+}
+
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	var t models.APIToken
+	var scopes string
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.TokenHash, &scopes,
+		&t.CreatedAt, &t.LastUsedAt, &t.ExpiresAt, &t.RevokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("APIトークンの読み込みに失敗しました: %w", err)
+	}
+	if err := json.Unmarshal([]byte(scopes), &t.Scopes); err != nil || t.Scopes == nil {
+		t.Scopes = []string{}
+	}
+	return &t, nil
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// SaveArticles 記事をGUID（ソース単位）で重複排除して保存し、新規に追加された記事を返します
+// 既存の記事はタイトル・説明などを最新の内容で更新します
+func (s *Store) SaveArticles(items []models.FeedItem) ([]models.FeedItem, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var inserted []models.FeedItem
+	for _, item := range items {
+		if item.GUID == "" {
+			continue
+		}
+		categories, err := json.Marshal(item.Categories)
+		if err != nil {
+			return nil, fmt.Errorf("カテゴリのエンコードに失敗しました: %w", err)
+		}
+
+		var exists bool
+		err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM articles WHERE source_id = ? AND guid = ?)`,
+			item.SourceID, item.GUID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("記事の存在確認に失敗しました: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO articles (source_id, guid, title, link, description, author, categories, image, published, updated, language, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (source_id, guid) DO UPDATE SET
+				title = excluded.title,
+				link = excluded.link,
+				description = excluded.description,
+				author = excluded.author,
+				categories = excluded.categories,
+				image = excluded.image,
+				published = excluded.published,
+				updated = excluded.updated`,
+			item.SourceID, item.GUID, item.Title, item.Link, item.Description, item.Author,
+			string(categories), item.Image, item.Published, item.Updated, item.Language, now)
+		if err != nil {
+			return nil, fmt.Errorf("記事の保存に失敗しました: %w", err)
+		}
+		if !exists {
+			inserted = append(inserted, item)
+		}
+
+		err = indexDocument(tx, models.SearchDocument{
+			Kind:      models.SearchKindArticle,
+			Ref:       item.SourceID + ":" + item.GUID,
+			SourceID:  item.SourceID,
+			Language:  item.Language,
+			Link:      item.Link,
+			Published: item.Published,
+			Title:     item.Title,
+			Body:      item.Description,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("記事の保存のコミットに失敗しました: %w", err)
+	}
+	return inserted, nil
+}
+
+// ListArticles ソースの記事を公開日時の新しい順に返します
+func (s *Store) ListArticles(sourceID string, limit int) ([]models.FeedItem, error) {
+	return s.queryArticles(`
+		SELECT guid, title, link, description, author, categories, image, published, updated, source_id, language
+		FROM articles
+		WHERE source_id = ?
+		ORDER BY published DESC, id DESC
+		LIMIT ?`, sourceID, limit)
+}
+
+// ListArticlesBetween 公開日時が [from, to) の範囲にあるソースの記事を新しい順に返します（RFC3339 UTC）
+func (s *Store) ListArticlesBetween(sourceID, from, to string, limit int) ([]models.FeedItem, error) {
+	return s.queryArticles(`
+		SELECT guid, title, link, description, author, categories, image, published, updated, source_id, language
+		FROM articles
+		WHERE source_id = ? AND published >= ? AND published < ?
+		ORDER BY published DESC, id DESC
+		LIMIT ?`, sourceID, from, to, limit)
+}
+
+// ListRecentArticles 指定ソース（空の場合は全ソース）の記事を公開日時の新しい順に返します
+func (s *Store) ListRecentArticles(sourceIDs []string, limit int) ([]models.FeedItem, error) {
+	query := `
+		SELECT guid, title, link, description, author, categories, image, published, updated, source_id, language
+		FROM articles`
+	args := []any{}
+	if len(sourceIDs) > 0 {
+		query += ` WHERE source_id IN (?` + strings.Repeat(`, ?`, len(sourceIDs)-1) + `)`
+		for _, id := range sourceIDs {
+			args = append(args, id)
+		}
+	}
+	query += ` ORDER BY published DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+	return s.queryArticles(query, args...)
+}
+
+func (s *Store) queryArticles(query string, args ...any) ([]models.FeedItem, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("記事の取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.FeedItem{}
+	for rows.Next() {
+		var item models.FeedItem
+		var categories string
+		if err := rows.Scan(&item.GUID, &item.Title, &item.Link, &item.Description, &item.Author,
+			&categories, &item.Image, &item.Published, &item.Updated, &item.SourceID, &item.Language); err != nil {
+			return nil, fmt.Errorf("記事の読み込みに失敗しました: %w", err)
+		}
+		if err := json.Unmarshal([]byte(categories), &item.Categories); err != nil || item.Categories == nil {
+			item.Categories = []string{}
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// AddAuditEvent 監査ログを1件追加します（CreatedAtが空の場合は現在時刻）
+func (s *Store) AddAuditEvent(e models.AuditEvent) error {
+	if e.CreatedAt == "" {
+		e.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO audit_events (event, user_id, username, actor, ip, user_agent, detail, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Event, e.UserID, e.Username, e.Actor, e.IP, e.UserAgent, e.Detail, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("監査ログの保存に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEvents 条件に一致する監査ログを新しい順に返します
+func (s *Store) ListAuditEvents(q models.AuditQuery) ([]models.AuditEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT id, event, user_id, username, actor, ip, user_agent, detail, created_at
+		FROM audit_events
+		WHERE (? = '' OR event = ?)
+		  AND (? = '' OR username = ? COLLATE NOCASE)
+		  AND (? = '' OR ip = ?)
+		  AND (? = '' OR created_at >= ?)
+		  AND (? = '' OR created_at < ?)
+		  AND (? = 0 OR id < ?)
+		ORDER BY id DESC
+		LIMIT ?`,
+		q.Event, q.Event, q.Username, q.Username, q.IP, q.IP,
+		q.Since, q.Since, q.Until, q.Until, q.BeforeID, q.BeforeID, q.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("監査ログの取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		if err := rows.Scan(&e.ID, &e.Event, &e.UserID, &e.Username, &e.Actor, &e.IP, &e.UserAgent, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("監査ログの読み込みに失敗しました: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// PurgeAuditEvents before より前の監査ログを削除し、削除した件数を返します
+func (s *Store) PurgeAuditEvents(before time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM audit_events WHERE created_at < ?`, before.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("古い監査ログの削除に失敗しました: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"fmt"
+
+	"trends-summary/internal/models"
+)
+
+// SaveDelivery 配信ログを保存し、採番したIDを返します
+func (s *Store) SaveDelivery(d models.Delivery) (int64, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO deliveries (target, type, event, ref, status, attempts, status_code, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.Target, d.Type, d.Event, d.Ref, d.Status, d.Attempts, d.StatusCode, d.Error, d.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("配信ログの保存に失敗しました: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// ListDeliveries 配信ログを新しい順に返します（targetが空の場合は全配信先）
+func (s *Store) ListDeliveries(target string, limit int) ([]models.Delivery, error) {
+	rows, err := s.db.Query(`
+		SELECT id, target, type, event, ref, status, attempts, status_code, error, created_at
+		FROM deliveries
+		WHERE ? = '' OR target = ?
+		ORDER BY id DESC
+		LIMIT ?`, target, target, limit)
+	if err != nil {
+		return nil, fmt.Errorf("配信ログの取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.Delivery{}
+	for rows.Next() {
+		var d models.Delivery
+		if err := rows.Scan(&d.ID, &d.Target, &d.Type, &d.Event, &d.Ref, &d.Status, &d.Attempts, &d.StatusCode, &d.Error, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("配信ログの読み込みに失敗しました: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
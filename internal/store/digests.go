@@ -0,0 +1,101 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"trends-summary/internal/models"
+)
+
+// SaveDigest ダイジェストを保存します（同じ日付は置き換え）
+func (s *Store) SaveDigest(d models.Digest) error {
+	sources, err := json.Marshal(d.Sources)
+	if err != nil {
+		return fmt.Errorf("ソースのエンコードに失敗しました: %w", err)
+	}
+	input, err := json.Marshal(d.Input)
+	if err != nil {
+		return fmt.Errorf("ダイジェストの元データのエンコードに失敗しました: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO digests (date, sources, input, summary, model, prompt_version, generated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (date) DO UPDATE SET
+			sources = excluded.sources,
+			input = excluded.input,
+			summary = excluded.summary,
+			model = excluded.model,
+			prompt_version = excluded.prompt_version,
+			generated_at = excluded.generated_at`,
+		d.Date, string(sources), string(input), d.Summary, d.Model, d.PromptVersion, d.GeneratedAt)
+	if err != nil {
+		return fmt.Errorf("ダイジェストの保存に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// GetDigest 指定日のダイジェストを元データ付きで返します（存在しない場合はnil）
+func (s *Store) GetDigest(date string) (*models.Digest, error) {
+	var d models.Digest
+	var sources, input string
+	err := s.db.QueryRow(`
+		SELECT date, sources, input, summary, model, prompt_version, generated_at
+		FROM digests
+		WHERE date = ?`, date).
+		Scan(&d.Date, &sources, &input, &d.Summary, &d.Model, &d.PromptVersion, &d.GeneratedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ダイジェストの取得に失敗しました: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(sources), &d.Sources); err != nil {
+		return nil, fmt.Errorf("ソースのデコードに失敗しました: %w", err)
+	}
+	if err := json.Unmarshal([]byte(input), &d.Input); err != nil {
+		return nil, fmt.Errorf("ダイジェストの元データのデコードに失敗しました: %w", err)
+	}
+	return &d, nil
+}
+
+// HasDigest 指定日のダイジェストが保存済みか確認します
+func (s *Store) HasDigest(date string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM digests WHERE date = ?)`, date).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("ダイジェストの存在確認に失敗しました: %w", err)
+	}
+	return exists, nil
+}
+
+// ListDigests 期間内（from, to を含む）のダイジェストを新しい順に返します（元データは含みません）
+func (s *Store) ListDigests(from, to string, limit int) ([]models.Digest, error) {
+	rows, err := s.db.Query(`
+		SELECT date, sources, summary, model, prompt_version, generated_at
+		FROM digests
+		WHERE date >= ? AND date <= ?
+		ORDER BY date DESC
+		LIMIT ?`, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ダイジェスト一覧の取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	digests := []models.Digest{}
+	for rows.Next() {
+		var d models.Digest
+		var sources string
+		if err := rows.Scan(&d.Date, &sources, &d.Summary, &d.Model, &d.PromptVersion, &d.GeneratedAt); err != nil {
+			return nil, fmt.Errorf("ダイジェストの読み込みに失敗しました: %w", err)
+		}
+		if err := json.Unmarshal([]byte(sources), &d.Sources); err != nil || d.Sources == nil {
+			d.Sources = []string{}
+		}
+		digests = append(digests, d)
+	}
+	return digests, rows.Err()
+}
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// GetUserByIdentity 外部IdPのアカウント（issuer + subject）に紐付いたユーザーを返します（存在しない場合はnil）
+func (s *Store) GetUserByIdentity(issuer, subject string) (*models.User, error) {
+	return s.queryUser(`SELECT `+userColumns+` FROM users
+		WHERE id = (SELECT user_id FROM user_identities WHERE issuer = ? AND subject = ?)`, issuer, subject)
+}
+
+// LinkUserIdentity 外部IdPのアカウントをユーザーに紐付け、最終ログイン日時を記録します
+// 既に紐付いている場合はメールアドレスと最終ログイン日時のみ更新します
+func (s *Store) LinkUserIdentity(userID int64, issuer, subject, email string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := s.db.Exec(`
+		INSERT INTO user_identities (issuer, subject, user_id, email, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (issuer, subject) DO UPDATE SET
+			email = excluded.email,
+			last_login_at = excluded.last_login_at`,
+		issuer, subject, userID, email, now, now)
+	if err != nil {
+		return fmt.Errorf("外部アカウントの紐付けに失敗しました: %w", err)
+	}
+	return nil
+}
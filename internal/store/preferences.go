@@ -0,0 +1,73 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// GetPreferences ユーザーの表示設定を返します（未保存の場合は絞り込みなしの設定）
+func (s *Store) GetPreferences(userID int64) (models.UserPreferences, error) {
+	prefs := models.UserPreferences{
+		Sources:         []string{},
+		Keywords:        []string{},
+		GitHubLanguages: []string{},
+	}
+	var sources, keywords, githubLanguages string
+	err := s.db.QueryRow(`
+		SELECT sources, language, keywords, github_languages, updated_at
+		FROM user_preferences
+		WHERE user_id = ?`, userID).
+		Scan(&sources, &prefs.Language, &keywords, &githubLanguages, &prefs.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return prefs, nil
+	}
+	if err != nil {
+		return prefs, fmt.Errorf("表示設定の取得に失敗しました: %w", err)
+	}
+
+	for _, f := range []struct {
+		raw  string
+		dest *[]string
+	}{
+		{sources, &prefs.Sources},
+		{keywords, &prefs.Keywords},
+		{githubLanguages, &prefs.GitHubLanguages},
+	} {
+		if err := json.Unmarshal([]byte(f.raw), f.dest); err != nil {
+			return prefs, fmt.Errorf("表示設定のデコードに失敗しました: %w", err)
+		}
+	}
+	return prefs, nil
+}
+
+// SavePreferences ユーザーの表示設定を保存します（既存の設定は置き換え）
+func (s *Store) SavePreferences(userID int64, prefs models.UserPreferences) (models.UserPreferences, error) {
+	encode := func(list []string) string {
+		if list == nil {
+			list = []string{}
+		}
+		b, _ := json.Marshal(list)
+		return string(b)
+	}
+
+	prefs.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	_, err := s.db.Exec(`
+		INSERT INTO user_preferences (user_id, sources, language, keywords, github_languages, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			sources = excluded.sources,
+			language = excluded.language,
+			keywords = excluded.keywords,
+			github_languages = excluded.github_languages,
+			updated_at = excluded.updated_at`,
+		userID, encode(prefs.Sources), prefs.Language, encode(prefs.Keywords), encode(prefs.GitHubLanguages), prefs.UpdatedAt)
+	if err != nil {
+		return prefs, fmt.Errorf("表示設定の保存に失敗しました: %w", err)
+	}
+	return prefs, nil
+}
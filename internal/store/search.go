@@ -0,0 +1,142 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"trends-summary/internal/models"
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// スニペットの一致箇所の印（HTMLエスケープ後に <mark> に置き換えるため、本文に現れない制御文字を使う）
+const (
+	snippetMarkStart = "\x02"
+	snippetMarkEnd   = "\x03"
+)
+
+// plainText HTMLタグを除去して検索用のテキストにします
+// 実体参照は文字に戻すため、インデックスの内容はHTMLとして出力する前にエスケープが必要です
+func plainText(s string) string {
+	s = strings.NewReplacer(snippetMarkStart, "", snippetMarkEnd, "").Replace(s)
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTagPattern.ReplaceAllString(s, " "))), " ")
+}
+
+// snippetHTML スニペットをHTMLエスケープし、一致箇所の印を <mark> に置き換えます
+func snippetHTML(s string) string {
+	return strings.NewReplacer(snippetMarkStart, "<mark>", snippetMarkEnd, "</mark>").Replace(html.EscapeString(s))
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// IndexDocument ドキュメントを検索インデックスに登録します（同じ種別・キーは置き換え）
+func (s *Store) IndexDocument(doc models.SearchDocument) error {
+	return indexDocument(s.db, doc)
+}
+
+func indexDocument(db execer, doc models.SearchDocument) error {
+	if _, err := db.Exec(`DELETE FROM search_index WHERE kind = ? AND ref = ?`, doc.Kind, doc.Ref); err != nil {
+		return fmt.Errorf("検索インデックスの削除に失敗しました: %w", err)
+	}
+	_, err := db.Exec(`
+		INSERT INTO search_index (kind, ref, source_id, language, link, published, title, body)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		doc.Kind, doc.Ref, doc.SourceID, doc.Language, doc.Link, doc.Published, plainText(doc.Title), plainText(doc.Body))
+	if err != nil {
+		return fmt.Errorf("検索インデックスの登録に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// Search 全文検索を実行します
+// trigramトークナイザのため3文字以上の語はMATCH、それ未満の語は部分一致で検索します
+func (s *Store) Search(q models.SearchQuery) (models.SearchResult, error) {
+	var where []string
+	var args []any
+	matchTerms := []string{}
+
+	for _, term := range strings.Fields(q.Query) {
+		if utf8.RuneCountInString(term) >= 3 {
+			matchTerms = append(matchTerms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+			continue
+		}
+		like := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term) + "%"
+		where = append(where, `(title LIKE ? ESCAPE '\' OR body LIKE ? ESCAPE '\')`)
+		args = append(args, like, like)
+	}
+	if len(matchTerms) > 0 {
+		where = append([]string{`search_index MATCH ?`}, where...)
+		args = append([]any{strings.Join(matchTerms, " AND ")}, args...)
+	}
+
+	if q.Kind != "" {
+		where = append(where, `kind = ?`)
+		args = append(args, q.Kind)
+	}
+	if q.SourceID != "" {
+		where = append(where, `source_id = ?`)
+		args = append(args, q.SourceID)
+	}
+	if q.Language != "" {
+		where = append(where, `language = ?`)
+		args = append(args, q.Language)
+	}
+	if q.From != "" {
+		where = append(where, `published >= ?`)
+		args = append(args, q.From)
+	}
+	if q.To != "" {
+		to, err := time.Parse("2006-01-02", q.To)
+		if err != nil {
+			return models.SearchResult{}, fmt.Errorf("toの日付形式が不正です: %w", err)
+		}
+		where = append(where, `published < ?`)
+		args = append(args, to.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+
+	cond := ""
+	if len(where) > 0 {
+		cond = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	result := models.SearchResult{Page: q.Page, PerPage: q.PerPage, Items: []models.SearchHit{}}
+	if err := s.db.QueryRow(`SELECT count(*) FROM search_index `+cond, args...).Scan(&result.Total); err != nil {
+		return models.SearchResult{}, fmt.Errorf("検索件数の取得に失敗しました: %w", err)
+	}
+
+	// MATCHがある場合は関連度順、ない場合は新しい順
+	snippet := `substr(body, 1, 120)`
+	order := `published DESC`
+	if len(matchTerms) > 0 {
+		snippet = `snippet(search_index, 7, char(2), char(3), '…', 24)`
+		order = `rank`
+	}
+
+	rows, err := s.db.Query(`
+		SELECT kind, source_id, language, title, link, `+snippet+`, published
+		FROM search_index `+cond+`
+		ORDER BY `+order+`
+		LIMIT ? OFFSET ?`,
+		append(args, q.PerPage, (q.Page-1)*q.PerPage)...)
+	if err != nil {
+		return models.SearchResult{}, fmt.Errorf("検索に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hit models.SearchHit
+		if err := rows.Scan(&hit.Kind, &hit.SourceID, &hit.Language, &hit.Title, &hit.Link, &hit.Snippet, &hit.Published); err != nil {
+			return models.SearchResult{}, fmt.Errorf("検索結果の読み込みに失敗しました: %w", err)
+		}
+		hit.Snippet = snippetHTML(hit.Snippet)
+		result.Items = append(result.Items, hit)
+	}
+	return result, rows.Err()
+}
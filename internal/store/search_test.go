@@ -0,0 +1,52 @@
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"trends-summary/internal/models"
+)
+
+func TestSearchSnippetEscapesIndexedText(t *testing.T) {
+	st := openTestStore(t)
+	err := st.IndexDocument(models.SearchDocument{
+		Kind:      "article",
+		Ref:       "src:1",
+		SourceID:  "src",
+		Link:      "https://example.com/1",
+		Published: "2024-01-01T00:00:00Z",
+		Title:     "&lt;img src=x onerror=alert(1)&gt; title",
+		Body:      "<p>&lt;script&gt;alert(1)&lt;/script&gt; vulnerable \x02sample\x03 code</p>",
+	})
+	if err != nil {
+		t.Fatalf("IndexDocument: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string // スニペットに含まれるべき文字列
+	}{
+		{"MATCHのスニペット", "vulnerable", "script&gt; <mark>vulnerable</mark> sample"},
+		{"部分一致のスニペット", "co", "&lt;script&gt;alert(1)&lt;/script&gt; vulnerable sample code"},
+		{"記号を含む語の検索", "<script>", "<mark>&lt;script&gt;</mark>alert(1)&lt;/script&gt;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := st.Search(models.SearchQuery{Query: tt.query, Page: 1, PerPage: 10})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(result.Items) != 1 {
+				t.Fatalf("len(Items) = %d, want 1", len(result.Items))
+			}
+			snippet := result.Items[0].Snippet
+			if !strings.Contains(snippet, tt.want) {
+				t.Errorf("Snippet = %q, want to contain %q", snippet, tt.want)
+			}
+			if strings.Contains(strings.NewReplacer("<mark>", "", "</mark>", "").Replace(snippet), "<") {
+				t.Errorf("Snippet contains unescaped markup: %q", snippet)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+const sessionColumns = `id, user_id, refresh_hash, user_agent, ip, created_at, last_used_at, expires_at, revoked_at, prev_refresh_hash, rotated_at`
+
+// CreateSession セッションを保存します
+func (s *Store) CreateSession(session models.Session) error {
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (`+sessionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.ID, session.UserID, session.RefreshHash, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt, session.RevokedAt,
+		session.PrevRefreshHash, session.RotatedAt)
+	if err != nil {
+		return fmt.Errorf("セッションの保存に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// GetSession IDでセッションを返します（存在しない場合はnil）
+func (s *Store) GetSession(id string) (*models.Session, error) {
+	session, err := scanSession(s.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return session, err
+}
+
+// RotateSession リフレッシュトークンがoldHashと一致する有効なセッションのトークンを置き換えます
+// oldHashは直前のトークンとして置き換えた日時とともに保存します
+// 置き換えた場合にtrueを返します（同じトークンでの同時リフレッシュは1つだけが成功します）
+func (s *Store) RotateSession(id, oldHash, newHash, expiresAt string) (bool, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	res, err := s.db.Exec(`
+		UPDATE sessions SET refresh_hash = ?, prev_refresh_hash = refresh_hash, rotated_at = ?, last_used_at = ?, expires_at = ?
+		WHERE id = ? AND refresh_hash = ? AND revoked_at = ''`,
+		newHash, now, now, expiresAt, id, oldHash)
+	if err != nil {
+		return false, fmt.Errorf("セッションの更新に失敗しました: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("セッションの更新結果の取得に失敗しました: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListSessions ユーザーの有効なセッションを最近使用した順に返します
+func (s *Store) ListSessions(userID int64) ([]models.Session, error) {
+	rows, err := s.db.Query(`
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_id = ? AND revoked_at = '' AND expires_at > ?
+		ORDER BY last_used_at DESC`, userID, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("セッション一覧の取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession ユーザーのセッションを失効させます（対象が存在しない・失効済みの場合はfalse）
+func (s *Store) RevokeSession(userID int64, id string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE sessions SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at = ''`,
+		time.Now().UTC().Format(time.RFC3339), id, userID)
+	if err != nil {
+		return false, fmt.Errorf("セッションの失効に失敗しました: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("セッションの失効結果の取得に失敗しました: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RevokeUserSessions ユーザーのセッションをexceptID以外すべて失効させ、失効させた件数を返します
+func (s *Store) RevokeUserSessions(userID int64, exceptID string) (int64, error) {
+	res, err := s.db.Exec(`
+		UPDATE sessions SET revoked_at = ?
+		WHERE user_id = ? AND id != ? AND revoked_at = ''`,
+		time.Now().UTC().Format(time.RFC3339), userID, exceptID)
+	if err != nil {
+		return 0, fmt.Errorf("セッションの失効に失敗しました: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// PurgeExpiredSessions 有効期限切れのセッションを削除します
+func (s *Store) PurgeExpiredSessions() error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE expires_at <= ?`, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("期限切れセッションの削除に失敗しました: %w", err)
+	}
+	return nil
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	var session models.Session
+	err := row.Scan(&session.ID, &session.UserID, &session.RefreshHash, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt,
+		&session.PrevRefreshHash, &session.RotatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("セッションの読み込みに失敗しました: %w", err)
+	}
+	return &session, nil
+}
@@ -0,0 +1,252 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite" // SQLiteドライバ（cgo不要）
+)
+
+// Store SQLiteによる永続化ストア
+type Store struct {
+	db *sql.DB
+}
+
+// migrations スキーマ定義（PRAGMA user_version で適用済みの位置を管理）
+// 既存の要素は変更せず、末尾に追加すること
+var migrations = []string{
+	`CREATE TABLE articles (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_id   TEXT NOT NULL,
+		guid        TEXT NOT NULL,
+		title       TEXT NOT NULL,
+		link        TEXT NOT NULL,
+		description TEXT NOT NULL,
+		author      TEXT NOT NULL,
+		categories  TEXT NOT NULL,
+		image       TEXT NOT NULL,
+		published   TEXT NOT NULL,
+		updated     TEXT NOT NULL,
+		language    TEXT NOT NULL,
+		fetched_at  TEXT NOT NULL,
+		UNIQUE (source_id, guid)
+	);
+	CREATE INDEX articles_source_published ON articles (source_id, published DESC);`,
+
+	`CREATE VIRTUAL TABLE search_index USING fts5 (
+		kind UNINDEXED,
+		ref UNINDEXED,
+		source_id UNINDEXED,
+		language UNINDEXED,
+		link UNINDEXED,
+		published UNINDEXED,
+		title,
+		body,
+		tokenize = 'trigram'
+	);
+	INSERT INTO search_index (kind, ref, source_id, language, link, published, title, body)
+	SELECT 'article', source_id || ':' || guid, source_id, language, link, published, title, description
+	FROM articles;`,
+
+	`CREATE TABLE trending_snapshots (
+		date        TEXT NOT NULL,
+		scope       TEXT NOT NULL,
+		rank        INTEGER NOT NULL,
+		full_name   TEXT NOT NULL,
+		description TEXT NOT NULL,
+		language    TEXT NOT NULL,
+		stars       INTEGER NOT NULL,
+		captured_at TEXT NOT NULL,
+		PRIMARY KEY (date, scope, full_name)
+	);
+	CREATE INDEX trending_snapshots_repo ON trending_snapshots (full_name, scope, date);`,
+
+	`CREATE TABLE summary_cache (
+		cache_key      TEXT PRIMARY KEY,
+		endpoint       TEXT NOT NULL,
+		target         TEXT NOT NULL,
+		prompt_version TEXT NOT NULL,
+		model          TEXT NOT NULL,
+		content_hash   TEXT NOT NULL,
+		summary        TEXT NOT NULL,
+		cached_at      TEXT NOT NULL,
+		expires_at     TEXT NOT NULL
+	);
+	CREATE INDEX summary_cache_expires ON summary_cache (expires_at);`,
+
+	`CREATE TABLE digests (
+		date           TEXT PRIMARY KEY,
+		sources        TEXT NOT NULL,
+		input          TEXT NOT NULL,
+		summary        TEXT NOT NULL,
+		model          TEXT NOT NULL,
+		prompt_version TEXT NOT NULL,
+		generated_at   TEXT NOT NULL
+	);`,
+
+	`CREATE TABLE deliveries (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		target      TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		event       TEXT NOT NULL,
+		ref         TEXT NOT NULL,
+		status      TEXT NOT NULL,
+		attempts    INTEGER NOT NULL,
+		status_code INTEGER NOT NULL,
+		error       TEXT NOT NULL,
+		created_at  TEXT NOT NULL
+	);`,
+
+	`CREATE TABLE subscribers (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		email           TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		token           TEXT NOT NULL UNIQUE,
+		created_at      TEXT NOT NULL,
+		unsubscribed_at TEXT NOT NULL DEFAULT ''
+	);`,
+
+	`CREATE TABLE users (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		username      TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		password_hash TEXT NOT NULL,
+		is_admin      INTEGER NOT NULL DEFAULT 0,
+		disabled      INTEGER NOT NULL DEFAULT 0,
+		created_at    TEXT NOT NULL,
+		updated_at    TEXT NOT NULL,
+		last_login_at TEXT NOT NULL DEFAULT ''
+	);`,
+
+	`CREATE TABLE user_preferences (
+		user_id          INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+		sources          TEXT NOT NULL DEFAULT '[]',
+		language         TEXT NOT NULL DEFAULT '',
+		keywords         TEXT NOT NULL DEFAULT '[]',
+		github_languages TEXT NOT NULL DEFAULT '[]',
+		updated_at       TEXT NOT NULL
+	);`,
+
+	`CREATE TABLE sessions (
+		id           TEXT PRIMARY KEY,
+		user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		refresh_hash TEXT NOT NULL,
+		user_agent   TEXT NOT NULL DEFAULT '',
+		ip           TEXT NOT NULL DEFAULT '',
+		created_at   TEXT NOT NULL,
+		last_used_at TEXT NOT NULL,
+		expires_at   TEXT NOT NULL,
+		revoked_at   TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX sessions_user ON sessions (user_id, expires_at);`,
+
+	`CREATE TABLE api_tokens (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		name         TEXT NOT NULL,
+		prefix       TEXT NOT NULL,
+		token_hash   TEXT NOT NULL UNIQUE,
+		scopes       TEXT NOT NULL DEFAULT '[]',
+		created_at   TEXT NOT NULL,
+		last_used_at TEXT NOT NULL DEFAULT '',
+		expires_at   TEXT NOT NULL DEFAULT '',
+		revoked_at   TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX api_tokens_user ON api_tokens (user_id);`,
+	`CREATE TABLE user_identities (
+		issuer        TEXT NOT NULL,
+		subject       TEXT NOT NULL,
+		user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		email         TEXT NOT NULL DEFAULT '',
+		created_at    TEXT NOT NULL,
+		last_login_at TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (issuer, subject)
+	);
+	CREATE INDEX user_identities_user ON user_identities (user_id);`,
+	// 管理者フラグをロールに置き換え（既存の一般ユーザーはAI要約を使えていたためsummarizerにする）
+	`ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'viewer';
+	UPDATE users SET role = CASE WHEN is_admin THEN 'admin' ELSE 'summarizer' END;
+	ALTER TABLE users DROP COLUMN is_admin;`,
+	`CREATE TABLE audit_events (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		event      TEXT NOT NULL,
+		user_id    INTEGER NOT NULL DEFAULT 0,
+		username   TEXT NOT NULL DEFAULT '',
+		actor      TEXT NOT NULL DEFAULT '',
+		ip         TEXT NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		detail     TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	);
+	CREATE INDEX audit_events_created ON audit_events (created_at);
+	CREATE INDEX audit_events_username ON audit_events (username COLLATE NOCASE);`,
+	`CREATE TABLE ai_usage (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id       INTEGER NOT NULL DEFAULT 0,
+		username      TEXT NOT NULL DEFAULT '',
+		endpoint      TEXT NOT NULL,
+		provider      TEXT NOT NULL,
+		model         TEXT NOT NULL,
+		prompt_tokens INTEGER NOT NULL DEFAULT 0,
+		output_tokens INTEGER NOT NULL DEFAULT 0,
+		cost_usd      REAL NOT NULL DEFAULT 0,
+		created_at    TEXT NOT NULL
+	);
+	CREATE INDEX ai_usage_created ON ai_usage (created_at);
+	CREATE INDEX ai_usage_user ON ai_usage (user_id, created_at);`,
+	// 複数のタブからの同時リフレッシュのため、直前のリフレッシュトークンを置き換えた直後に限り受け付ける
+	`ALTER TABLE sessions ADD COLUMN prev_refresh_hash TEXT NOT NULL DEFAULT '';
+	ALTER TABLE sessions ADD COLUMN rotated_at TEXT NOT NULL DEFAULT '';`,
+}
+
+// Open SQLiteデータベースを開き、未適用のマイグレーションを実行します
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("データベースのオープンに失敗しました: %w", err)
+	}
+	// SQLiteは書き込みが直列化されるため接続を1本に絞る
+	db.SetMaxOpenConns(1)
+
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close データベースを閉じます
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("スキーマバージョンの取得に失敗しました: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("マイグレーション%dの適用に失敗しました: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("スキーマバージョンの更新に失敗しました: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("マイグレーション%dのコミットに失敗しました: %w", i+1, err)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"function": "store.migrate",
+			"version":  i + 1,
+		}).Info("マイグレーションを適用しました")
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// openTestStore テスト用の一時ディレクトリにストアを作成します
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	st, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// AddSubscriber 購読者を追加します（配信停止済みのアドレスは再開します）
+func (s *Store) AddSubscriber(email string) (models.Subscriber, error) {
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return models.Subscriber{}, fmt.Errorf("トークンの生成に失敗しました: %w", err)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := s.db.Exec(`
+		INSERT INTO subscribers (email, token, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (email) DO UPDATE SET unsubscribed_at = ''`,
+		email, hex.EncodeToString(token), now)
+	if err != nil {
+		return models.Subscriber{}, fmt.Errorf("購読者の保存に失敗しました: %w", err)
+	}
+
+	var sub models.Subscriber
+	err = s.db.QueryRow(`
+		SELECT id, email, token, created_at, unsubscribed_at
+		FROM subscribers
+		WHERE email = ?`, email).
+		Scan(&sub.ID, &sub.Email, &sub.Token, &sub.CreatedAt, &sub.UnsubscribedAt)
+	if err != nil {
+		return models.Subscriber{}, fmt.Errorf("購読者の取得に失敗しました: %w", err)
+	}
+	return sub, nil
+}
+
+// ListSubscribers 購読者を登録順に返します（activeOnlyの場合は配信停止済みを除外）
+func (s *Store) ListSubscribers(activeOnly bool) ([]models.Subscriber, error) {
+	rows, err := s.db.Query(`
+		SELECT id, email, token, created_at, unsubscribed_at
+		FROM subscribers
+		WHERE NOT ? OR unsubscribed_at = ''
+		ORDER BY id`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("購読者の取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	subscribers := []models.Subscriber{}
+	for rows.Next() {
+		var sub models.Subscriber
+		if err := rows.Scan(&sub.ID, &sub.Email, &sub.Token, &sub.CreatedAt, &sub.UnsubscribedAt); err != nil {
+			return nil, fmt.Errorf("購読者の読み込みに失敗しました: %w", err)
+		}
+		subscribers = append(subscribers, sub)
+	}
+	return subscribers, rows.Err()
+}
+
+// DeleteSubscriber 購読者を削除します（存在しない場合はfalse）
+func (s *Store) DeleteSubscriber(id int64) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM subscribers WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("購読者の削除に失敗しました: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetSubscriberByToken 配信停止リンクのトークンに対応する購読者を返します（該当しない場合はnil）
+func (s *Store) GetSubscriberByToken(token string) (*models.Subscriber, error) {
+	var sub models.Subscriber
+	err := s.db.QueryRow(`
+		SELECT id, email, token, created_at, unsubscribed_at
+		FROM subscribers
+		WHERE token = ?`, token).
+		Scan(&sub.ID, &sub.Email, &sub.Token, &sub.CreatedAt, &sub.UnsubscribedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("購読者の取得に失敗しました: %w", err)
+	}
+	return &sub, nil
+}
+
+// Unsubscribe 配信停止リンクのトークンに対応する購読者を配信停止にします（該当しない場合はnil）
+func (s *Store) Unsubscribe(token string) (*models.Subscriber, error) {
+	sub, err := s.GetSubscriberByToken(token)
+	if sub == nil || err != nil {
+		return nil, err
+	}
+
+	if sub.UnsubscribedAt == "" {
+		sub.UnsubscribedAt = time.Now().UTC().Format(time.RFC3339)
+		if _, err := s.db.Exec(`UPDATE subscribers SET unsubscribed_at = ? WHERE id = ?`, sub.UnsubscribedAt, sub.ID); err != nil {
+			return nil, fmt.Errorf("配信停止の保存に失敗しました: %w", err)
+		}
+	}
+	return sub, nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import "testing"
+
+func TestStoreUnsubscribe(t *testing.T) {
+	st := openTestStore(t)
+	sub, err := st.AddSubscriber("alice@example.com")
+	if err != nil {
+		t.Fatalf("AddSubscriber: %v", err)
+	}
+
+	// トークンでの取得（確認ページ）では配信停止にならない
+	got, err := st.GetSubscriberByToken(sub.Token)
+	if err != nil {
+		t.Fatalf("GetSubscriberByToken: %v", err)
+	}
+	if got == nil || got.ID != sub.ID || got.UnsubscribedAt != "" {
+		t.Fatalf("GetSubscriberByToken = %+v, want active subscriber %d", got, sub.ID)
+	}
+	if active, _ := st.ListSubscribers(true); len(active) != 1 {
+		t.Fatalf("active subscribers = %d, want 1", len(active))
+	}
+
+	unsubscribed, err := st.Unsubscribe(sub.Token)
+	if err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if unsubscribed == nil || unsubscribed.UnsubscribedAt == "" {
+		t.Fatalf("Unsubscribe = %+v, want unsubscribed", unsubscribed)
+	}
+	if active, _ := st.ListSubscribers(true); len(active) != 0 {
+		t.Fatalf("active subscribers after Unsubscribe = %d, want 0", len(active))
+	}
+	// 2回目も同じ日時のまま成功する
+	again, err := st.Unsubscribe(sub.Token)
+	if err != nil || again == nil || again.UnsubscribedAt != unsubscribed.UnsubscribedAt {
+		t.Fatalf("2回目の Unsubscribe = %+v, %v", again, err)
+	}
+
+	// 再登録で配信が再開される
+	if _, err := st.AddSubscriber("alice@example.com"); err != nil {
+		t.Fatalf("AddSubscriber: %v", err)
+	}
+	if active, _ := st.ListSubscribers(true); len(active) != 1 {
+		t.Fatalf("active subscribers after AddSubscriber = %d, want 1", len(active))
+	}
+
+	for _, token := range []string{"", "unknown"} {
+		if got, err := st.GetSubscriberByToken(token); got != nil || err != nil {
+			t.Errorf("GetSubscriberByToken(%q) = %+v, %v, want nil", token, got, err)
+		}
+		if got, err := st.Unsubscribe(token); got != nil || err != nil {
+			t.Errorf("Unsubscribe(%q) = %+v, %v, want nil", token, got, err)
+		}
+	}
+}
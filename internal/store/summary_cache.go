@@ -0,0 +1,79 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// GetSummaryCache 有効期限内のキャッシュを返します（存在しない場合はnil）
+func (s *Store) GetSummaryCache(key string) (*models.SummaryCacheEntry, error) {
+	var e models.SummaryCacheEntry
+	err := s.db.QueryRow(`
+		SELECT cache_key, endpoint, target, prompt_version, model, content_hash, summary, cached_at, expires_at
+		FROM summary_cache
+		WHERE cache_key = ? AND expires_at > ?`, key, time.Now().UTC().Format(time.RFC3339)).
+		Scan(&e.Key, &e.Endpoint, &e.Target, &e.PromptVersion, &e.Model, &e.ContentHash, &e.Summary, &e.CachedAt, &e.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("要約キャッシュの取得に失敗しました: %w", err)
+	}
+	return &e, nil
+}
+
+// SaveSummaryCache キャッシュを保存し、期限切れのキャッシュを削除します
+func (s *Store) SaveSummaryCache(e models.SummaryCacheEntry) error {
+	_, err := s.db.Exec(`
+		INSERT INTO summary_cache (cache_key, endpoint, target, prompt_version, model, content_hash, summary, cached_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (cache_key) DO UPDATE SET
+			summary = excluded.summary,
+			cached_at = excluded.cached_at,
+			expires_at = excluded.expires_at`,
+		e.Key, e.Endpoint, e.Target, e.PromptVersion, e.Model, e.ContentHash, e.Summary, e.CachedAt, e.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("要約キャッシュの保存に失敗しました: %w", err)
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM summary_cache WHERE expires_at <= ?`, e.CachedAt); err != nil {
+		return fmt.Errorf("期限切れの要約キャッシュの削除に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// LatestSummaries エンドポイントの要約済み対象（正規化済みURL）ごとに最新の要約を返します
+func (s *Store) LatestSummaries(endpoint string, targets []string) (map[string]string, error) {
+	summaries := map[string]string{}
+	if len(targets) == 0 {
+		return summaries, nil
+	}
+
+	args := []any{endpoint}
+	for _, t := range targets {
+		args = append(args, t)
+	}
+	rows, err := s.db.Query(`
+		SELECT target, summary
+		FROM summary_cache
+		WHERE endpoint = ? AND target IN (?`+strings.Repeat(`, ?`, len(targets)-1)+`)
+		ORDER BY cached_at`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("要約の取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var target, summary string
+		if err := rows.Scan(&target, &summary); err != nil {
+			return nil, fmt.Errorf("要約の読み込みに失敗しました: %w", err)
+		}
+		summaries[target] = summary // cached_at順なので最後が最新
+	}
+	return summaries, rows.Err()
+}
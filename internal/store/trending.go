@@ -0,0 +1,101 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// SaveTrendingSnapshot 日付・スコープ単位でトレンドのスナップショットを保存します（同じ日付は置き換え）
+func (s *Store) SaveTrendingSnapshot(date, scope string, entries []models.TrendingSnapshotEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM trending_snapshots WHERE date = ? AND scope = ?`, date, scope); err != nil {
+		return fmt.Errorf("スナップショットの削除に失敗しました: %w", err)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, e := range entries {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO trending_snapshots (date, scope, rank, full_name, description, language, stars, captured_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			date, scope, e.Rank, e.FullName, e.Description, e.Language, e.Stars, now)
+		if err != nil {
+			return fmt.Errorf("スナップショットの保存に失敗しました: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("スナップショットのコミットに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// HasTrendingSnapshot 指定日のスナップショットが保存済みか確認します
+func (s *Store) HasTrendingSnapshot(date, scope string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM trending_snapshots WHERE date = ? AND scope = ?)`, date, scope).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("スナップショットの存在確認に失敗しました: %w", err)
+	}
+	return exists, nil
+}
+
+// TrendingSnapshot 指定日のスナップショットを順位順に返します
+func (s *Store) TrendingSnapshot(date, scope string) ([]models.TrendingSnapshotEntry, error) {
+	return s.queryTrending(`
+		SELECT date, scope, rank, full_name, description, language, stars
+		FROM trending_snapshots
+		WHERE date = ? AND scope = ?
+		ORDER BY rank`, date, scope)
+}
+
+// TrendingHistory リポジトリの順位履歴を日付順に返します
+func (s *Store) TrendingHistory(fullName, scope, since string) ([]models.TrendingSnapshotEntry, error) {
+	return s.queryTrending(`
+		SELECT date, scope, rank, full_name, description, language, stars
+		FROM trending_snapshots
+		WHERE full_name = ? AND scope = ? AND date >= ?
+		ORDER BY date`, fullName, scope, since)
+}
+
+// LatestTrendingSnapshotDate 指定日以前で最新のスナップショットの日付を返します（存在しない場合は空文字）
+func (s *Store) LatestTrendingSnapshotDate(scope, onOrBefore string) (string, error) {
+	var date string
+	err := s.db.QueryRow(`
+		SELECT date FROM trending_snapshots
+		WHERE scope = ? AND date <= ?
+		ORDER BY date DESC LIMIT 1`, scope, onOrBefore).Scan(&date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("スナップショット日付の取得に失敗しました: %w", err)
+	}
+	return date, nil
+}
+
+func (s *Store) queryTrending(query string, args ...any) ([]models.TrendingSnapshotEntry, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("スナップショットの取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.TrendingSnapshotEntry{}
+	for rows.Next() {
+		var e models.TrendingSnapshotEntry
+		if err := rows.Scan(&e.Date, &e.Scope, &e.Rank, &e.FullName, &e.Description, &e.Language, &e.Stars); err != nil {
+			return nil, fmt.Errorf("スナップショットの読み込みに失敗しました: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
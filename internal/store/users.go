@@ -0,0 +1,124 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+// ErrUserExists 同じユーザー名のユーザーが既に存在する場合のエラー
+var ErrUserExists = errors.New("同じユーザー名のユーザーが既に存在します")
+
+const userColumns = `id, username, password_hash, role, disabled, created_at, updated_at, last_login_at`
+
+// CreateUser ユーザーを作成します
+func (s *Store) CreateUser(username, passwordHash, role string) (models.User, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	res, err := s.db.Exec(`
+		INSERT INTO users (username, password_hash, role, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`, username, passwordHash, role, now, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return models.User{}, ErrUserExists
+		}
+		return models.User{}, fmt.Errorf("ユーザーの作成に失敗しました: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.User{}, fmt.Errorf("ユーザーIDの取得に失敗しました: %w", err)
+	}
+	user, err := s.GetUser(id)
+	if err != nil {
+		return models.User{}, err
+	}
+	return *user, nil
+}
+
+// GetUser IDでユーザーを返します（存在しない場合はnil）
+func (s *Store) GetUser(id int64) (*models.User, error) {
+	return s.queryUser(`SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+}
+
+// GetUserByUsername ユーザー名（大文字小文字を区別しない）でユーザーを返します（存在しない場合はnil）
+func (s *Store) GetUserByUsername(username string) (*models.User, error) {
+	return s.queryUser(`SELECT `+userColumns+` FROM users WHERE username = ?`, username)
+}
+
+// ListUsers ユーザーを作成順に返します
+func (s *Store) ListUsers() ([]models.User, error) {
+	rows, err := s.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザーの取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// UpdateUser ユーザーのロール・無効化フラグ・パスワードハッシュを更新します
+func (s *Store) UpdateUser(user models.User) error {
+	_, err := s.db.Exec(`
+		UPDATE users SET password_hash = ?, role = ?, disabled = ?, updated_at = ?
+		WHERE id = ?`,
+		user.PasswordHash, user.Role, user.Disabled, time.Now().UTC().Format(time.RFC3339), user.ID)
+	if err != nil {
+		return fmt.Errorf("ユーザーの更新に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// TouchUserLogin 最終ログイン日時を記録します
+func (s *Store) TouchUserLogin(id int64) error {
+	_, err := s.db.Exec(`UPDATE users SET last_login_at = ? WHERE id = ?`, time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("最終ログイン日時の更新に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// CountActiveAdmins 有効な管理者の人数を返します
+func (s *Store) CountActiveAdmins() (int, error) {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE role = ? AND NOT disabled`, models.RoleAdmin).Scan(&n); err != nil {
+		return 0, fmt.Errorf("管理者数の取得に失敗しました: %w", err)
+	}
+	return n, nil
+}
+
+func (s *Store) queryUser(query string, args ...any) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return user, err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.Disabled,
+		&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ユーザーの読み込みに失敗しました: %w", err)
+	}
+	return &user, nil
+}
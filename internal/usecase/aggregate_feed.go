@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+)
+
+// ArticleSummaryEndpoint 記事要約のエンドポイント名（要約キャッシュのendpoint）
+const ArticleSummaryEndpoint = "ai-article-summary"
+
+// ArticlePromptVersion 記事要約のプロンプトを変更した場合に更新します（要約キャッシュのキーに使用）
+const ArticlePromptVersion = "v2"
+
+// BuildAggregatedFeed 全ソース（または指定ソース）の記事を新しい順に集約し、同じURLの記事を1件にまとめます
+// Summariesが指定された場合は記事要約のキャッシュから要約を付与します
+func BuildAggregatedFeed(st *store.Store, q models.AggregatedFeedQuery) ([]models.FeedItem, error) {
+	// 重複を除いた後にLimit件残るよう多めに取得する
+	articles, err := st.ListRecentArticles(q.Sources, q.Limit*2)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []models.FeedItem{}
+	seen := map[string]bool{}
+	var targets []string
+	for _, item := range articles {
+		key := NormalizeURL(item.Link)
+		if key == "" {
+			key = item.SourceID + ":" + item.GUID
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		items = append(items, item)
+		targets = append(targets, key)
+		if len(items) >= q.Limit {
+			break
+		}
+	}
+
+	if q.Summaries {
+		summaries, err := st.LatestSummaries(ArticleSummaryEndpoint, targets)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			items[i].Summary = summaries[targets[i]]
+		}
+	}
+	return items, nil
+}
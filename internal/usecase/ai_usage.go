@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 利用量の上限の対象と期間
+const (
+	AIBudgetScopeUser     = "user"
+	AIBudgetScopeGlobal   = "global"
+	AIBudgetPeriodDaily   = "daily"
+	AIBudgetPeriodMonthly = "monthly"
+)
+
+// aiUsageSystemUser ユーザーによらない呼び出しを記録するときのユーザー名
+const aiUsageSystemUser = "system"
+
+// LLMCaller LLMを呼び出したユーザー（利用量の記録と上限の判定に使います）
+type LLMCaller struct {
+	UserID   int64
+	Username string
+}
+
+type llmCallerKey struct{}
+
+// WithLLMCaller LLMを呼び出すユーザーをコンテキストに設定します
+// 設定しない場合はユーザーによらない処理（日次ダイジェストなど）として記録します
+func WithLLMCaller(ctx context.Context, caller LLMCaller) context.Context {
+	return context.WithValue(ctx, llmCallerKey{}, caller)
+}
+
+// llmCallerFrom コンテキストに設定されたユーザーを返します
+func llmCallerFrom(ctx context.Context) LLMCaller {
+	if caller, ok := ctx.Value(llmCallerKey{}).(LLMCaller); ok {
+		return caller
+	}
+	return LLMCaller{Username: aiUsageSystemUser}
+}
+
+// AIBudgetError 利用量の上限に達している場合のエラー
+type AIBudgetError struct {
+	Status models.AIBudgetStatus
+	Reset  time.Time
+}
+
+func (e *AIBudgetError) Error() string {
+	scope := "全体"
+	if e.Status.Scope == AIBudgetScopeUser {
+		scope = "ユーザー"
+	}
+	period := "1日"
+	if e.Status.Period == AIBudgetPeriodMonthly {
+		period = "1か月"
+	}
+	return fmt.Sprintf("AI要約の%sの%sあたりの利用上限（$%.2f）に達しました。%sにリセットされます",
+		scope, period, e.Status.LimitUSD, e.Reset.Format("2006-01-02 15:04"))
+}
+
+// aiPrice 100万トークンあたりの料金
+type aiPrice struct {
+	input, output float64
+}
+
+// AIUsageTracker LLMの利用量と推定コストを記録し、設定した上限を超えていないか判定します
+// 同時に生成しても上限を超えないよう、生成前に見積もりコストを仮押さえ（Reserve）してから生成します
+type AIUsageTracker struct {
+	store  *store.Store
+	cfg    config.AIUsageConfig
+	prices map[string]aiPrice
+
+	mu            sync.Mutex
+	reserved      map[int64]float64 // ユーザーID → 生成中の呼び出しの見積もりコスト
+	reservedTotal float64
+}
+
+// NewAIUsageTracker 設定の上限とプロバイダーごとの料金から利用量の記録を作成します
+func NewAIUsageTracker(st *store.Store, cfg config.AIUsageConfig, llm config.LLMConfig) *AIUsageTracker {
+	prices := make(map[string]aiPrice, len(llm.Providers))
+	for name, p := range llm.Providers {
+		prices[name] = aiPrice{input: p.InputPricePerMTok, output: p.OutputPricePerMTok}
+	}
+	if cfg.ReservedOutputTokens <= 0 {
+		cfg.ReservedOutputTokens = 4096
+	}
+	return &AIUsageTracker{store: st, cfg: cfg, prices: prices, reserved: map[int64]float64{}}
+}
+
+// AIUsageReservation 生成前に仮押さえした見積もりコスト
+// 生成後（利用量の記録後）に Release で解放します
+type AIUsageReservation struct {
+	tracker *AIUsageTracker
+	userID  int64
+	costUSD float64
+	once    sync.Once
+}
+
+// Release 仮押さえを解放します（nilや2回目の呼び出しでは何もしません）
+func (r *AIUsageReservation) Release() {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() {
+		t := r.tracker
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.reservedTotal -= r.costUSD
+		if t.reserved[r.userID] -= r.costUSD; t.reserved[r.userID] <= 0 {
+			delete(t.reserved, r.userID)
+		}
+		if t.reservedTotal < 0 {
+			t.reservedTotal = 0
+		}
+	})
+}
+
+// Record LLMの呼び出し1回分の利用量を記録します（失敗はログのみで、呼び出し元の処理は続行します）
+func (t *AIUsageTracker) Record(ctx context.Context, endpoint, provider string, resp *LLMResponse) {
+	caller := llmCallerFrom(ctx)
+	price := t.prices[provider]
+	rec := models.AIUsageRecord{
+		UserID:       caller.UserID,
+		Username:     caller.Username,
+		Endpoint:     endpoint,
+		Provider:     provider,
+		Model:        resp.Model,
+		PromptTokens: resp.PromptTokens,
+		OutputTokens: resp.OutputTokens,
+		CostUSD: (float64(resp.PromptTokens)*price.input +
+			float64(resp.OutputTokens)*price.output) / 1_000_000,
+	}
+	if err := t.store.AddAIUsage(rec); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "AIUsageTracker.Record",
+			"endpoint": endpoint,
+			"username": caller.Username,
+			"error":    err.Error(),
+		}).Error("AI利用量の記録に失敗しました")
+	}
+}
+
+// Reserve プロンプトとprovider（プロバイダー名）の料金から1回分のコストを見積もり、
+// ユーザーと全体の利用量・仮押さえの合計に加えても上限を超えない場合に仮押さえします
+// 上限を超える場合は *AIBudgetError を返します。判定と仮押さえは同時に実行されるため、並行した呼び出しでも上限を超えません
+func (t *AIUsageTracker) Reserve(userID int64, provider, prompt string) (*AIUsageReservation, error) {
+	price := t.prices[provider]
+	cost := (float64(estimateTokens(prompt))*price.input +
+		float64(t.cfg.ReservedOutputTokens)*price.output) / 1_000_000
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses, err := t.status(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statuses {
+		if s.SpentUSD+s.ReservedUSD+cost > s.LimitUSD || s.SpentUSD >= s.LimitUSD {
+			reset, _ := time.Parse(time.RFC3339, s.ResetAt)
+			return nil, &AIBudgetError{Status: s, Reset: reset.In(time.Local)}
+		}
+	}
+
+	t.reserved[userID] += cost
+	t.reservedTotal += cost
+	return &AIUsageReservation{tracker: t, userID: userID, costUSD: cost}, nil
+}
+
+// Status 設定されている上限ごとの現在の利用状況を返します（上限が0のものは含みません）
+// userIDが0の場合は全体の上限のみ返します
+func (t *AIUsageTracker) Status(userID int64) ([]models.AIBudgetStatus, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status(userID)
+}
+
+// status Status の本体（t.muをロックした状態で呼び出します）
+func (t *AIUsageTracker) status(userID int64) ([]models.AIBudgetStatus, error) {
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	periods := map[string][2]time.Time{
+		AIBudgetPeriodDaily:   {dayStart, dayStart.AddDate(0, 0, 1)},
+		AIBudgetPeriodMonthly: {monthStart, monthStart.AddDate(0, 1, 0)},
+	}
+
+	budgets := []struct {
+		scope, period string
+		limit         float64
+	}{
+		{AIBudgetScopeUser, AIBudgetPeriodDaily, t.cfg.UserDailyBudgetUSD},
+		{AIBudgetScopeUser, AIBudgetPeriodMonthly, t.cfg.UserMonthlyBudgetUSD},
+		{AIBudgetScopeGlobal, AIBudgetPeriodDaily, t.cfg.GlobalDailyBudgetUSD},
+		{AIBudgetScopeGlobal, AIBudgetPeriodMonthly, t.cfg.GlobalMonthlyBudgetUSD},
+	}
+	statuses := []models.AIBudgetStatus{}
+	for _, b := range budgets {
+		if b.limit <= 0 || (b.scope == AIBudgetScopeUser && userID == 0) {
+			continue
+		}
+		span := periods[b.period]
+		q := models.AIUsageQuery{
+			Since: span[0].UTC().Format(time.RFC3339),
+			Until: span[1].UTC().Format(time.RFC3339),
+		}
+		reserved := t.reservedTotal
+		if b.scope == AIBudgetScopeUser {
+			q.UserID = userID
+			reserved = t.reserved[userID]
+		}
+		totals, err := t.store.SumAIUsage(q)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, models.AIBudgetStatus{
+			Scope:       b.scope,
+			Period:      b.period,
+			LimitUSD:    b.limit,
+			SpentUSD:    totals.CostUSD,
+			ReservedUSD: reserved,
+			ResetAt:     span[1].UTC().Format(time.RFC3339),
+		})
+	}
+	return statuses, nil
+}
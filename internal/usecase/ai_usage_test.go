@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+)
+
+// openTestStore テスト用の一時ディレクトリにストアを作成します
+func openTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	st, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.Open: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+// newTestAIUsageTracker 出力100万トークンあたり$100（仮押さえ1回$0.4）の stub プロバイダーで利用量の記録を作成します
+func newTestAIUsageTracker(t *testing.T, cfg config.AIUsageConfig) (*AIUsageTracker, *store.Store) {
+	t.Helper()
+	st := openTestStore(t)
+	cfg.ReservedOutputTokens = 4000
+	tracker := NewAIUsageTracker(st, cfg, config.LLMConfig{
+		Providers: map[string]config.LLMProviderConfig{"stub": {OutputPricePerMTok: 100}},
+	})
+	return tracker, st
+}
+
+func TestAIUsageTrackerReserve(t *testing.T) {
+	tracker, st := newTestAIUsageTracker(t, config.AIUsageConfig{UserDailyBudgetUSD: 1})
+
+	first, err := tracker.Reserve(1, "stub", "")
+	if err != nil {
+		t.Fatalf("1回目の Reserve: %v", err)
+	}
+	if _, err := tracker.Reserve(1, "stub", ""); err != nil {
+		t.Fatalf("2回目の Reserve: %v", err)
+	}
+	// 仮押さえの合計が上限を超えるため拒否される
+	var budgetErr *AIBudgetError
+	if _, err := tracker.Reserve(1, "stub", ""); !errors.As(err, &budgetErr) {
+		t.Fatalf("3回目の Reserve: err = %v, want *AIBudgetError", err)
+	}
+	if budgetErr.Status.Scope != AIBudgetScopeUser || budgetErr.Status.ReservedUSD < 0.79 {
+		t.Errorf("Status = %+v, want user scope with $0.8 reserved", budgetErr.Status)
+	}
+	// 別のユーザーは影響を受けない
+	if _, err := tracker.Reserve(2, "stub", ""); err != nil {
+		t.Fatalf("別のユーザーの Reserve: %v", err)
+	}
+
+	// 解放すると再び仮押さえできる（2回目の解放は何もしない）
+	first.Release()
+	first.Release()
+	third, err := tracker.Reserve(1, "stub", "")
+	if err != nil {
+		t.Fatalf("解放後の Reserve: %v", err)
+	}
+	third.Release()
+
+	// 記録済みの利用量も合計に含める
+	if err := st.AddAIUsage(models.AIUsageRecord{UserID: 1, Username: "alice", Endpoint: "test", Provider: "stub", CostUSD: 0.3}); err != nil {
+		t.Fatalf("AddAIUsage: %v", err)
+	}
+	if _, err := tracker.Reserve(1, "stub", ""); !errors.As(err, &budgetErr) {
+		t.Fatalf("記録後の Reserve: err = %v, want *AIBudgetError", err)
+	}
+}
+
+func TestAIUsageTrackerReserveConcurrent(t *testing.T) {
+	tracker, _ := newTestAIUsageTracker(t, config.AIUsageConfig{GlobalDailyBudgetUSD: 1})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := range 20 {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			if _, err := tracker.Reserve(userID, "stub", ""); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}(int64(i + 1))
+	}
+	wg.Wait()
+
+	// $0.4ずつ仮押さえするため、全体の上限$1では2件まで
+	if accepted != 2 {
+		t.Errorf("accepted = %d, want 2", accepted)
+	}
+}
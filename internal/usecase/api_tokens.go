@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// apiTokenPrefix APIトークンの接頭辞（ログや設定ファイルに紛れ込んだ場合に見分けやすくする）
+const apiTokenPrefix = "ts_"
+
+// ErrInvalidAPIToken APIトークンが無効・失効済み・期限切れの場合のエラー
+var ErrInvalidAPIToken = errors.New("APIトークンが無効です")
+
+// APITokenScopes 付与できるスコープ
+var APITokenScopes = []string{
+	models.APITokenScopeReadFeeds,
+	models.APITokenScopeRunAISummary,
+	models.APITokenScopeAdmin,
+}
+
+// apiTokenScopeRoles スコープを付与するのに必要なロール
+var apiTokenScopeRoles = map[string]string{
+	models.APITokenScopeReadFeeds:    models.RoleViewer,
+	models.APITokenScopeRunAISummary: models.RoleSummarizer,
+	models.APITokenScopeAdmin:        models.RoleAdmin,
+}
+
+// CreateAPIToken APIトークンを発行し、保存したトークンと平文のトークン（この時だけ返す）を返します
+// expiresInが0の場合は無期限です。ユーザーのロールで行えない操作のスコープは付与できません
+func CreateAPIToken(st *store.Store, user models.User, name string, scopes []string, expiresIn time.Duration) (models.APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return models.APIToken{}, "", fmt.Errorf("nameは100文字以内で指定してください")
+	}
+	scopes = uniqueStrings(scopes, true)
+	if len(scopes) == 0 {
+		return models.APIToken{}, "", fmt.Errorf("scopesは %s から1つ以上指定してください", strings.Join(APITokenScopes, ", "))
+	}
+	for _, scope := range scopes {
+		if !containsOrEmpty(APITokenScopes, scope) {
+			return models.APIToken{}, "", fmt.Errorf("scopesは %s のいずれかを指定してください: %s", strings.Join(APITokenScopes, ", "), scope)
+		}
+		if required := apiTokenScopeRoles[scope]; !models.RoleAtLeast(user.Role, required) {
+			return models.APIToken{}, "", fmt.Errorf("%sスコープの付与には%sロールが必要です", scope, required)
+		}
+	}
+	if expiresIn < 0 {
+		return models.APIToken{}, "", fmt.Errorf("有効期限が不正です")
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return models.APIToken{}, "", err
+	}
+	raw := apiTokenPrefix + secret
+
+	now := time.Now().UTC()
+	t := models.APIToken{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    raw[:len(apiTokenPrefix)+8],
+		TokenHash: hashToken(raw),
+		Scopes:    scopes,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	if expiresIn > 0 {
+		t.ExpiresAt = now.Add(expiresIn).Format(time.RFC3339)
+	}
+	t, err = st.CreateAPIToken(t)
+	if err != nil {
+		return models.APIToken{}, "", err
+	}
+	return t, raw, nil
+}
+
+// AuthenticateAPIToken APIトークンを検証し、トークンと所有ユーザーを返します
+func AuthenticateAPIToken(st *store.Store, raw string) (*models.APIToken, *models.User, error) {
+	if !strings.HasPrefix(raw, apiTokenPrefix) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	t, err := st.GetAPITokenByHash(hashToken(raw))
+	if err != nil {
+		return nil, nil, err
+	}
+	if t == nil || t.RevokedAt != "" || (t.ExpiresAt != "" && t.ExpiresAt <= time.Now().UTC().Format(time.RFC3339)) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	user, err := st.GetUser(t.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	if user.Disabled {
+		return nil, nil, ErrUserDisabled
+	}
+
+	if err := st.TouchAPIToken(t.ID); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "AuthenticateAPIToken",
+			"tokenID":  t.ID,
+			"error":    err.Error(),
+		}).Warn("APIトークンの最終使用日時の更新に失敗しました")
+	}
+	return t, user, nil
+}
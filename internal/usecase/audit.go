@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AuditLog 認証に関するイベントを監査ログとしてストアに保存します
+type AuditLog struct {
+	store     *store.Store
+	retention time.Duration
+}
+
+// NewAuditLog 監査ログを作成します（retentionが0の場合は古いログを削除しない）
+func NewAuditLog(st *store.Store, retention time.Duration) *AuditLog {
+	return &AuditLog{store: st, retention: retention}
+}
+
+// Record 監査ログを1件保存します（失敗はログのみで、呼び出し元の処理は続行します）
+// ログイン成功時に保存期間を過ぎたログを削除します
+func (a *AuditLog) Record(e models.AuditEvent) {
+	e.UserAgent = truncateRunes(e.UserAgent, 256)
+	e.Detail = truncateRunes(e.Detail, 500)
+	if err := a.store.AddAuditEvent(e); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "AuditLog.Record",
+			"event":    e.Event,
+			"username": e.Username,
+			"error":    err.Error(),
+		}).Error("監査ログの保存に失敗しました")
+		return
+	}
+
+	if e.Event == models.AuditEventLogin && a.retention > 0 {
+		if _, err := a.store.PurgeAuditEvents(time.Now().Add(-a.retention)); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "AuditLog.Record",
+				"error":    err.Error(),
+			}).Warn("古い監査ログの削除に失敗しました")
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sirupsen/logrus"
+)
+
+// DigestPromptVersion ダイジェストのプロンプトを変更した場合に更新します（要約キャッシュのキーに使用）
+const DigestPromptVersion = "v1"
+
+// DigestLLMEndpoint ダイジェストの生成に使うLLMの割り当て名（設定ファイルの llm.endpoints のキー）
+const DigestLLMEndpoint = "ai-trends-summary"
+
+// ダイジェストに含める記事の範囲
+const (
+	digestInfoQDays         = 7    // InfoQ: 指定日を含む直近7日間
+	digestInfoQLimit        = 30   // InfoQ: 最大件数
+	digestGolangWeeklyDays  = 14   // Golang Weekly: 指定日を含む直近14日間の最新号
+	digestDescriptionLength = 200  // 記事説明の最大文字数
+	digestIssueLength       = 8000 // Golang Weekly本文の最大文字数
+)
+
+// DefaultDigestSources ソース未指定時にダイジェストへ含めるデータソース
+var DefaultDigestSources = []string{
+	models.DigestSourceInfoQ,
+	models.DigestSourceGitHubTrending,
+	models.DigestSourceGolangWeekly,
+}
+
+// ErrDigestNoData 指定日のダイジェストの元データが1件もない場合のエラー
+var ErrDigestNoData = errors.New("指定日のダイジェストの元データがありません")
+
+// ParseDigestQuery 日付（空は今日）とカンマ区切りのソース（空は全ソース）を検証します
+func ParseDigestQuery(date, sources string) (models.DigestQuery, error) {
+	q := models.DigestQuery{Date: date}
+	if q.Date == "" {
+		q.Date = Today()
+	}
+	day, err := time.ParseInLocation(snapshotDateLayout, q.Date, time.Local)
+	if err != nil {
+		return q, fmt.Errorf("日付はYYYY-MM-DD形式で指定してください")
+	}
+	if day.After(time.Now()) {
+		return q, fmt.Errorf("未来の日付は指定できません")
+	}
+
+	if sources == "" {
+		q.Sources = append([]string{}, DefaultDigestSources...)
+		return q, nil
+	}
+	seen := map[string]bool{}
+	for _, source := range strings.Split(sources, ",") {
+		source = strings.TrimSpace(source)
+		switch source {
+		case models.DigestSourceInfoQ, models.DigestSourceGitHubTrending, models.DigestSourceGolangWeekly:
+		default:
+			return q, fmt.Errorf("sourcesは %s のいずれかをカンマ区切りで指定してください", strings.Join(DefaultDigestSources, ", "))
+		}
+		if !seen[source] {
+			seen[source] = true
+			q.Sources = append(q.Sources, source)
+		}
+	}
+	return q, nil
+}
+
+// BuildDigestInput 指定日のダイジェストの元データをストア（今日の分で未取得のものはその場で取得）から集めます
+func BuildDigestInput(ctx context.Context, fetcher *Fetcher, st *store.Store, registry *FeedRegistry, q models.DigestQuery) (models.DigestInput, error) {
+	input := models.DigestInput{Date: q.Date, Sources: q.Sources}
+	day, err := time.ParseInLocation(snapshotDateLayout, q.Date, time.Local)
+	if err != nil {
+		return input, fmt.Errorf("日付の解析に失敗しました: %w", err)
+	}
+
+	for _, source := range q.Sources {
+		var err error
+		switch source {
+		case models.DigestSourceInfoQ:
+			input.InfoQ, err = digestArticles(ctx, fetcher, st, registry, source, day, digestInfoQDays, digestInfoQLimit)
+		case models.DigestSourceGolangWeekly:
+			input.GolangWeekly, err = digestArticles(ctx, fetcher, st, registry, source, day, digestGolangWeeklyDays, 1)
+		case models.DigestSourceGitHubTrending:
+			input.GitHubTrending, err = digestTrending(ctx, fetcher, st, q.Date)
+		}
+		if err != nil {
+			// 1つのソースが失敗しても残りのソースでダイジェストを作成する
+			logrus.WithFields(logrus.Fields{
+				"function": "BuildDigestInput",
+				"date":     q.Date,
+				"source":   source,
+				"error":    err.Error(),
+			}).Warn("ダイジェストの元データの取得に失敗しました")
+		}
+	}
+
+	if len(input.InfoQ) == 0 && len(input.GitHubTrending) == 0 && len(input.GolangWeekly) == 0 {
+		return input, ErrDigestNoData
+	}
+	return input, nil
+}
+
+// digestArticles 指定日を含む直近days日間に公開されたソースの記事を返します
+func digestArticles(ctx context.Context, fetcher *Fetcher, st *store.Store, registry *FeedRegistry, sourceID string, day time.Time, days, limit int) ([]models.FeedItem, error) {
+	src, ok := registry.Get(sourceID)
+	if !ok {
+		return nil, fmt.Errorf("フィードソース %s が登録されていません", sourceID)
+	}
+
+	from := day.AddDate(0, 0, 1-days).UTC().Format(time.RFC3339)
+	to := day.AddDate(0, 0, 1).UTC().Format(time.RFC3339)
+	items, err := st.ListArticlesBetween(src.ID, from, to, limit)
+	if err != nil || len(items) > 0 || day.Format(snapshotDateLayout) != Today() {
+		return items, err
+	}
+
+	// 今日の分がまだストアにない場合はその場で取得する
+	if _, err := RefreshFeed(ctx, fetcher, st, src); err != nil {
+		return nil, err
+	}
+	return st.ListArticlesBetween(src.ID, from, to, limit)
+}
+
+// digestTrending 指定日の全言語のGitHub Trendingスナップショットを返します
+// 今日のスナップショットが未保存の場合はその場で取得した内容を返します（保存はしません）
+func digestTrending(ctx context.Context, fetcher *Fetcher, st *store.Store, date string) ([]models.TrendingSnapshotEntry, error) {
+	entries, err := st.TrendingSnapshot(date, "")
+	if err != nil || len(entries) > 0 || date != Today() {
+		return entries, err
+	}
+
+	// スナップショットの保存は TrendingSnapshotJob に任せ、ここでは取得した内容を使うだけにする
+	repos, err := ScrapeGitHubTrending(ctx, fetcher, models.TrendingQuery{Since: models.TrendingSinceDaily})
+	if err != nil {
+		return nil, err
+	}
+	return trendingSnapshotEntries(date, "", repos), nil
+}
+
+// GenerateDigest 指定日のダイジェストを作成して元データとともに保存します
+func GenerateDigest(ctx context.Context, fetcher *Fetcher, st *store.Store, registry *FeedRegistry, router *LLMRouter, q models.DigestQuery) (models.Digest, error) {
+	input, err := BuildDigestInput(ctx, fetcher, st, registry, q)
+	if err != nil {
+		return models.Digest{}, err
+	}
+
+	result, err := router.Generate(ctx, DigestLLMEndpoint, BuildDigestPrompt(input))
+	if err != nil {
+		return models.Digest{}, err
+	}
+
+	digest := models.Digest{
+		Date:          q.Date,
+		Sources:       q.Sources,
+		Summary:       result.Text,
+		Model:         result.Model,
+		PromptVersion: DigestPromptVersion,
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		Input:         &input,
+	}
+	return digest, st.SaveDigest(digest)
+}
+
+// DigestJob 毎日決まった時刻以降に今日のダイジェストを1回作成します
+type DigestJob struct {
+	store    *store.Store
+	registry *FeedRegistry
+	fetcher  *Fetcher
+	router   *LLMRouter
+	notifier *Notifier
+	at       time.Duration // 0時からの経過時間
+	sources  []string
+	interval time.Duration
+
+	// 作成に失敗した日付と次に再試行する時刻（失敗した日付をintervalごとにLLMへ送り直さないため）
+	failedDate string
+	failures   int
+	retryAt    time.Time
+}
+
+// ダイジェストの作成に失敗した場合に再試行するまでの待ち時間（失敗するたびに倍にし、最大値で頭打ち）
+const (
+	digestRetryBackoff    = 30 * time.Minute
+	digestRetryMaxBackoff = 6 * time.Hour
+)
+
+// NewDigestJob ダイジェストジョブを作成します
+// atは "HH:MM"（サーバーのローカルタイムゾーン）、sourcesは空の場合すべてのソースを対象にします
+// notifierがnilでない場合は作成したダイジェストを配信します
+func NewDigestJob(st *store.Store, registry *FeedRegistry, fetcher *Fetcher, router *LLMRouter, notifier *Notifier, at string, sources []string, interval time.Duration) (*DigestJob, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("ダイジェストの作成時刻はHH:MM形式で指定してください: %s", at)
+	}
+	if _, err := ParseDigestQuery("", strings.Join(sources, ",")); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	return &DigestJob{
+		store:    st,
+		registry: registry,
+		fetcher:  fetcher,
+		router:   router,
+		notifier: notifier,
+		at:       time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute,
+		sources:  sources,
+		interval: interval,
+	}, nil
+}
+
+// Start intervalごとに作成時刻を過ぎているか確認し、今日のダイジェストが未作成なら作成します
+func (j *DigestJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.RunOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+// RunOnce 作成時刻を過ぎていて今日のダイジェストが未作成の場合に作成します
+// 作成に失敗した場合は再試行の時刻を過ぎるか翌日になるまで作成しません
+func (j *DigestJob) RunOnce(ctx context.Context) {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Before(midnight.Add(j.at)) {
+		return
+	}
+
+	date := Today()
+	if date == j.failedDate && now.Before(j.retryAt) {
+		return
+	}
+	exists, err := j.store.HasDigest(date)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "DigestJob.RunOnce",
+			"date":     date,
+			"error":    err.Error(),
+		}).Warn("ダイジェストの作成済み確認に失敗しました")
+		return
+	}
+	if exists {
+		return
+	}
+
+	q, err := ParseDigestQuery(date, strings.Join(j.sources, ","))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "DigestJob.RunOnce",
+			"date":     date,
+			"sources":  j.sources,
+			"error":    err.Error(),
+		}).Warn("ダイジェストの作成条件が不正です")
+		return
+	}
+	digest, err := GenerateDigest(ctx, j.fetcher, j.store, j.registry, j.router, q)
+	if err != nil {
+		retryAt := j.scheduleRetry(date, now)
+		logrus.WithFields(logrus.Fields{
+			"function": "DigestJob.RunOnce",
+			"date":     date,
+			"failures": j.failures,
+			"retryAt":  retryAt.Format(time.RFC3339),
+			"error":    err.Error(),
+		}).Warn("ダイジェストの作成に失敗しました")
+		return
+	}
+	j.failedDate, j.failures = "", 0
+
+	logrus.WithFields(logrus.Fields{
+		"function":   "DigestJob.RunOnce",
+		"date":       date,
+		"model":      digest.Model,
+		"summaryLen": len(digest.Summary),
+	}).Info("ダイジェストを作成しました")
+
+	j.notifier.NotifyDigest(ctx, digest)
+}
+
+// scheduleRetry 作成に失敗した日付を記録し、次に再試行する時刻を返します
+func (j *DigestJob) scheduleRetry(date string, now time.Time) time.Time {
+	if j.failedDate != date {
+		j.failedDate, j.failures = date, 0
+	}
+	j.failures++
+
+	backoff := digestRetryBackoff
+	for i := 1; i < j.failures && backoff < digestRetryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > digestRetryMaxBackoff {
+		backoff = digestRetryMaxBackoff
+	}
+	j.retryAt = now.Add(backoff)
+	return j.retryAt
+}
+
+// BuildDigestPrompt ダイジェストの元データからトレンド分析のプロンプトを組み立てます
+func BuildDigestPrompt(input models.DigestInput) string {
+	var b strings.Builder
+	b.WriteString("下記は最新のIT業界のNews一覧です。後述の項目に沿って要約してMarkdown形式で回答してください。・全てのデータから読み取れる傾向と推測される理由")
+	if len(input.InfoQ) > 0 {
+		b.WriteString(" ・InfoQから読み取れる傾向と推測される理由")
+	}
+	if len(input.GitHubTrending) > 0 {
+		b.WriteString(" ・Github daily trendsから読み取れる傾向と推測される理由")
+	}
+	if len(input.GolangWeekly) > 0 {
+		b.WriteString(" ・golangWeeklyから読み取れる傾向と推測される理由")
+	}
+	b.WriteString("\n")
+
+	if len(input.InfoQ) > 0 {
+		b.WriteString("\n## InfoQ\n")
+		for _, item := range input.InfoQ {
+			fmt.Fprintf(&b, "- %s: %s\n", item.Title, truncateRunes(htmlToText(item.Description), digestDescriptionLength))
+		}
+	}
+	if len(input.GitHubTrending) > 0 {
+		b.WriteString("\n## Github daily trends\n")
+		for _, e := range input.GitHubTrending {
+			fmt.Fprintf(&b, "%d. %s [%s] ★%d: %s\n", e.Rank, e.FullName, e.Language, e.Stars, e.Description)
+		}
+	}
+	if len(input.GolangWeekly) > 0 {
+		issue := input.GolangWeekly[0]
+		fmt.Fprintf(&b, "\n## golangWeekly\n%s\n%s\n", issue.Title, truncateRunes(htmlToText(issue.Description), digestIssueLength))
+	}
+	return b.String()
+}
+
+// htmlToText HTMLからテキストを取り出し、連続する空白を1つにまとめます
+func htmlToText(s string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(s))
+	if err != nil {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+// truncateRunes 文字数がmaxを超える場合に切り詰めます
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+func TestDigestJobRetryBackoff(t *testing.T) {
+	st := openTestStore(t)
+	registry, err := NewFeedRegistry([]models.FeedSource{
+		{ID: models.DigestSourceInfoQ, URLs: []string{"https://example.com/infoq"}},
+	})
+	if err != nil {
+		t.Fatalf("NewFeedRegistry: %v", err)
+	}
+	if _, err := st.SaveArticles([]models.FeedItem{{
+		GUID:      "1",
+		Title:     "article",
+		SourceID:  models.DigestSourceInfoQ,
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}}); err != nil {
+		t.Fatalf("SaveArticles: %v", err)
+	}
+
+	provider := &stubProvider{name: "stub", streamErr: errors.New("upstream unavailable")}
+	router, err := NewLLMRouter(map[string]LLMProvider{"stub": provider}, nil, "stub")
+	if err != nil {
+		t.Fatalf("NewLLMRouter: %v", err)
+	}
+	job, err := NewDigestJob(st, registry, nil, router, nil, "00:00", []string{models.DigestSourceInfoQ}, time.Minute)
+	if err != nil {
+		t.Fatalf("NewDigestJob: %v", err)
+	}
+
+	ctx := context.Background()
+	job.RunOnce(ctx)
+	job.RunOnce(ctx)
+	// 失敗した日付は再試行の時刻までLLMを呼び出さない
+	if len(provider.prompts) != 1 {
+		t.Fatalf("LLMの呼び出し = %d回, want 1", len(provider.prompts))
+	}
+	if wait := time.Until(job.retryAt); wait <= digestRetryBackoff-time.Minute || wait > digestRetryBackoff {
+		t.Errorf("再試行までの待ち時間 = %v, want %v", wait, digestRetryBackoff)
+	}
+
+	// 再試行の時刻を過ぎたら作成し直し、待ち時間を倍にする
+	job.retryAt = time.Now().Add(-time.Second)
+	job.RunOnce(ctx)
+	if len(provider.prompts) != 2 {
+		t.Fatalf("再試行後のLLMの呼び出し = %d回, want 2", len(provider.prompts))
+	}
+	if wait := time.Until(job.retryAt); wait <= 2*digestRetryBackoff-time.Minute || wait > 2*digestRetryBackoff {
+		t.Errorf("2回目の失敗後の待ち時間 = %v, want %v", wait, 2*digestRetryBackoff)
+	}
+
+	// 作成に成功したら失敗の記録を消す
+	provider.streamErr = nil
+	provider.chunks = []string{"# digest"}
+	job.retryAt = time.Now().Add(-time.Second)
+	job.RunOnce(ctx)
+	if exists, err := st.HasDigest(Today()); err != nil || !exists {
+		t.Fatalf("HasDigest = %v, %v, want true", exists, err)
+	}
+	if job.failedDate != "" || job.failures != 0 {
+		t.Errorf("成功後の failedDate = %q, failures = %d, want reset", job.failedDate, job.failures)
+	}
+}
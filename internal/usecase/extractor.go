@@ -0,0 +1,613 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNoArticleContent ページから本文を抽出できなかった場合のエラー
+var ErrNoArticleContent = errors.New("記事の本文を抽出できませんでした")
+
+const (
+	// extractMinParagraph 本文の段落とみなす最小の文字数
+	extractMinParagraph = 25
+	// extractDefaultMaxText 要約に渡す本文の既定の最大文字数
+	extractDefaultMaxText = 20000
+)
+
+// 本文の推定に使うclass・id・roleの判定（Mozilla Readability の判定を元にしています）
+var (
+	unlikelyCandidatePattern = regexp.MustCompile(`(?i)-ad-|ad-break|advert|banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|legends|menu|modal|nav|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tags|toolbar|widget`)
+	maybeCandidatePattern    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow|post|entry|story|text|blog`)
+	positiveClassPattern     = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeClassPattern     = regexp.MustCompile(`(?i)-ad-|hidden|banner|combx|comment|com-|contact|footer|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|widget`)
+)
+
+// preformattedMark blocksText で前後の空白を除かない行（pre要素）の印
+const preformattedMark = "\x00"
+
+// extractJunkSelector 本文にならない要素
+const extractJunkSelector = "script, style, noscript, iframe, svg, canvas, form, button, input, select, textarea, template, object, embed, dialog, [hidden], [aria-hidden=true]"
+
+// extractBlockTags 段落として扱うブロック要素（これを子に持たないdivは段落とみなします）
+var extractBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "dl": true, "div": true,
+	"figure": true, "footer": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true,
+	"table": true, "ul": true,
+}
+
+// jsonLDArticleTypes JSON-LDで記事とみなす @type
+var jsonLDArticleTypes = map[string]bool{
+	"Article": true, "NewsArticle": true, "BlogPosting": true, "TechArticle": true,
+	"Report": true, "ScholarlyArticle": true, "SocialMediaPosting": true,
+}
+
+// publishedLayouts 公開日時として解釈する書式
+var publishedLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"2006年1月2日",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// ContentExtractor 任意のサイトの記事ページから本文・タイトル・著者・公開日時を抽出します
+type ContentExtractor struct {
+	sites         []config.SiteExtractionConfig
+	maxTextLength int
+}
+
+// NewContentExtractor 設定から本文の抽出器を作成します（不正なセレクタはエラー）
+func NewContentExtractor(cfg config.ExtractionConfig) (*ContentExtractor, error) {
+	e := &ContentExtractor{maxTextLength: cfg.MaxTextLength}
+	if e.maxTextLength <= 0 {
+		e.maxTextLength = extractDefaultMaxText
+	}
+	for _, site := range cfg.Sites {
+		site.Domain = strings.Trim(strings.ToLower(strings.TrimSpace(site.Domain)), ".")
+		if site.Domain == "" {
+			return nil, fmt.Errorf("extraction.sitesのdomainが未設定です")
+		}
+		selectors := append([]string{site.Content, site.Title, site.Author, site.Published}, site.Remove...)
+		for _, sel := range selectors {
+			if sel == "" {
+				continue
+			}
+			if _, err := cascadia.Compile(sel); err != nil {
+				return nil, fmt.Errorf("extraction.sites（%s）のセレクタが不正です: %s: %w", site.Domain, sel, err)
+			}
+		}
+		e.sites = append(e.sites, site)
+	}
+	return e, nil
+}
+
+// site URLのホストに一致するドメインの抽出方法を返します（最も長く一致するもの、ない場合はnil）
+func (e *ContentExtractor) site(pageURL string) *config.SiteExtractionConfig {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	var found *config.SiteExtractionConfig
+	for i, site := range e.sites {
+		if host != site.Domain && !strings.HasSuffix(host, "."+site.Domain) {
+			continue
+		}
+		if found == nil || len(site.Domain) > len(found.Domain) {
+			found = &e.sites[i]
+		}
+	}
+	return found
+}
+
+// Extract HTMLから記事の本文とメタデータを抽出します
+// contentTypeのcharsetまたはmetaタグに従ってUTF-8に変換し、本文がない場合は ErrNoArticleContent を返します
+func (e *ContentExtractor) Extract(pageURL, contentType string, body []byte) (*models.ExtractedArticle, error) {
+	r, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("文字コードの変換に失敗しました: %w", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("HTMLの解析に失敗しました: %w", err)
+	}
+
+	// JSON-LD・metaタグは不要な要素を取り除く前に読む
+	article := extractMetadata(doc)
+	article.URL = pageURL
+
+	site := e.site(pageURL)
+	if site != nil {
+		if site.Title != "" {
+			if v := normalizeSpace(doc.Find(site.Title).First().Text()); v != "" {
+				article.Title = v
+			}
+		}
+		if site.Author != "" {
+			if v := normalizeSpace(doc.Find(site.Author).First().Text()); v != "" {
+				article.Author = v
+			}
+		}
+		if site.Published != "" {
+			if v := parsePublished(publishedValue(doc.Find(site.Published).First())); v != "" {
+				article.PublishedAt = v
+			}
+		}
+	}
+
+	doc.Find(extractJunkSelector).Remove()
+	var content *goquery.Selection
+	if site != nil {
+		for _, sel := range site.Remove {
+			doc.Find(sel).Remove()
+		}
+		if site.Content != "" {
+			if s := doc.Find(site.Content); normalizeSpace(s.Text()) != "" {
+				content = s
+			}
+		}
+	}
+	if content == nil {
+		content = findMainContent(doc.Selection)
+	}
+
+	article.Text = truncateRunes(blocksText(content), e.maxTextLength)
+	if article.Text == "" {
+		return nil, ErrNoArticleContent
+	}
+	if article.Title == "" {
+		article.Title = normalizeSpace(doc.Find("title").First().Text())
+	}
+	return &article, nil
+}
+
+// BuildArticlePrompt 抽出した記事から記事要約のプロンプトを組み立てます
+func BuildArticlePrompt(a models.ExtractedArticle) string {
+	var b strings.Builder
+	b.WriteString("下記の記事の内容を簡潔に日本語で要約してください。その際に、結果から記載してください。\n")
+	for _, field := range []struct{ label, value string }{
+		{"タイトル", a.Title},
+		{"著者", a.Author},
+		{"公開日時", a.PublishedAt},
+		{"サイト", a.SiteName},
+		{"URL", a.URL},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.label, field.value)
+		}
+	}
+	b.WriteString("\n本文:\n")
+	b.WriteString(a.Text)
+	return b.String()
+}
+
+// findMainContent 段落の文字数・句読点・リンクの割合とclass・idから本文の要素を推定します
+// rootにはドキュメント全体（body要素を含む選択）を渡します
+func findMainContent(root *goquery.Selection) *goquery.Selection {
+	body := root.Find("body")
+	if body.Length() == 0 {
+		body = root
+	}
+
+	// 本文らしくない class・id の要素を取り除く
+	body.Find("nav, aside, footer, header").Remove()
+	body.Find("*").Each(func(_ int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "body", "article", "main":
+			return
+		}
+		match := s.AttrOr("class", "") + " " + s.AttrOr("id", "") + " " + s.AttrOr("role", "")
+		if unlikelyCandidatePattern.MatchString(match) && !maybeCandidatePattern.MatchString(match) {
+			s.Remove()
+		}
+	})
+
+	// 本文と推定した要素がbody自身の場合も選択できるよう、bodyではなくドキュメントから選択する
+	selectNodes := func(nodes ...*html.Node) *goquery.Selection {
+		return root.FindNodes(nodes...)
+	}
+
+	// 段落のスコアを親に、半分を祖父母に加算する（bodyより外側のhtml要素は候補にしない）
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode || n.Data == "html" || n.Data == "head" {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	body.Find("p, pre, td, blockquote, div").Each(func(_ int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "div" && s.ChildrenFiltered("*").FilterFunction(isBlockElement).Length() > 0 {
+			return
+		}
+		text := normalizeSpace(s.Text())
+		length := utf8.RuneCountInString(text)
+		if length < extractMinParagraph {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "、")+strings.Count(text, "。")) + math.Min(float64(length)/100, 3)
+		parent := s.Get(0).Parent
+		addScore(parent, score)
+		if parent != nil {
+			addScore(parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(selectNodes(n)))
+		scores[n] = score
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		for _, sel := range []string{"article", "main", "[role=main]"} {
+			if s := body.Find(sel).First(); s.Length() > 0 {
+				return s
+			}
+		}
+		return body
+	}
+
+	// 同じ親を持つ要素のうち、スコアが高いものや長い段落は本文の続きとして含める
+	threshold := math.Max(10, bestScore*0.2)
+	var nodes []*html.Node
+	for n := best.Parent.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		include := n == best
+		if score, ok := scores[n]; ok && score >= threshold {
+			include = true
+		}
+		if !include && n.Data == "p" {
+			s := selectNodes(n)
+			length := utf8.RuneCountInString(normalizeSpace(s.Text()))
+			density := linkDensity(s)
+			include = (length >= 80 && density < 0.25) || (length > 0 && density == 0 && strings.ContainsAny(s.Text(), ".。"))
+		}
+		if include {
+			nodes = append(nodes, n)
+		}
+	}
+	content := selectNodes(nodes...)
+
+	// 本文中のリンク集・関連記事などを取り除く
+	content.Find("div, section, ul, ol, table, aside").Each(func(_ int, s *goquery.Selection) {
+		if classWeight(s.Get(0)) < 0 || linkDensity(s) > 0.5 {
+			s.Remove()
+		}
+	})
+	return content
+}
+
+// initialScore 要素の種類とclass・idによる初期スコア
+func initialScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.Data {
+	case "div", "article", "main", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score + classWeight(n)
+}
+
+// classWeight class・idが本文らしい場合は正、本文らしくない場合は負の重み
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, attr := range n.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if negativeClassPattern.MatchString(attr.Val) {
+			weight -= 25
+		}
+		if positiveClassPattern.MatchString(attr.Val) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// isBlockElement 段落として扱うブロック要素か判定します
+func isBlockElement(_ int, s *goquery.Selection) bool {
+	return extractBlockTags[goquery.NodeName(s)]
+}
+
+// linkDensity テキストのうちリンクの文字数の割合
+func linkDensity(s *goquery.Selection) float64 {
+	total := utf8.RuneCountInString(normalizeSpace(s.Text()))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += utf8.RuneCountInString(normalizeSpace(a.Text()))
+	})
+	return float64(links) / float64(total)
+}
+
+// blocksText 見出し・段落・リストの区切りを残して要素のテキストを返します
+func blocksText(s *goquery.Selection) string {
+	var b strings.Builder
+	for _, n := range s.Nodes {
+		writeBlocks(&b, n)
+		b.WriteString("\n\n")
+	}
+
+	// 行ごとの前後の空白を除き、3行以上の空行は1行にまとめる
+	var lines []string
+	blank := true
+	for _, line := range strings.Split(b.String(), "\n") {
+		if rest, ok := strings.CutPrefix(line, preformattedMark); ok {
+			lines = append(lines, strings.TrimRight(rest, " \t\r"))
+			blank = false
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		lines = append(lines, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// writeBlocks ノードのテキストをブロック要素ごとに改行で区切って書き込みます
+func writeBlocks(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(collapseSpace(n.Data))
+		return
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return
+	}
+
+	prefix, suffix := "", ""
+	switch n.Data {
+	case "br":
+		b.WriteString("\n")
+		return
+	case "img", "picture", "video", "audio":
+		return
+	case "pre":
+		// コードのインデントを残すため、行ごとに整形対象外の印を付ける
+		b.WriteString("\n\n```\n")
+		for _, line := range strings.Split(strings.Trim(goquery.NewDocumentFromNode(n).Text(), "\n"), "\n") {
+			b.WriteString(preformattedMark + line + "\n")
+		}
+		b.WriteString("```\n\n")
+		return
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		prefix, suffix = "\n\n"+strings.Repeat("#", int(n.Data[1]-'0'))+" ", "\n\n"
+	case "li":
+		prefix = "\n- "
+	case "td", "th":
+		suffix = " "
+	case "tr", "dt", "dd", "figcaption":
+		prefix, suffix = "\n", "\n"
+	default:
+		if extractBlockTags[n.Data] {
+			prefix, suffix = "\n\n", "\n\n"
+		}
+	}
+	b.WriteString(prefix)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeBlocks(b, c)
+	}
+	b.WriteString(suffix)
+}
+
+// extractMetadata JSON-LD・OpenGraph・metaタグなどからタイトル・著者・公開日時・サイト名を取り出します
+func extractMetadata(doc *goquery.Document) models.ExtractedArticle {
+	ld := jsonLDArticle(doc)
+	metaContent := func(selectors ...string) string {
+		for _, sel := range selectors {
+			if v := strings.TrimSpace(doc.Find(sel).First().AttrOr("content", "")); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	var a models.ExtractedArticle
+	a.Title = firstNonEmpty(
+		metaContent(`meta[property="og:title"]`, `meta[name="twitter:title"]`),
+		jsonLDString(ld["headline"]),
+		normalizeSpace(doc.Find("h1").First().Text()),
+	)
+	a.Author = firstNonEmpty(
+		authorName(jsonLDAuthor(ld["author"])),
+		authorName(metaContent(`meta[name="author"]`)),
+		authorName(metaContent(`meta[property="article:author"]`)),
+		authorName(doc.Find(`[itemprop="author"] [itemprop="name"], [rel="author"], [itemprop="author"]`).First().Text()),
+		authorName(doc.Find(`.byline, .author-name, .author`).First().Text()),
+	)
+	a.PublishedAt = parsePublished(firstNonEmpty(
+		metaContent(`meta[property="article:published_time"]`, `meta[itemprop="datePublished"]`,
+			`meta[name="pubdate"]`, `meta[name="publishdate"]`, `meta[name="publish-date"]`, `meta[name="date"]`,
+			`meta[name="dc.date"]`, `meta[name="DC.date.issued"]`),
+		jsonLDString(ld["datePublished"]),
+		publishedValue(doc.Find(`[itemprop="datePublished"], time[datetime]`).First()),
+	))
+	a.SiteName = metaContent(`meta[property="og:site_name"]`)
+	return a
+}
+
+// authorName 著者名として使える値を返します（プロフィールのURLや長すぎる値は空）
+func authorName(v string) string {
+	v = normalizeSpace(v)
+	if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") || utf8.RuneCountInString(v) > 100 {
+		return ""
+	}
+	for _, prefix := range []string{"By ", "by ", "BY "} {
+		v = strings.TrimPrefix(v, prefix)
+	}
+	return v
+}
+
+// jsonLDArticle JSON-LDの記事（Article・BlogPostingなど）を返します（ない場合は空）
+func jsonLDArticle(doc *goquery.Document) map[string]interface{} {
+	var found map[string]interface{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		if found != nil {
+			return
+		}
+		switch v := v.(type) {
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			types, _ := v["@type"].([]interface{})
+			if t, ok := v["@type"].(string); ok {
+				types = append(types, t)
+			}
+			for _, t := range types {
+				if s, _ := t.(string); jsonLDArticleTypes[s] {
+					found = v
+					return
+				}
+			}
+			walk(v["@graph"])
+		}
+	}
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &v); err == nil {
+			walk(v)
+		}
+	})
+	if found == nil {
+		return map[string]interface{}{}
+	}
+	return found
+}
+
+// jsonLDString JSON-LDの値が文字列の場合に返します
+func jsonLDString(v interface{}) string {
+	s, _ := v.(string)
+	return normalizeSpace(s)
+}
+
+// jsonLDAuthor JSON-LDのauthor（文字列・Person・その配列）から著者名をカンマ区切りで返します
+func jsonLDAuthor(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return normalizeSpace(v)
+	case map[string]interface{}:
+		return jsonLDString(v["name"])
+	case []interface{}:
+		var names []string
+		for _, item := range v {
+			if name := jsonLDAuthor(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// publishedValue 日時の要素から値を取り出します（datetime・content属性を優先）
+func publishedValue(s *goquery.Selection) string {
+	for _, attr := range []string{"datetime", "content"} {
+		if v := strings.TrimSpace(s.AttrOr(attr, "")); v != "" {
+			return v
+		}
+	}
+	return normalizeSpace(s.Text())
+}
+
+// parsePublished 公開日時をRFC3339に変換します（解釈できない場合は空）
+// タイムゾーンのない日時はサーバーのローカルタイムゾーンとして扱います
+func parsePublished(v string) string {
+	if v == "" {
+		return ""
+	}
+	for _, layout := range publishedLayouts {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+// normalizeSpace 連続する空白を1つにまとめ、前後の空白を除きます
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// collapseSpace 連続する空白を1つの空白にまとめます（前後の空白は1つ残します）
+func collapseSpace(s string) string {
+	if strings.TrimSpace(s) == "" {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	out := strings.Join(strings.Fields(s), " ")
+	if first, _ := utf8.DecodeRuneInString(s); strings.ContainsRune(" \t\n\r\f", first) {
+		out = " " + out
+	}
+	if last, _ := utf8.DecodeLastRuneInString(s); strings.ContainsRune(" \t\n\r\f", last) {
+		out += " "
+	}
+	return out
+}
+
+// firstNonEmpty 最初の空でない値を返します
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"trends-summary/internal/config"
+)
+
+const longParagraph = "This paragraph is long enough to be treated as part of the article body, with commas, and periods."
+
+func TestContentExtractorExtract(t *testing.T) {
+	extractor, err := NewContentExtractor(config.ExtractionConfig{
+		Sites: []config.SiteExtractionConfig{
+			{Domain: "example.com", Content: ".post-body", Title: "h1.headline", Author: ".writer", Published: "time.posted", Remove: []string{".ad"}},
+			{Domain: "blog.example.com", Content: ".entry"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewContentExtractor: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		html        string
+		want        []string // 本文に含まれるべき文字列
+		notWant     []string // 本文に含まれてはいけない文字列
+		title       string
+		author      string
+		published   string
+		siteName    string
+		err         error
+	}{
+		{
+			name:  "段落がbody直下にある",
+			url:   "https://plain.test/a",
+			html:  "<html><head><title>Plain</title></head><body><p>" + longParagraph + "</p><p>Second " + longParagraph + "</p></body></html>",
+			want:  []string{longParagraph, "Second " + longParagraph},
+			title: "Plain",
+		},
+		{
+			name:    "article要素とナビゲーション・関連記事",
+			url:     "https://news.test/a",
+			html:    `<html><body><nav><a href="/">Home</a> <a href="/about">About</a></nav><div class="sidebar"><p>Sidebar ` + longParagraph + `</p></div><article><h2>Heading</h2><p>` + longParagraph + `</p><ul><li>one</li><li>two</li></ul><div class="related"><a href="/x">Related article link text</a></div></article><footer>Copyright</footer></body></html>`,
+			want:    []string{"## Heading", longParagraph, "- one\n- two"},
+			notWant: []string{"Home", "Sidebar", "Related", "Copyright"},
+		},
+		{
+			name: "preのインデントを保持する",
+			url:  "https://code.test/a",
+			html: "<html><body><article><p>" + longParagraph + "</p><pre>func main() {\n\tprintln(1)\n}</pre></article></body></html>",
+			want: []string{"```\nfunc main() {\n\tprintln(1)\n}\n```"},
+		},
+		{
+			name: "JSON-LDとOGのメタデータ",
+			url:  "https://meta.test/a",
+			html: `<html><head><title>Fallback</title><meta property="og:site_name" content="Meta Site">` +
+				`<script type="application/ld+json">{"@type":"NewsArticle","headline":"LD Headline","author":{"@type":"Person","name":"Alice"},"datePublished":"2024-05-01T09:00:00+09:00"}</script>` +
+				`</head><body><article><p>` + longParagraph + `</p></article></body></html>`,
+			want:      []string{longParagraph},
+			title:     "LD Headline",
+			author:    "Alice",
+			published: "2024-05-01T09:00:00+09:00",
+			siteName:  "Meta Site",
+		},
+		{
+			name:      "サイトごとのセレクタ",
+			url:       "https://www.example.com/post/1",
+			html:      `<html><head><title>Site Title</title></head><body><h1 class="headline">Override Title</h1><span class="writer">Bob</span><time class="posted" datetime="2024-01-02">Jan 2</time><div class="post-body"><p>short</p><div class="ad">Buy now</div></div><div class="comments"><p>` + longParagraph + `</p><p>` + longParagraph + `</p></div></body></html>`,
+			want:      []string{"short"},
+			notWant:   []string{"Buy now", longParagraph},
+			title:     "Override Title",
+			author:    "Bob",
+			published: "2024-01-02T00:00:00Z",
+		},
+		{
+			name:    "最も長く一致するドメインのセレクタを使う",
+			url:     "https://blog.example.com/entry/1",
+			html:    `<html><body><div class="post-body">parent domain</div><div class="entry">subdomain body</div></body></html>`,
+			want:    []string{"subdomain body"},
+			notWant: []string{"parent domain"},
+		},
+		{
+			name: "サイトのセレクタに一致しない場合は自動で推定する",
+			url:  "https://example.com/other",
+			html: "<html><body><div><p>" + longParagraph + "</p></div></body></html>",
+			want: []string{longParagraph},
+		},
+		{
+			name:        "Content-Typeのcharsetで変換する",
+			url:         "https://sjis.test/a",
+			contentType: "text/html; charset=Shift_JIS",
+			html:        "<html><body><p>" + strings.Repeat("\x93\xfa\x96\x7b\x8c\xea", 10) + "\x81\x42</p></body></html>",
+			want:        []string{strings.Repeat("日本語", 10) + "。"},
+		},
+		{
+			name: "本文がない",
+			url:  "https://empty.test/a",
+			html: "<html><head><title>Empty</title></head><body><script>var a = 1;</script></body></html>",
+			err:  ErrNoArticleContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "text/html; charset=utf-8"
+			}
+			article, err := extractor.Extract(tt.url, contentType, []byte(tt.html))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(article.Text, s) {
+					t.Errorf("Text does not contain %q\n%s", s, article.Text)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(article.Text, s) {
+					t.Errorf("Text contains %q\n%s", s, article.Text)
+				}
+			}
+			if tt.title != "" && article.Title != tt.title {
+				t.Errorf("Title = %q, want %q", article.Title, tt.title)
+			}
+			if tt.author != "" && article.Author != tt.author {
+				t.Errorf("Author = %q, want %q", article.Author, tt.author)
+			}
+			if tt.published != "" && article.PublishedAt != tt.published {
+				t.Errorf("PublishedAt = %q, want %q", article.PublishedAt, tt.published)
+			}
+			if tt.siteName != "" && article.SiteName != tt.siteName {
+				t.Errorf("SiteName = %q, want %q", article.SiteName, tt.siteName)
+			}
+			if article.URL != tt.url {
+				t.Errorf("URL = %q, want %q", article.URL, tt.url)
+			}
+		})
+	}
+}
+
+func TestContentExtractorMaxTextLength(t *testing.T) {
+	extractor, err := NewContentExtractor(config.ExtractionConfig{MaxTextLength: 30})
+	if err != nil {
+		t.Fatalf("NewContentExtractor: %v", err)
+	}
+	var body bytes.Buffer
+	body.WriteString("<html><body><article>")
+	for range 5 {
+		body.WriteString("<p>" + longParagraph + "</p>")
+	}
+	body.WriteString("</article></body></html>")
+
+	article, err := extractor.Extract("https://long.test/a", "text/html", body.Bytes())
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if n := len([]rune(article.Text)); n > 31 {
+		t.Errorf("Text length = %d, want <= 31: %q", n, article.Text)
+	}
+}
+
+func TestNewContentExtractorValidatesSites(t *testing.T) {
+	tests := []struct {
+		name string
+		site config.SiteExtractionConfig
+	}{
+		{"ドメインが空", config.SiteExtractionConfig{Content: "article"}},
+		{"本文のセレクタが不正", config.SiteExtractionConfig{Domain: "example.com", Content: "div[["}},
+		{"除外するセレクタが不正", config.SiteExtractionConfig{Domain: "example.com", Remove: []string{">>"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewContentExtractor(config.ExtractionConfig{Sites: []config.SiteExtractionConfig{tt.site}})
+			if err == nil {
+				t.Fatal("err = nil, want error")
+			}
+		})
+	}
+}
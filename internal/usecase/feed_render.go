@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown LLMが返すMarkdownの変換器（生HTMLは既定でエスケープされる）
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// MarkdownToHTML MarkdownをHTMLに変換します
+func MarkdownToHTML(s string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(s), &buf); err != nil {
+		return "", fmt.Errorf("Markdownの変換に失敗しました: %w", err)
+	}
+	return buf.String(), nil
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language,omitempty"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// RenderRSS 正規化済みフィードをRSS 2.0のXMLに変換します
+func RenderRSS(feed models.Feed, link string) ([]byte, error) {
+	doc := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       feed.Title,
+			Link:        link,
+			Description: feed.Description,
+			Language:    feed.Language,
+		},
+	}
+
+	for _, item := range feed.Items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: itemContentHTML(item),
+			Author:      item.Author,
+			Categories:  item.Categories,
+			GUID:        rssGUID{Value: item.GUID, IsPermaLink: item.GUID == item.Link},
+			PubDate:     formatRFC1123(item.Published),
+		})
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// RenderAtom 正規化済みフィードをAtom 1.0のXMLに変換します
+func RenderAtom(feed models.Feed, selfURL, homeURL string) ([]byte, error) {
+	doc := atomFeed{
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		ID:       selfURL,
+		Updated:  time.Now().UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: selfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: homeURL, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	if len(feed.Items) > 0 {
+		doc.Updated = itemUpdated(feed.Items[0], doc.Updated)
+	}
+
+	for _, item := range feed.Items {
+		// Atomのidは IRI である必要があるため、GUIDがURIでない場合はリンクを使う
+		id := item.GUID
+		if !strings.Contains(id, ":") {
+			id = item.Link
+		}
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        id,
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate"}},
+			Published: item.Published,
+			Updated:   itemUpdated(item, doc.Updated),
+			Content:   &atomText{Type: "html", Value: item.Description},
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: item.Summary}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Language    string         `json:"language,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentHTML   string           `json:"content_html"`
+	Summary       string           `json:"summary,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published,omitempty"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Language      string           `json:"language,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// RenderJSONFeed 正規化済みフィードをJSON Feed 1.1に変換します
+func RenderJSONFeed(feed models.Feed, feedURL, homeURL string) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		Description: feed.Description,
+		HomePageURL: homeURL,
+		FeedURL:     feedURL,
+		Language:    feed.Language,
+		Items:       []jsonFeedItem{},
+	}
+
+	for _, item := range feed.Items {
+		entry := jsonFeedItem{
+			ID:            item.GUID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.Description,
+			Summary:       item.Summary,
+			Image:         item.Image,
+			DatePublished: item.Published,
+			DateModified:  item.Updated,
+			Tags:          item.Categories,
+			Language:      item.Language,
+		}
+		if item.Author != "" {
+			entry.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, entry)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// itemContentHTML RSSの本文を返します（AI要約がある場合は本文の前に付与）
+func itemContentHTML(item models.FeedItem) string {
+	if item.Summary == "" {
+		return item.Description
+	}
+	summary, err := MarkdownToHTML(item.Summary)
+	if err != nil {
+		return item.Description
+	}
+	return "<p><strong>AI要約</strong></p>" + summary + "<hr>" + item.Description
+}
+
+// itemUpdated 記事の更新日時（なければ公開日時、どちらもなければfallback）を返します
+func itemUpdated(item models.FeedItem, fallback string) string {
+	if item.Updated != "" {
+		return item.Updated
+	}
+	if item.Published != "" {
+		return item.Published
+	}
+	return fallback
+}
+
+// formatRFC1123 RFC3339の日時をRSSのpubDate形式に変換します
+func formatRFC1123(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/sirupsen/logrus"
+)
+
+// FeedRegistry 設定ファイルから読み込んだフィードソースの一覧
+type FeedRegistry struct {
+	sources []models.FeedSource
+	byID    map[string]models.FeedSource
+}
+
+// NewFeedRegistry フィードソース定義を検証してレジストリを作成します
+func NewFeedRegistry(sources []models.FeedSource) (*FeedRegistry, error) {
+	r := &FeedRegistry{
+		byID: make(map[string]models.FeedSource, len(sources)),
+	}
+	for _, src := range sources {
+		if src.ID == "" {
+			return nil, fmt.Errorf("フィードソースのidが未設定です")
+		}
+		if _, ok := r.byID[src.ID]; ok {
+			return nil, fmt.Errorf("フィードソースのidが重複しています: %s", src.ID)
+		}
+		if len(src.URLs) == 0 {
+			return nil, fmt.Errorf("フィードソース %s のurlsが未設定です", src.ID)
+		}
+		switch src.Output {
+		case "":
+			src.Output = models.FeedOutputJSON
+		case models.FeedOutputJSON, models.FeedOutputRaw, models.FeedOutputNormalized, models.FeedOutputRSS:
+		default:
+			return nil, fmt.Errorf("フィードソース %s のoutputが不正です: %s", src.ID, src.Output)
+		}
+		if src.Title == "" {
+			src.Title = src.Name
+		}
+		r.sources = append(r.sources, src)
+		r.byID[src.ID] = src
+	}
+	return r, nil
+}
+
+// Get idに対応するフィードソースを返します
+func (r *FeedRegistry) Get(id string) (models.FeedSource, bool) {
+	src, ok := r.byID[id]
+	return src, ok
+}
+
+// List 登録済みのフィードソースを定義順に返します
+func (r *FeedRegistry) List() []models.FeedSource {
+	return append([]models.FeedSource(nil), r.sources...)
+}
+
+// LanguageVariant 同じフィードの指定言語版のソースを返します（例: infoq と infoq-ja）
+// 言語版はidの末尾に "-言語" を付けて登録されているものとし、見つからない場合は元のソースを返します
+func (r *FeedRegistry) LanguageVariant(id, language string) (models.FeedSource, bool) {
+	src, ok := r.byID[id]
+	if !ok || language == "" || src.Language == language {
+		return src, ok
+	}
+
+	base := strings.TrimSuffix(id, "-"+src.Language)
+	for _, candidate := range []string{base + "-" + language, base} {
+		if v, ok := r.byID[candidate]; ok && v.Language == language {
+			return v, true
+		}
+	}
+	return src, true
+}
+
+// FetchFeedItems ソースの全URLからフィードを取得し、公開日時の新しい順に統合して返します
+// 一部のURLの取得に失敗した場合はスキップし、すべて失敗した場合のみエラーを返します
+func FetchFeedItems(ctx context.Context, fetcher *Fetcher, src models.FeedSource) ([]*gofeed.Item, *gofeed.Feed, error) {
+	fp := gofeed.NewParser()
+
+	var allItems []*gofeed.Item
+	var firstFeed *gofeed.Feed
+	var lastErr error
+	for _, feedURL := range src.URLs {
+		feed, err := fetchFeed(ctx, fetcher, fp, feedURL)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "FetchFeedItems",
+				"sourceID": src.ID,
+				"feedURL":  feedURL,
+				"error":    err.Error(),
+			}).Warn("一部のRSSフィード取得に失敗しました（スキップ）")
+			lastErr = err
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"function":  "FetchFeedItems",
+			"sourceID":  src.ID,
+			"feedURL":   feedURL,
+			"feedTitle": feed.Title,
+			"itemCount": len(feed.Items),
+		}).Info("RSSフィード取得成功")
+
+		if firstFeed == nil {
+			firstFeed = feed
+		}
+		allItems = append(allItems, feed.Items...)
+	}
+
+	if firstFeed == nil {
+		return nil, nil, fmt.Errorf("すべてのRSSフィード取得に失敗しました: %w", lastErr)
+	}
+
+	// 複数フィードを統合した場合は公開日時でソート（新しい順）
+	if len(src.URLs) > 1 {
+		sort.SliceStable(allItems, func(i, j int) bool {
+			if allItems[i].PublishedParsed == nil || allItems[j].PublishedParsed == nil {
+				return false
+			}
+			return allItems[i].PublishedParsed.After(*allItems[j].PublishedParsed)
+		})
+	}
+
+	return allItems, firstFeed, nil
+}
+
+// fetchFeed フェッチャーでフィードを取得してパースします
+func fetchFeed(ctx context.Context, fetcher *Fetcher, fp *gofeed.Parser, feedURL string) (*gofeed.Feed, error) {
+	res, err := fetcher.Get(ctx, feedURL, FetchFeedTypes)
+	if err != nil {
+		return nil, err
+	}
+	return fp.Parse(bytes.NewReader(res.Body))
+}
+
+// NormalizeFeed gofeedの取得結果を共通のFeedモデルに変換します
+func NormalizeFeed(src models.FeedSource, feed *gofeed.Feed, items []*gofeed.Item) models.Feed {
+	// 単一フィードの場合は上流のタイトルと説明を優先
+	title, description := src.Title, src.Description
+	if len(src.URLs) == 1 && feed != nil {
+		title, description = feed.Title, feed.Description
+	}
+
+	normalized := models.Feed{
+		SourceID:    src.ID,
+		Title:       title,
+		Description: description,
+		Language:    src.Language,
+		Category:    src.Category,
+		Items:       make([]models.FeedItem, 0, len(items)),
+	}
+	for _, item := range items {
+		normalized.Items = append(normalized.Items, NormalizeFeedItem(src, item))
+	}
+	return normalized
+}
+
+// NormalizeFeedItem gofeedの記事を共通のFeedItemモデルに変換します
+func NormalizeFeedItem(src models.FeedSource, item *gofeed.Item) models.FeedItem {
+	normalized := models.FeedItem{
+		GUID:        item.GUID,
+		Title:       item.Title,
+		Link:        item.Link,
+		Description: item.Description,
+		Categories:  item.Categories,
+		SourceID:    src.ID,
+		Language:    src.Language,
+	}
+
+	// GUIDを持たないフィードはリンクで代用
+	if normalized.GUID == "" {
+		normalized.GUID = item.Link
+	}
+	if normalized.Categories == nil {
+		normalized.Categories = []string{}
+	}
+
+	if item.Author != nil {
+		normalized.Author = item.Author.Name
+	} else if len(item.Authors) > 0 && item.Authors[0] != nil {
+		normalized.Author = item.Authors[0].Name
+	}
+
+	if item.Image != nil {
+		normalized.Image = item.Image.URL
+	} else {
+		for _, enc := range item.Enclosures {
+			if enc != nil && strings.HasPrefix(enc.Type, "image/") {
+				normalized.Image = enc.URL
+				break
+			}
+		}
+	}
+
+	if item.PublishedParsed != nil {
+		normalized.Published = item.PublishedParsed.UTC().Format(time.RFC3339)
+	}
+	if item.UpdatedParsed != nil {
+		normalized.Updated = item.UpdatedParsed.UTC().Format(time.RFC3339)
+	}
+
+	return normalized
+}
+
+// RefreshFeed ソースのフィードを取得してストアに保存し、新規に追加された記事を返します
+func RefreshFeed(ctx context.Context, fetcher *Fetcher, st *store.Store, src models.FeedSource) ([]models.FeedItem, error) {
+	items, feed, err := FetchFeedItems(ctx, fetcher, src)
+	if err != nil {
+		return nil, err
+	}
+	normalized := NormalizeFeed(src, feed, items)
+	return st.SaveArticles(normalized.Items)
+}
+
+// LoadFeed ストアに保存済みの記事からフィードを組み立てます
+// まだ一度も取得していないソースはその場で取得して保存します
+func LoadFeed(ctx context.Context, fetcher *Fetcher, st *store.Store, src models.FeedSource, limit int) (models.Feed, error) {
+	items, err := st.ListArticles(src.ID, limit)
+	if err != nil {
+		return models.Feed{}, err
+	}
+
+	if len(items) == 0 {
+		logrus.WithFields(logrus.Fields{
+			"function": "LoadFeed",
+			"sourceID": src.ID,
+		}).Info("ストアに記事がないためフィードを取得します")
+		if _, err := RefreshFeed(ctx, fetcher, st, src); err != nil {
+			return models.Feed{}, err
+		}
+		if items, err = st.ListArticles(src.ID, limit); err != nil {
+			return models.Feed{}, err
+		}
+	}
+
+	return models.Feed{
+		SourceID:    src.ID,
+		Title:       src.Title,
+		Description: src.Description,
+		Language:    src.Language,
+		Category:    src.Category,
+		Items:       items,
+	}, nil
+}
@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"trends-summary/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrFetchNotAllowed スキーム・ドメイン・接続先アドレスが許可されていないURLを取得しようとした場合のエラー
+	ErrFetchNotAllowed = errors.New("このURLは取得できません")
+	// ErrFetchTooLarge レスポンスが最大サイズを超えた場合のエラー
+	ErrFetchTooLarge = errors.New("レスポンスが大きすぎます")
+	// ErrFetchContentType レスポンスのContent-Typeが想定と異なる場合のエラー
+	ErrFetchContentType = errors.New("レスポンスの形式が対応していません")
+)
+
+// 取得するコンテンツの種類ごとに許可するContent-Type
+var (
+	FetchHTMLTypes = []string{"text/html", "application/xhtml+xml"}
+	FetchFeedTypes = []string{"application/rss+xml", "application/atom+xml", "application/feed+json", "application/json", "application/xml", "text/xml"}
+)
+
+// blockedPrefixes IsPrivate などで判定できない、外部から到達させるべきでないアドレス範囲
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // 「このネットワーク」
+	netip.MustParsePrefix("100.64.0.0/10"),  // キャリアグレードNAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETFプロトコル割り当て
+	netip.MustParsePrefix("198.18.0.0/15"),  // ベンチマーク用
+	netip.MustParsePrefix("240.0.0.0/4"),    // 予約済み・ブロードキャスト
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64（IPv4の内部アドレスに変換される）
+	netip.MustParsePrefix("64:ff9b:1::/48"), // ローカルNAT64
+	netip.MustParsePrefix("fec0::/10"),      // サイトローカル（廃止済み）
+	netip.MustParsePrefix("2002::/16"),      // 6to4（IPv4アドレスを埋め込める）
+	netip.MustParsePrefix("2001::/32"),      // Teredo（IPv4アドレスを埋め込める）
+}
+
+// FetchResult 取得したレスポンス
+type FetchResult struct {
+	URL         string // リダイレクト後の最終的なURL
+	ContentType string // 返されたContent-Type（返されない場合は本文から推定）
+	Body        []byte
+}
+
+// Fetcher ユーザーが指定したURLなど外部のコンテンツを取得する共通のHTTPクライアントです
+// 接続先のアドレスは名前解決後の実際の接続時に検査するため、リダイレクトやDNSの切り替えでも内部アドレスには接続しません
+type Fetcher struct {
+	client          *http.Client
+	maxBodyBytes    int64
+	allowedSchemes  []string
+	allowedDomains  []string
+	allowedNetworks []netip.Prefix
+}
+
+// NewFetcher 設定から取得用のクライアントを作成します
+func NewFetcher(cfg config.FetcherConfig) (*Fetcher, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 5 << 20
+	}
+	if cfg.MaxRedirects < 0 {
+		cfg.MaxRedirects = 0
+	}
+	if len(cfg.AllowedSchemes) == 0 {
+		cfg.AllowedSchemes = []string{"http", "https"}
+	}
+
+	f := &Fetcher{maxBodyBytes: cfg.MaxBodyBytes}
+	for _, scheme := range cfg.AllowedSchemes {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if scheme != "http" && scheme != "https" {
+			return nil, fmt.Errorf("fetcherのallowed_schemesはhttp/httpsのいずれかを指定してください: %s", scheme)
+		}
+		f.allowedSchemes = append(f.allowedSchemes, scheme)
+	}
+	for _, domain := range cfg.AllowedDomains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain == "" {
+			return nil, fmt.Errorf("fetcherのallowed_domainsに空のドメインは指定できません")
+		}
+		f.allowedDomains = append(f.allowedDomains, domain)
+	}
+	for _, cidr := range cfg.AllowedNetworks {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("fetcherのallowed_networksが不正です: %w", err)
+		}
+		f.allowedNetworks = append(f.allowedNetworks, prefix.Masked())
+	}
+
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		// 名前解決後の接続先アドレスを検査する
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return f.checkAddress(address)
+		},
+	}
+	f.client = &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil, // プロキシ経由では接続先アドレスを検査できないため使用しない
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   cfg.Timeout,
+			ResponseHeaderTimeout: cfg.Timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return fmt.Errorf("リダイレクトが多すぎます（最大%d回）", cfg.MaxRedirects)
+			}
+			return f.CheckURL(req.URL)
+		},
+	}
+	return f, nil
+}
+
+// CheckURL スキームとドメインが許可されているか検査します（接続先アドレスは接続時に検査します）
+func (f *Fetcher) CheckURL(u *url.URL) error {
+	if !slices.Contains(f.allowedSchemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: %sスキームは許可されていません", ErrFetchNotAllowed, u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: ホストが指定されていません", ErrFetchNotAllowed)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: URLに認証情報は含められません", ErrFetchNotAllowed)
+	}
+	if len(f.allowedDomains) == 0 {
+		return nil
+	}
+	for _, domain := range f.allowedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s は許可されたドメインではありません", ErrFetchNotAllowed, host)
+}
+
+// checkAddress 接続先のアドレスが内部アドレスでないか検査します
+func (f *Fetcher) checkAddress(address string) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: 接続先アドレスが不正です: %s", ErrFetchNotAllowed, address)
+	}
+	ip := addrPort.Addr().Unmap()
+	for _, prefix := range f.allowedNetworks {
+		if prefix.Contains(ip) {
+			return nil
+		}
+	}
+	if isInternalAddress(ip) {
+		return fmt.Errorf("%w: 内部アドレス %s には接続できません", ErrFetchNotAllowed, ip)
+	}
+	return nil
+}
+
+// isInternalAddress ループバック・プライベート・リンクローカルなど外部から到達させるべきでないアドレスか判定します
+func isInternalAddress(ip netip.Addr) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get URLを取得し、ステータスコード200かつContent-TypeがacceptTypesのいずれかの場合に本文を返します
+// Content-Typeが返されない場合は本文から推定します
+func (f *Fetcher) Get(ctx context.Context, rawURL string, acceptTypes []string) (*FetchResult, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("URLが無効です: %s", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("URLが無効です: %w", err)
+	}
+	req.Header.Set("Accept", strings.Join(acceptTypes, ", ")+", */*;q=0.1")
+
+	resp, err := f.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("リクエストが失敗しました。ステータスコード: %d", resp.StatusCode)
+	}
+	if resp.ContentLength > f.maxBodyBytes {
+		return nil, fmt.Errorf("%w（%dバイト、最大%dバイト）", ErrFetchTooLarge, resp.ContentLength, f.maxBodyBytes)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("レスポンスの読み込みに失敗しました: %w", err)
+	}
+	if int64(len(body)) > f.maxBodyBytes {
+		return nil, fmt.Errorf("%w（最大%dバイト）", ErrFetchTooLarge, f.maxBodyBytes)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !slices.Contains(acceptTypes, strings.ToLower(mediaType)) {
+		return nil, fmt.Errorf("%w: %s", ErrFetchContentType, contentType)
+	}
+
+	return &FetchResult{
+		URL:         resp.Request.URL.String(),
+		ContentType: contentType,
+		Body:        body,
+	}, nil
+}
+
+// Do URLを検査してからリクエストを送信します（Webhookの送信などGET以外のリクエスト用）
+// レスポンスの本文のサイズ・形式は検査しないため、呼び出し側で読み込む量を制限してください
+func (f *Fetcher) Do(req *http.Request) (*http.Response, error) {
+	if err := f.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrFetchNotAllowed) {
+			// 名前解決後の内部アドレスやリダイレクト先をクライアントに返さないよう、詳細はログにのみ出力する
+			logrus.WithFields(logrus.Fields{
+				"function": "Fetcher.Do",
+				"url":      req.URL.String(),
+				"error":    err.Error(),
+			}).Warn("許可されていない接続先へのリクエストを拒否しました")
+			return nil, ErrFetchNotAllowed
+		}
+		return nil, fmt.Errorf("URLへのリクエストに失敗しました: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"trends-summary/internal/config"
+)
+
+func TestFetcherBlocksInternalAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	fetcher, err := NewFetcher(config.FetcherConfig{})
+	if err != nil {
+		t.Fatalf("NewFetcher: %v", err)
+	}
+	_, err = fetcher.Get(context.Background(), server.URL, FetchHTMLTypes)
+	if !errors.Is(err, ErrFetchNotAllowed) {
+		t.Fatalf("err = %v, want ErrFetchNotAllowed", err)
+	}
+	// 接続先の内部アドレスをエラーメッセージに含めない
+	if strings.Contains(err.Error(), "127.0.0.1") {
+		t.Errorf("エラーメッセージに内部アドレスが含まれています: %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"trends-summary/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sirupsen/logrus"
+)
+
+const githubBaseURL = "https://github.com"
+
+var spokenLanguageCodePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// ValidateTrendingQuery 取得条件を検証し、未指定のsinceをdailyで補完します
+func ValidateTrendingQuery(q models.TrendingQuery) (models.TrendingQuery, error) {
+	switch q.Since {
+	case "":
+		q.Since = models.TrendingSinceDaily
+	case models.TrendingSinceDaily, models.TrendingSinceWeekly, models.TrendingSinceMonthly:
+	default:
+		return q, fmt.Errorf("sinceはdaily/weekly/monthlyのいずれかを指定してください")
+	}
+	if q.SpokenLanguageCode != "" && !spokenLanguageCodePattern.MatchString(q.SpokenLanguageCode) {
+		return q, fmt.Errorf("spoken_language_codeはISO 639-1の2文字で指定してください")
+	}
+	q.Language = strings.ToLower(strings.TrimSpace(q.Language))
+	return q, nil
+}
+
+// trendingURL GitHub TrendingのURLを組み立てます（pathは "/trending" または "/trending/developers"）
+func trendingURL(path string, q models.TrendingQuery) string {
+	targetURL := githubBaseURL + path
+	if q.Language != "" {
+		targetURL += "/" + url.PathEscape(q.Language)
+	}
+
+	params := url.Values{}
+	if q.Since != "" {
+		params.Set("since", q.Since)
+	}
+	if q.SpokenLanguageCode != "" {
+		params.Set("spoken_language_code", q.SpokenLanguageCode)
+	}
+	if len(params) > 0 {
+		targetURL += "?" + params.Encode()
+	}
+	return targetURL
+}
+
+// fetchTrendingDocument GitHub Trendingのページを取得してパースします
+func fetchTrendingDocument(ctx context.Context, fetcher *Fetcher, function, targetURL string) (*goquery.Document, error) {
+	// GitHub Trendingページをスクレイピング
+	res, err := fetcher.Get(ctx, targetURL, FetchHTMLTypes)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function":  function,
+			"targetURL": targetURL,
+			"error":     err.Error(),
+			"errorType": "HTTPリクエストエラー",
+		}).Error("GitHub Trendingページの取得に失敗しました")
+		return nil, fmt.Errorf("GitHub Trendingページの取得に失敗しました: %w", err)
+	}
+
+	// HTMLドキュメントをパース
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(res.Body))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function":  function,
+			"targetURL": targetURL,
+			"error":     err.Error(),
+			"errorType": "HTMLパースエラー",
+		}).Error("GitHub Trendingページのパースに失敗しました")
+		return nil, fmt.Errorf("GitHub Trendingページのパースに失敗しました: %w", err)
+	}
+	return doc, nil
+}
+
+// ScrapeGitHubTrending GitHub Trendingページからリポジトリ一覧を取得します
+func ScrapeGitHubTrending(ctx context.Context, fetcher *Fetcher, q models.TrendingQuery) ([]models.TrendingRepository, error) {
+	q, err := ValidateTrendingQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	targetURL := trendingURL("/trending", q)
+
+	doc, err := fetchTrendingDocument(ctx, fetcher, "ScrapeGitHubTrending", targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	trendingRepos := []models.TrendingRepository{}
+
+	// トレンドリポジトリを抽出
+	doc.Find("article.Box-row").Each(func(i int, s *goquery.Selection) {
+		// リポジトリのURL（/owner/repo）からオーナー名とリポジトリ名を取得
+		repoURL, _ := s.Find("h2.h3 a").Attr("href")
+		repoPath := strings.Trim(strings.TrimSpace(repoURL), "/")
+		owner, repoName, _ := strings.Cut(repoPath, "/")
+
+		// Built by（主なコントリビューター）
+		builtBy := []models.TrendingContributor{}
+		s.Find("span:contains('Built by') a").Each(func(_ int, a *goquery.Selection) {
+			href, _ := a.Attr("href")
+			avatar, _ := a.Find("img.avatar").Attr("src")
+			builtBy = append(builtBy, models.TrendingContributor{
+				Username:  strings.Trim(href, "/"),
+				URL:       githubBaseURL + href,
+				AvatarURL: avatar,
+			})
+		})
+
+		// "1,234 stars today" / "this week" / "this month" から数値部分を取り出す
+		starsGained, _, _ := strings.Cut(strings.TrimSpace(s.Find("span.float-sm-right").Text()), " ")
+
+		trendingRepos = append(trendingRepos, models.TrendingRepository{
+			Rank:        i + 1,
+			Name:        repoPath,
+			Owner:       owner,
+			Repo:        repoName,
+			URL:         githubBaseURL + "/" + repoPath,
+			Description: strings.TrimSpace(s.Find("p").Text()),
+			Language:    strings.TrimSpace(s.Find("[itemprop='programmingLanguage']").Text()),
+			Stars:       parseCount(s.Find("a.Link--muted[href$='/stargazers']").Text()),
+			Forks:       parseCount(s.Find("a.Link--muted[href$='/forks']").Text()),
+			StarsGained: parseCount(starsGained),
+			BuiltBy:     builtBy,
+		})
+	})
+
+	logrus.WithFields(logrus.Fields{
+		"function":          "ScrapeGitHubTrending",
+		"targetURL":         targetURL,
+		"repositoriesCount": len(trendingRepos),
+	}).Info("GitHubトレンドの取得に成功しました")
+
+	return trendingRepos, nil
+}
+
+// ScrapeGitHubTrendingDevelopers GitHub Trendingの開発者ページから開発者一覧を取得します
+// spoken_language_code は開発者ページでは使用されないため無視します
+func ScrapeGitHubTrendingDevelopers(ctx context.Context, fetcher *Fetcher, q models.TrendingQuery) ([]models.TrendingDeveloper, error) {
+	q, err := ValidateTrendingQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	q.SpokenLanguageCode = ""
+	targetURL := trendingURL("/trending/developers", q)
+
+	doc, err := fetchTrendingDocument(ctx, fetcher, "ScrapeGitHubTrendingDevelopers", targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	developers := []models.TrendingDeveloper{}
+
+	// トレンド開発者を抽出
+	doc.Find("article.Box-row").Each(func(i int, s *goquery.Selection) {
+		nameAnchor := s.Find("h1.h3 a")
+		href, _ := nameAnchor.Attr("href")
+		avatar, _ := s.Find("img.avatar").First().Attr("src")
+
+		developer := models.TrendingDeveloper{
+			Rank:      i + 1,
+			Name:      strings.TrimSpace(nameAnchor.Text()),
+			Username:  strings.Trim(href, "/"),
+			URL:       githubBaseURL + href,
+			AvatarURL: avatar,
+		}
+
+		// 代表的なリポジトリ（Popular repo）
+		repoAnchor := s.Find("article h1 a")
+		if repoHref, ok := repoAnchor.Attr("href"); ok {
+			developer.PopularRepo = &models.TrendingDeveloperRepo{
+				Name:        strings.TrimSpace(repoAnchor.Text()),
+				URL:         githubBaseURL + repoHref,
+				Description: strings.TrimSpace(s.Find("article div.f6").Text()),
+			}
+		}
+
+		developers = append(developers, developer)
+	})
+
+	logrus.WithFields(logrus.Fields{
+		"function":        "ScrapeGitHubTrendingDevelopers",
+		"targetURL":       targetURL,
+		"developersCount": len(developers),
+	}).Info("GitHubトレンド開発者の取得に成功しました")
+
+	return developers, nil
+}
+
+// MergeTrending 言語ごとのトレンドを1つの一覧にまとめます
+// 期間中に増えたスター数の多い順（同数の場合は言語ごとの順位順）に並べ、Rankを1から振り直します
+func MergeTrending(lists ...[]models.TrendingRepository) []models.TrendingRepository {
+	merged := []models.TrendingRepository{}
+	seen := map[string]bool{}
+	for _, repos := range lists {
+		for _, repo := range repos {
+			if seen[repo.Name] {
+				continue
+			}
+			seen[repo.Name] = true
+			merged = append(merged, repo)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].StarsGained != merged[j].StarsGained {
+			return merged[i].StarsGained > merged[j].StarsGained
+		}
+		return merged[i].Rank < merged[j].Rank
+	})
+	for i := range merged {
+		merged[i].Rank = i + 1
+	}
+	return merged
+}
+
+// parseCount "12,345" のような表記の数値を整数に変換します（解析できない場合は0）
+func parseCount(s string) int {
+	n, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(s), ",", ""))
+	if err != nil {
+		return 0
+	}
+	return n
+}
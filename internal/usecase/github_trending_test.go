@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"testing"
+
+	"trends-summary/internal/models"
+)
+
+func TestMergeTrending(t *testing.T) {
+	goRepos := []models.TrendingRepository{
+		{Rank: 1, Name: "a/go1", StarsGained: 300},
+		{Rank: 2, Name: "a/go2", StarsGained: 50},
+	}
+	rustRepos := []models.TrendingRepository{
+		{Rank: 1, Name: "b/rust1", StarsGained: 500},
+		{Rank: 2, Name: "b/rust2", StarsGained: 50},
+		{Rank: 3, Name: "a/go1", StarsGained: 300}, // 重複は除く
+	}
+
+	merged := MergeTrending(goRepos, rustRepos, nil)
+
+	want := []string{"b/rust1", "a/go1", "a/go2", "b/rust2"}
+	if len(merged) != len(want) {
+		t.Fatalf("merged = %+v, want %v", merged, want)
+	}
+	for i, repo := range merged {
+		if repo.Name != want[i] || repo.Rank != i+1 {
+			t.Errorf("merged[%d] = %s (rank %d), want %s (rank %d)", i, repo.Name, repo.Rank, want[i], i+1)
+		}
+	}
+	// 元の一覧の順位は変更しない
+	if goRepos[0].Rank != 1 || rustRepos[0].Rank != 1 {
+		t.Error("MergeTrending modified the input lists")
+	}
+}
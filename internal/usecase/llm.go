@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"trends-summary/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LLMの種類
+const (
+	LLMTypeGemini = "gemini"
+	LLMTypeOpenAI = "openai" // OpenAI互換のChat Completions API
+	LLMTypeOllama = "ollama"
+)
+
+// LLMResponse LLMの生成結果
+type LLMResponse struct {
+	Text         string
+	Model        string
+	FinishReason string
+	PromptTokens int
+	OutputTokens int
+	TotalTokens  int
+}
+
+// LLMProvider テキスト生成を行うLLMの共通インターフェース
+type LLMProvider interface {
+	// Name 設定ファイル上のプロバイダー名
+	Name() string
+	// Model 使用するモデル名
+	Model() string
+	// Generate プロンプトからテキストを生成します
+	Generate(ctx context.Context, prompt string) (*LLMResponse, error)
+	// GenerateStream 生成されたテキストを受信するたびにonChunkを呼び出し、最後に全文と使用量を返します
+	// リクエストが受け付けられた後のエラー（onChunkのエラーを含む）では、途中までのテキストと判明している使用量もあわせて返します
+	GenerateStream(ctx context.Context, prompt string, onChunk func(text string) error) (*LLMResponse, error)
+}
+
+// NewLLMProvider 設定からプロバイダーを作成します
+func NewLLMProvider(name string, cfg config.LLMProviderConfig) (LLMProvider, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("LLMプロバイダー %s のmodelが未設定です", name)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.StreamTimeout <= 0 {
+		cfg.StreamTimeout = 5 * time.Minute
+	}
+
+	switch cfg.Type {
+	case LLMTypeGemini:
+		return newGeminiProvider(name, cfg), nil
+	case LLMTypeOpenAI:
+		return newOpenAIProvider(name, cfg)
+	case LLMTypeOllama:
+		return newOllamaProvider(name, cfg)
+	default:
+		return nil, fmt.Errorf("LLMプロバイダー %s のtypeが不正です: %s", name, cfg.Type)
+	}
+}
+
+// LLMRouter エンドポイントごとに使用するLLMプロバイダーを振り分けます
+type LLMRouter struct {
+	providers       map[string]LLMProvider
+	endpoints       map[string]string
+	defaultProvider string
+	usage           *AIUsageTracker
+}
+
+// NewLLMRouterFromConfig 設定ファイルのLLM定義からルーターを作成します
+func NewLLMRouterFromConfig(cfg config.LLMConfig) (*LLMRouter, error) {
+	providers := make(map[string]LLMProvider, len(cfg.Providers))
+	for name, providerCfg := range cfg.Providers {
+		provider, err := NewLLMProvider(name, providerCfg)
+		if err != nil {
+			return nil, err
+		}
+		providers[name] = provider
+	}
+	return NewLLMRouter(providers, cfg.Endpoints, cfg.Default)
+}
+
+// NewLLMRouter プロバイダー一覧とエンドポイントの割り当てからルーターを作成します
+func NewLLMRouter(providers map[string]LLMProvider, endpoints map[string]string, defaultProvider string) (*LLMRouter, error) {
+	if _, ok := providers[defaultProvider]; !ok {
+		return nil, fmt.Errorf("デフォルトのLLMプロバイダー %s が定義されていません", defaultProvider)
+	}
+	for endpoint, name := range endpoints {
+		if _, ok := providers[name]; !ok {
+			return nil, fmt.Errorf("エンドポイント %s のLLMプロバイダー %s が定義されていません", endpoint, name)
+		}
+	}
+	return &LLMRouter{
+		providers:       providers,
+		endpoints:       endpoints,
+		defaultProvider: defaultProvider,
+	}, nil
+}
+
+// SetUsageTracker 生成に成功した呼び出しの利用量を記録する先を設定します（nilの場合は記録しない）
+func (r *LLMRouter) SetUsageTracker(t *AIUsageTracker) {
+	r.usage = t
+}
+
+// Provider エンドポイントに割り当てられたプロバイダーを返します（未設定の場合はデフォルト）
+func (r *LLMRouter) Provider(endpoint string) LLMProvider {
+	if name, ok := r.endpoints[endpoint]; ok {
+		return r.providers[name]
+	}
+	return r.providers[r.defaultProvider]
+}
+
+// Generate エンドポイントに割り当てられたプロバイダーでテキストを生成します
+func (r *LLMRouter) Generate(ctx context.Context, endpoint, prompt string) (*LLMResponse, error) {
+	provider := r.Provider(endpoint)
+	logrus.WithFields(logrus.Fields{
+		"function":       "LLMRouter.Generate",
+		"endpoint":       endpoint,
+		"provider":       provider.Name(),
+		"modelName":      provider.Model(),
+		"requestTextLen": len(prompt),
+	}).Info("LLMリクエスト開始")
+
+	resp, err := provider.Generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"function":     "LLMRouter.Generate",
+		"endpoint":     endpoint,
+		"provider":     provider.Name(),
+		"modelName":    resp.Model,
+		"summaryLen":   len(resp.Text),
+		"promptTokens": resp.PromptTokens,
+		"outputTokens": resp.OutputTokens,
+	}).Info("LLM生成成功")
+
+	if r.usage != nil {
+		r.usage.Record(ctx, endpoint, provider.Name(), resp)
+	}
+	return resp, nil
+}
+
+// GenerateStream エンドポイントに割り当てられたプロバイダーでテキストをストリーミング生成します
+func (r *LLMRouter) GenerateStream(ctx context.Context, endpoint, prompt string, onChunk func(text string) error) (*LLMResponse, error) {
+	provider := r.Provider(endpoint)
+	logrus.WithFields(logrus.Fields{
+		"function":       "LLMRouter.GenerateStream",
+		"endpoint":       endpoint,
+		"provider":       provider.Name(),
+		"modelName":      provider.Model(),
+		"requestTextLen": len(prompt),
+	}).Info("LLMストリーミングリクエスト開始")
+
+	resp, err := provider.GenerateStream(ctx, prompt, onChunk)
+	if err != nil {
+		// 途中で失敗・切断した場合も、送信済みのプロンプトと生成済みの分は課金されるため記録する
+		if resp != nil && r.usage != nil {
+			estimateUsage(resp, prompt)
+			r.usage.Record(context.WithoutCancel(ctx), endpoint, provider.Name(), resp)
+		}
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"function":     "LLMRouter.GenerateStream",
+		"endpoint":     endpoint,
+		"provider":     provider.Name(),
+		"modelName":    resp.Model,
+		"summaryLen":   len(resp.Text),
+		"promptTokens": resp.PromptTokens,
+		"outputTokens": resp.OutputTokens,
+		"finishReason": resp.FinishReason,
+	}).Info("LLMストリーミング生成成功")
+
+	if r.usage != nil {
+		r.usage.Record(context.WithoutCancel(ctx), endpoint, provider.Name(), resp)
+	}
+	return resp, nil
+}
+
+// estimateUsage 使用量が返される前に中断した場合に、プロンプトと生成済みのテキストからトークン数を見積もります
+func estimateUsage(resp *LLMResponse, prompt string) {
+	if resp.PromptTokens == 0 {
+		resp.PromptTokens = estimateTokens(prompt)
+	}
+	if resp.OutputTokens == 0 {
+		resp.OutputTokens = estimateTokens(resp.Text)
+	}
+	resp.TotalTokens = resp.PromptTokens + resp.OutputTokens
+}
+
+// estimateTokens テキストのトークン数の概算（UTF-8で3バイトあたり1トークン、日本語は1文字あたり約1トークン）
+func estimateTokens(s string) int {
+	return (len(s) + 2) / 3
+}
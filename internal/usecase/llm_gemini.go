@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"trends-summary/internal/config"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// geminiProvider Google Gemini（genai, v1beta）によるLLMProvider
+// クライアントは初回リクエスト時に作成し、以降は使い回します
+type geminiProvider struct {
+	name string
+	cfg  config.LLMProviderConfig
+
+	mu     sync.Mutex
+	client *genai.Client
+}
+
+func newGeminiProvider(name string, cfg config.LLMProviderConfig) *geminiProvider {
+	if cfg.APIKeyEnv == "" {
+		cfg.APIKeyEnv = "GEMINI_API_KEY"
+	}
+	return &geminiProvider{name: name, cfg: cfg}
+}
+
+func (p *geminiProvider) Name() string  { return p.name }
+func (p *geminiProvider) Model() string { return p.cfg.Model }
+
+// getClient Geminiクライアントを返します（未作成の場合は作成）
+func (p *geminiProvider) getClient() (*genai.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		return p.client, nil
+	}
+
+	// 環境変数からAPIキーを取得
+	apiKey := os.Getenv(p.cfg.APIKeyEnv)
+	if apiKey == "" {
+		errmsg := fmt.Sprintf("APIキーが設定されていません。環境変数 %s を設定してください。", p.cfg.APIKeyEnv)
+		logrus.WithFields(logrus.Fields{
+			"function":  "geminiProvider.getClient",
+			"errorType": "環境変数エラー",
+		}).Error(errmsg)
+		return nil, fmt.Errorf("%s", errmsg)
+	}
+
+	// genaiライブラリのデフォルト（v1beta）を使用
+	// option.WithAPIKeyで自動的に認証ヘッダーが追加される
+	client, err := genai.NewClient(context.Background(), option.WithAPIKey(apiKey))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function":  "geminiProvider.getClient",
+			"error":     err.Error(),
+			"errorType": "Geminiクライアント作成エラー",
+		}).Error("Geminiクライアントの作成に失敗しました")
+		return nil, fmt.Errorf("Geminiクライアントの作成に失敗しました: %w", err)
+	}
+	p.client = client
+	return client, nil
+}
+
+// Generate Gemini APIでテキストを生成します
+func (p *geminiProvider) Generate(ctx context.Context, prompt string) (*LLMResponse, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// タイムアウト付きコンテキスト
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	model := client.GenerativeModel(p.cfg.Model)
+	response, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		logGeminiError(p.cfg.Model, err)
+		return nil, fmt.Errorf("Gemini APIリクエストに失敗しました: %w", err)
+	}
+
+	result := &LLMResponse{
+		Text:  geminiResponseText(response),
+		Model: p.cfg.Model,
+	}
+	if len(response.Candidates) > 0 {
+		result.FinishReason = response.Candidates[0].FinishReason.String()
+	}
+	if response.UsageMetadata != nil {
+		result.PromptTokens = int(response.UsageMetadata.PromptTokenCount)
+		result.OutputTokens = int(response.UsageMetadata.CandidatesTokenCount)
+		result.TotalTokens = int(response.UsageMetadata.TotalTokenCount)
+	}
+	return result, nil
+}
+
+// geminiResponseText レスポンスの全候補のテキストを結合します
+func geminiResponseText(response *genai.GenerateContentResponse) string {
+	var builder strings.Builder
+	for _, cand := range response.Candidates {
+		if cand.Content != nil {
+			for _, part := range cand.Content.Parts {
+				// 型アサーションと型スイッチを使用して、Textフィールドが存在するか確認し、値を取得する
+				switch v := part.(type) {
+				case genai.Text:
+					builder.WriteString(string(v)) // genai.Textはstring型に変換可能
+				default:
+					logrus.Warn("Part.(type) is not string")
+				}
+			}
+		}
+	}
+	return builder.String()
+}
+
+// logGeminiError googleapi.Errorの場合は詳細を含めてログ出力します
+func logGeminiError(modelName string, err error) {
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		logrus.WithFields(logrus.Fields{
+			"function":     "geminiProvider.Generate",
+			"modelName":    modelName,
+			"endpoint":     "v1beta",
+			"statusCode":   gerr.Code,
+			"errorMessage": gerr.Message,
+			"errorBody":    string(gerr.Body),
+			"errorType":    "Gemini API通信エラー",
+		}).Error("Gemini APIリクエストに失敗しました（詳細）")
+		return
+	}
+	logrus.WithFields(logrus.Fields{
+		"function":  "geminiProvider.Generate",
+		"modelName": modelName,
+		"endpoint":  "v1beta",
+		"error":     err.Error(),
+		"errorType": "Gemini API通信エラー",
+	}).Error("Gemini APIリクエストに失敗しました")
+}
+
+// GenerateStream Gemini APIのGenerateContentStreamでテキストをストリーミング生成します
+func (p *geminiProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(text string) error) (*LLMResponse, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.StreamTimeout)
+	defer cancel()
+
+	model := client.GenerativeModel(p.cfg.Model)
+	iter := model.GenerateContentStream(ctx, genai.Text(prompt))
+
+	result := &LLMResponse{Model: p.cfg.Model}
+	var builder strings.Builder
+	// 最初のレスポンスを受信した後のエラーでは、途中までの結果も返す
+	received := false
+	partial := func() *LLMResponse {
+		if !received {
+			return nil
+		}
+		result.Text = builder.String()
+		return result
+	}
+	for {
+		response, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			logGeminiError(p.cfg.Model, err)
+			return partial(), fmt.Errorf("Gemini APIストリーミングに失敗しました: %w", err)
+		}
+		received = true
+
+		if text := geminiResponseText(response); text != "" {
+			builder.WriteString(text)
+			if err := onChunk(text); err != nil {
+				return partial(), err
+			}
+		}
+		if len(response.Candidates) > 0 && response.Candidates[0].FinishReason != genai.FinishReasonUnspecified {
+			result.FinishReason = response.Candidates[0].FinishReason.String()
+		}
+		// 使用量は最後のレスポンスに累計が含まれる
+		if response.UsageMetadata != nil {
+			result.PromptTokens = int(response.UsageMetadata.PromptTokenCount)
+			result.OutputTokens = int(response.UsageMetadata.CandidatesTokenCount)
+			result.TotalTokens = int(response.UsageMetadata.TotalTokenCount)
+		}
+	}
+
+	result.Text = builder.String()
+	return result, nil
+}
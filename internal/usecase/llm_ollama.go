@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"trends-summary/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ollamaProvider ローカルのOllama（/api/generate）によるLLMProvider
+// 社外に送信できないコンテンツの要約に使用します
+type ollamaProvider struct {
+	name   string
+	cfg    config.LLMProviderConfig
+	client *http.Client
+}
+
+func newOllamaProvider(name string, cfg config.LLMProviderConfig) (*ollamaProvider, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "http://localhost:11434"
+	}
+	return &ollamaProvider{
+		name: name,
+		cfg:  cfg,
+		// ストリーミングではボディの受信が長時間続くため、タイムアウトはcontextで管理する
+		client: &http.Client{},
+	}, nil
+}
+
+func (p *ollamaProvider) Name() string  { return p.name }
+func (p *ollamaProvider) Model() string { return p.cfg.Model }
+
+type ollamaGenerateRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+type ollamaGenerateResponse struct {
+	Model           string `json:"model"`
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// post /api/generate にリクエストを送信します
+func (p *ollamaProvider) post(ctx context.Context, body ollamaGenerateRequest) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("Ollamaリクエストの作成に失敗しました: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(p.cfg.BaseURL, "/")+"/api/generate", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("Ollamaリクエストの作成に失敗しました: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function":  "ollamaProvider.post",
+			"modelName": p.cfg.Model,
+			"baseURL":   p.cfg.BaseURL,
+			"error":     err.Error(),
+			"errorType": "Ollama通信エラー",
+		}).Error("Ollamaへのリクエストに失敗しました")
+		return nil, fmt.Errorf("Ollamaへのリクエストに失敗しました: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		logrus.WithFields(logrus.Fields{
+			"function":   "ollamaProvider.post",
+			"modelName":  p.cfg.Model,
+			"baseURL":    p.cfg.BaseURL,
+			"statusCode": resp.StatusCode,
+			"errorBody":  string(errBody),
+			"errorType":  "Ollama通信エラー",
+		}).Error("Ollamaへのリクエストに失敗しました（詳細）")
+		return nil, fmt.Errorf("Ollamaへのリクエストに失敗しました。ステータスコード: %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// Generate Ollamaでテキストを生成します
+func (p *ollamaProvider) Generate(ctx context.Context, prompt string) (*LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	resp, err := p.post(ctx, ollamaGenerateRequest{
+		Model:  p.cfg.Model,
+		Prompt: prompt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body ollamaGenerateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("Ollamaレスポンスの解析に失敗しました: %w", err)
+	}
+
+	return &LLMResponse{
+		Text:         body.Response,
+		Model:        p.cfg.Model,
+		FinishReason: body.DoneReason,
+		PromptTokens: body.PromptEvalCount,
+		OutputTokens: body.EvalCount,
+		TotalTokens:  body.PromptEvalCount + body.EvalCount,
+	}, nil
+}
+
+// GenerateStream Ollamaのストリーミング（NDJSON）でテキストを生成します
+func (p *ollamaProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(text string) error) (*LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.StreamTimeout)
+	defer cancel()
+
+	resp, err := p.post(ctx, ollamaGenerateRequest{
+		Model:  p.cfg.Model,
+		Prompt: prompt,
+		Stream: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 以降のエラーではリクエストが受け付けられているため、途中までの結果も返す
+	result := &LLMResponse{Model: p.cfg.Model}
+	var builder strings.Builder
+	partial := func() *LLMResponse {
+		result.Text = builder.String()
+		return result
+	}
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaGenerateResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return partial(), fmt.Errorf("Ollamaストリームの解析に失敗しました: %w", err)
+		}
+
+		if chunk.Response != "" {
+			builder.WriteString(chunk.Response)
+			if err := onChunk(chunk.Response); err != nil {
+				return partial(), err
+			}
+		}
+		if chunk.Done {
+			result.FinishReason = chunk.DoneReason
+			result.PromptTokens = chunk.PromptEvalCount
+			result.OutputTokens = chunk.EvalCount
+			result.TotalTokens = chunk.PromptEvalCount + chunk.EvalCount
+			break
+		}
+	}
+	return partial(), nil
+}
@@ -0,0 +1,236 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"trends-summary/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// openAIProvider OpenAI互換のChat Completions APIによるLLMProvider
+type openAIProvider struct {
+	name   string
+	cfg    config.LLMProviderConfig
+	client *http.Client
+}
+
+func newOpenAIProvider(name string, cfg config.LLMProviderConfig) (*openAIProvider, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.openai.com/v1"
+	}
+	if cfg.APIKeyEnv == "" {
+		cfg.APIKeyEnv = "OPENAI_API_KEY"
+	}
+	return &openAIProvider{
+		name: name,
+		cfg:  cfg,
+		// ストリーミングではボディの受信が長時間続くため、タイムアウトはcontextで管理する
+		client: &http.Client{},
+	}, nil
+}
+
+func (p *openAIProvider) Name() string  { return p.name }
+func (p *openAIProvider) Model() string { return p.cfg.Model }
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage openAIUsage `json:"usage"`
+}
+
+type openAIChatChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta        openAIMessage `json:"delta"`
+		FinishReason *string       `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// newRequest Chat Completions APIのリクエストを作成します
+func (p *openAIProvider) newRequest(ctx context.Context, body openAIChatRequest) (*http.Request, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(p.cfg.BaseURL, "/")+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// ローカルのOpenAI互換サーバーなどAPIキー不要の場合は省略
+	if apiKey := os.Getenv(p.cfg.APIKeyEnv); apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return req, nil
+}
+
+// do リクエストを送信し、ステータスコードを確認します
+func (p *openAIProvider) do(ctx context.Context, body openAIChatRequest) (*http.Response, error) {
+	req, err := p.newRequest(ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI互換APIリクエストの作成に失敗しました: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function":  "openAIProvider.do",
+			"modelName": p.cfg.Model,
+			"baseURL":   p.cfg.BaseURL,
+			"error":     err.Error(),
+			"errorType": "OpenAI互換API通信エラー",
+		}).Error("OpenAI互換APIリクエストに失敗しました")
+		return nil, fmt.Errorf("OpenAI互換APIリクエストに失敗しました: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		logrus.WithFields(logrus.Fields{
+			"function":   "openAIProvider.do",
+			"modelName":  p.cfg.Model,
+			"baseURL":    p.cfg.BaseURL,
+			"statusCode": resp.StatusCode,
+			"errorBody":  string(errBody),
+			"errorType":  "OpenAI互換API通信エラー",
+		}).Error("OpenAI互換APIリクエストに失敗しました（詳細）")
+		return nil, fmt.Errorf("OpenAI互換APIリクエストに失敗しました。ステータスコード: %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// Generate Chat Completions APIでテキストを生成します
+func (p *openAIProvider) Generate(ctx context.Context, prompt string) (*LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	resp, err := p.do(ctx, openAIChatRequest{
+		Model:    p.cfg.Model,
+		Messages: []openAIMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("OpenAI互換APIレスポンスの解析に失敗しました: %w", err)
+	}
+	if len(body.Choices) == 0 {
+		return nil, fmt.Errorf("OpenAI互換APIレスポンスに候補がありません")
+	}
+
+	model := body.Model
+	if model == "" {
+		model = p.cfg.Model
+	}
+	return &LLMResponse{
+		Text:         body.Choices[0].Message.Content,
+		Model:        model,
+		FinishReason: body.Choices[0].FinishReason,
+		PromptTokens: body.Usage.PromptTokens,
+		OutputTokens: body.Usage.CompletionTokens,
+		TotalTokens:  body.Usage.TotalTokens,
+	}, nil
+}
+
+// GenerateStream Chat Completions APIのストリーミング（SSE）でテキストを生成します
+func (p *openAIProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(text string) error) (*LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.StreamTimeout)
+	defer cancel()
+
+	resp, err := p.do(ctx, openAIChatRequest{
+		Model:         p.cfg.Model,
+		Messages:      []openAIMessage{{Role: "user", Content: prompt}},
+		Stream:        true,
+		StreamOptions: &openAIStreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 以降のエラーではリクエストが受け付けられているため、途中までの結果も返す
+	result := &LLMResponse{Model: p.cfg.Model}
+	var builder strings.Builder
+	partial := func() *LLMResponse {
+		result.Text = builder.String()
+		return result
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIChatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return partial(), fmt.Errorf("OpenAI互換APIストリームの解析に失敗しました: %w", err)
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.PromptTokens = chunk.Usage.PromptTokens
+			result.OutputTokens = chunk.Usage.CompletionTokens
+			result.TotalTokens = chunk.Usage.TotalTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != nil {
+				result.FinishReason = *choice.FinishReason
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			builder.WriteString(choice.Delta.Content)
+			if err := onChunk(choice.Delta.Content); err != nil {
+				return partial(), err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return partial(), fmt.Errorf("OpenAI互換APIストリームの受信に失敗しました: %w", err)
+	}
+	return partial(), nil
+}
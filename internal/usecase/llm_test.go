@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+)
+
+// stubProvider 決まった応答を返すテスト用のプロバイダー
+type stubProvider struct {
+	name   string
+	chunks []string
+	// streamErr 全チャンクを送った後に返すエラー（partialがtrueの場合は途中までの結果もあわせて返す）
+	streamErr error
+	partial   bool
+	usage     *LLMResponse // 完了時に返す使用量（nilの場合は0）
+
+	prompts []string
+}
+
+func (p *stubProvider) Name() string  { return p.name }
+func (p *stubProvider) Model() string { return p.name + "-model" }
+
+func (p *stubProvider) Generate(ctx context.Context, prompt string) (*LLMResponse, error) {
+	return p.GenerateStream(ctx, prompt, func(string) error { return nil })
+}
+
+func (p *stubProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(text string) error) (*LLMResponse, error) {
+	p.prompts = append(p.prompts, prompt)
+	result := &LLMResponse{Model: p.Model()}
+	if p.usage != nil {
+		*result = *p.usage
+		result.Model = p.Model()
+	}
+	var b strings.Builder
+	for _, chunk := range p.chunks {
+		b.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			result.Text = b.String()
+			return result, err
+		}
+	}
+	result.Text = b.String()
+	if p.streamErr != nil {
+		if p.partial {
+			return result, p.streamErr
+		}
+		return nil, p.streamErr
+	}
+	return result, nil
+}
+
+func TestLLMRouterProvider(t *testing.T) {
+	fast := &stubProvider{name: "fast", chunks: []string{"fast"}}
+	large := &stubProvider{name: "large", chunks: []string{"large"}}
+	router, err := NewLLMRouter(
+		map[string]LLMProvider{"fast": fast, "large": large},
+		map[string]string{"trends": "large"},
+		"fast",
+	)
+	if err != nil {
+		t.Fatalf("NewLLMRouter: %v", err)
+	}
+
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"trends", "large"},
+		{"summarize", "fast"}, // 割り当てのないエンドポイントはデフォルト
+		{"", "fast"},
+	}
+	for _, tt := range tests {
+		if got := router.Provider(tt.endpoint).Name(); got != tt.want {
+			t.Errorf("Provider(%q) = %s, want %s", tt.endpoint, got, tt.want)
+		}
+
+		resp, err := router.Generate(context.Background(), tt.endpoint, "prompt:"+tt.endpoint)
+		if err != nil {
+			t.Fatalf("Generate(%q): %v", tt.endpoint, err)
+		}
+		if resp.Text != tt.want || resp.Model != tt.want+"-model" {
+			t.Errorf("Generate(%q) = %q (%s), want %q", tt.endpoint, resp.Text, resp.Model, tt.want)
+		}
+
+		var chunks []string
+		resp, err = router.GenerateStream(context.Background(), tt.endpoint, "prompt:"+tt.endpoint, func(text string) error {
+			chunks = append(chunks, text)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("GenerateStream(%q): %v", tt.endpoint, err)
+		}
+		if resp.Text != tt.want || strings.Join(chunks, "") != tt.want {
+			t.Errorf("GenerateStream(%q) = %q (chunks %q), want %q", tt.endpoint, resp.Text, chunks, tt.want)
+		}
+	}
+
+	if got := strings.Join(large.prompts, ","); got != "prompt:trends,prompt:trends" {
+		t.Errorf("large prompts = %s", got)
+	}
+	if got := len(fast.prompts); got != 4 {
+		t.Errorf("fast prompts = %d, want 4", got)
+	}
+}
+
+func TestNewLLMRouterValidates(t *testing.T) {
+	providers := map[string]LLMProvider{"fast": &stubProvider{name: "fast"}}
+	tests := []struct {
+		name            string
+		endpoints       map[string]string
+		defaultProvider string
+	}{
+		{"デフォルトのプロバイダーが未定義", nil, "missing"},
+		{"デフォルトのプロバイダーが未指定", nil, ""},
+		{"エンドポイントのプロバイダーが未定義", map[string]string{"trends": "missing"}, "fast"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLLMRouter(providers, tt.endpoints, tt.defaultProvider); err == nil {
+				t.Fatal("err = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNewLLMRouterFromConfig(t *testing.T) {
+	router, err := NewLLMRouterFromConfig(config.LLMConfig{
+		Default: "local",
+		Providers: map[string]config.LLMProviderConfig{
+			"local":  {Type: LLMTypeOllama, Model: "llama3"},
+			"remote": {Type: LLMTypeOpenAI, Model: "gpt-4o-mini"},
+		},
+		Endpoints: map[string]string{"trends": "remote"},
+	})
+	if err != nil {
+		t.Fatalf("NewLLMRouterFromConfig: %v", err)
+	}
+	if got := router.Provider("trends").Model(); got != "gpt-4o-mini" {
+		t.Errorf("trends model = %s, want gpt-4o-mini", got)
+	}
+	if got := router.Provider("summarize").Model(); got != "llama3" {
+		t.Errorf("summarize model = %s, want llama3", got)
+	}
+
+	for name, cfg := range map[string]config.LLMConfig{
+		"typeが不正":   {Default: "x", Providers: map[string]config.LLMProviderConfig{"x": {Type: "unknown", Model: "m"}}},
+		"modelが未設定": {Default: "x", Providers: map[string]config.LLMProviderConfig{"x": {Type: LLMTypeOllama}}},
+	} {
+		if _, err := NewLLMRouterFromConfig(cfg); err == nil {
+			t.Errorf("%s: err = nil, want error", name)
+		}
+	}
+}
+
+func TestLLMRouterGenerateStreamRecordsPartialUsage(t *testing.T) {
+	errDisconnected := errors.New("client disconnected")
+	errUpstream := errors.New("upstream error")
+
+	tests := []struct {
+		name       string
+		provider   *stubProvider
+		onChunkErr error
+		wantErr    error
+		want       *models.AIUsageTotals // nilの場合は記録されない
+	}{
+		{
+			name:     "完了",
+			provider: &stubProvider{name: "stub", chunks: []string{"abc", "def"}, usage: &LLMResponse{PromptTokens: 10, OutputTokens: 20, TotalTokens: 30}},
+			want:     &models.AIUsageTotals{Requests: 1, PromptTokens: 10, OutputTokens: 20, CostUSD: 0.002},
+		},
+		{
+			name:       "クライアントの切断",
+			provider:   &stubProvider{name: "stub", chunks: []string{"abcdef", "ghi"}},
+			onChunkErr: errDisconnected,
+			wantErr:    errDisconnected,
+			// 使用量が返される前に中断したため、プロンプト（9バイト）と生成済みのテキスト（9バイト）から見積もる
+			want: &models.AIUsageTotals{Requests: 1, PromptTokens: 3, OutputTokens: 3, CostUSD: 0.0003},
+		},
+		{
+			name:     "生成の途中でプロバイダーのエラー",
+			provider: &stubProvider{name: "stub", chunks: []string{"abc"}, streamErr: errUpstream, partial: true, usage: &LLMResponse{PromptTokens: 7}},
+			wantErr:  errUpstream,
+			want:     &models.AIUsageTotals{Requests: 1, PromptTokens: 7, OutputTokens: 1, CostUSD: 0.0001},
+		},
+		{
+			name:     "リクエストが受け付けられる前のエラー",
+			provider: &stubProvider{name: "stub", streamErr: errUpstream},
+			wantErr:  errUpstream,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := openTestStore(t)
+			router, err := NewLLMRouter(map[string]LLMProvider{"stub": tt.provider}, nil, "stub")
+			if err != nil {
+				t.Fatalf("NewLLMRouter: %v", err)
+			}
+			router.SetUsageTracker(NewAIUsageTracker(st, config.AIUsageConfig{}, config.LLMConfig{
+				Providers: map[string]config.LLMProviderConfig{"stub": {OutputPricePerMTok: 100}},
+			}))
+
+			// リクエストのコンテキストが終了していても記録する
+			ctx, cancel := context.WithCancel(WithLLMCaller(context.Background(), LLMCaller{UserID: 1, Username: "alice"}))
+			sent := 0
+			_, err = router.GenerateStream(ctx, "summarize", "123456789", func(text string) error {
+				if tt.onChunkErr != nil && sent > 0 {
+					cancel()
+					return tt.onChunkErr
+				}
+				sent++
+				return nil
+			})
+			cancel()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			totals, err := st.SumAIUsage(models.AIUsageQuery{UserID: 1})
+			if err != nil {
+				t.Fatalf("SumAIUsage: %v", err)
+			}
+			want := tt.want
+			if want == nil {
+				want = &models.AIUsageTotals{}
+			}
+			if totals.Requests != want.Requests || totals.PromptTokens != want.PromptTokens || totals.OutputTokens != want.OutputTokens {
+				t.Errorf("totals = %+v, want %+v", totals, *want)
+			}
+			if diff := totals.CostUSD - want.CostUSD; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("CostUSD = %v, want %v", totals.CostUSD, want.CostUSD)
+			}
+		})
+	}
+}
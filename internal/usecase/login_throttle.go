@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"trends-summary/internal/config"
+)
+
+// ログイン失敗を数える対象
+const (
+	LoginThrottleUser = "user"
+	LoginThrottleIP   = "ip"
+)
+
+// LoginLockout 失敗回数の上限を超えて始まったログインの一時停止
+type LoginLockout struct {
+	Scope    string // user / ip
+	Key      string // ユーザー名またはIPアドレス
+	Failures int
+	Duration time.Duration
+}
+
+// LoginThrottle ユーザー名・IPアドレスごとにパスワードログインの失敗回数を数え、
+// 上限を超えたら一定時間ログインを停止します（停止のたびに停止時間は2倍、状態はメモリ上に保持）
+type LoginThrottle struct {
+	cfg config.LoginThrottleConfig
+	now func() time.Time // テストで時刻を差し替えるため
+
+	mu        sync.Mutex
+	entries   map[string]*throttleEntry
+	lastSweep time.Time
+}
+
+type throttleEntry struct {
+	failures    int
+	lockouts    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// NewLoginThrottle ログイン試行の制限を作成します（0の項目は既定値）
+func NewLoginThrottle(cfg config.LoginThrottleConfig) *LoginThrottle {
+	if cfg.MaxFailuresPerUser <= 0 {
+		cfg.MaxFailuresPerUser = 5
+	}
+	if cfg.MaxFailuresPerIP <= 0 {
+		cfg.MaxFailuresPerIP = 20
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 15 * time.Minute
+	}
+	if cfg.BaseLockout <= 0 {
+		cfg.BaseLockout = time.Minute
+	}
+	if cfg.MaxLockout < cfg.BaseLockout {
+		cfg.MaxLockout = cfg.BaseLockout
+	}
+	return &LoginThrottle{
+		cfg:     cfg,
+		now:     time.Now,
+		entries: map[string]*throttleEntry{},
+	}
+}
+
+// RetryAfter ユーザー名またはIPアドレスがログイン停止中の場合、解除までの時間を返します（停止中でなければ0）
+func (t *LoginThrottle) RetryAfter(ip, username string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	var wait time.Duration
+	for _, key := range throttleKeys(ip, username) {
+		if e, ok := t.entries[key]; ok && e.lockedUntil.After(now) {
+			wait = max(wait, e.lockedUntil.Sub(now))
+		}
+	}
+	return wait
+}
+
+// Failure ログイン失敗を記録し、この失敗で新たに始まったログイン停止を返します
+func (t *LoginThrottle) Failure(ip, username string) []LoginLockout {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	var lockouts []LoginLockout
+	for _, key := range throttleKeys(ip, username) {
+		e, ok := t.entries[key]
+		if !ok || now.Sub(e.lastFailure) > t.cfg.Window {
+			e = &throttleEntry{}
+			t.entries[key] = e
+		}
+		e.failures++
+		e.lastFailure = now
+
+		scope, value, _ := strings.Cut(key, ":")
+		limit := t.cfg.MaxFailuresPerUser
+		if scope == LoginThrottleIP {
+			limit = t.cfg.MaxFailuresPerIP
+		}
+		if e.failures < limit {
+			continue
+		}
+
+		// 停止のたびに停止時間を2倍にする
+		duration := t.cfg.BaseLockout
+		for i := 0; i < e.lockouts && duration < t.cfg.MaxLockout; i++ {
+			duration *= 2
+		}
+		duration = min(duration, t.cfg.MaxLockout)
+		lockouts = append(lockouts, LoginLockout{Scope: scope, Key: value, Failures: e.failures, Duration: duration})
+
+		e.lockouts++
+		e.failures = 0
+		e.lockedUntil = now.Add(duration)
+	}
+	return lockouts
+}
+
+// Success ログイン成功時にユーザー名の失敗回数をリセットします
+// IPアドレスの失敗回数は、複数のユーザー名を順に試す攻撃を防ぐためリセットしません
+func (t *LoginThrottle) Success(username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, userThrottleKey(username))
+}
+
+// sweep 失敗の記録が期限切れで停止中でもないエントリを削除します（windowごとに1回）
+func (t *LoginThrottle) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < t.cfg.Window {
+		return
+	}
+	t.lastSweep = now
+	for key, e := range t.entries {
+		if now.Sub(e.lastFailure) > t.cfg.Window && !e.lockedUntil.After(now) {
+			delete(t.entries, key)
+		}
+	}
+}
+
+func throttleKeys(ip, username string) []string {
+	keys := []string{LoginThrottleIP + ":" + ip}
+	if username != "" {
+		keys = append(keys, userThrottleKey(username))
+	}
+	return keys
+}
+
+// userThrottleKey ユーザー名は大文字小文字を区別しないため小文字で数える
+func userThrottleKey(username string) string {
+	return LoginThrottleUser + ":" + strings.ToLower(username)
+}
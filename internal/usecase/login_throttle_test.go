@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"trends-summary/internal/config"
+)
+
+// newTestLoginThrottle 時刻を進められるログイン試行の制限を作成します
+func newTestLoginThrottle(cfg config.LoginThrottleConfig) (*LoginThrottle, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t := NewLoginThrottle(cfg)
+	t.now = func() time.Time { return now }
+	return t, &now
+}
+
+func TestLoginThrottleLockoutDoubles(t *testing.T) {
+	throttle, now := newTestLoginThrottle(config.LoginThrottleConfig{
+		MaxFailuresPerUser: 3,
+		MaxFailuresPerIP:   100,
+		Window:             time.Hour,
+		BaseLockout:        time.Minute,
+		MaxLockout:         5 * time.Minute,
+	})
+
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		var lockouts []LoginLockout
+		for range 3 {
+			lockouts = throttle.Failure("192.0.2.1", "alice")
+		}
+		if len(lockouts) != 1 || lockouts[0].Scope != LoginThrottleUser || lockouts[0].Duration != want {
+			t.Fatalf("lockout %d = %+v, want user lockout for %s", i+1, lockouts, want)
+		}
+		if got := throttle.RetryAfter("192.0.2.1", "ALICE"); got != want {
+			t.Fatalf("RetryAfter after lockout %d = %s, want %s", i+1, got, want)
+		}
+		*now = now.Add(want)
+		if got := throttle.RetryAfter("192.0.2.1", "alice"); got != 0 {
+			t.Fatalf("RetryAfter after lockout %d expired = %s, want 0", i+1, got)
+		}
+	}
+}
+
+func TestLoginThrottleWindowReset(t *testing.T) {
+	throttle, now := newTestLoginThrottle(config.LoginThrottleConfig{
+		MaxFailuresPerUser: 3,
+		MaxFailuresPerIP:   100,
+		Window:             15 * time.Minute,
+		BaseLockout:        time.Minute,
+		MaxLockout:         time.Hour,
+	})
+
+	throttle.Failure("192.0.2.1", "alice")
+	throttle.Failure("192.0.2.1", "alice")
+	// 最後の失敗からwindowが過ぎると回数はリセットされる
+	*now = now.Add(16 * time.Minute)
+	if lockouts := throttle.Failure("192.0.2.1", "alice"); len(lockouts) != 0 {
+		t.Fatalf("lockouts after window = %+v, want none", lockouts)
+	}
+	throttle.Failure("192.0.2.1", "alice")
+	if lockouts := throttle.Failure("192.0.2.1", "alice"); len(lockouts) != 1 {
+		t.Fatalf("lockouts after 3 failures in window = %+v, want 1", lockouts)
+	}
+}
+
+func TestLoginThrottleSuccessResetsUserOnly(t *testing.T) {
+	throttle, _ := newTestLoginThrottle(config.LoginThrottleConfig{
+		MaxFailuresPerUser: 3,
+		MaxFailuresPerIP:   4,
+		Window:             time.Hour,
+		BaseLockout:        time.Minute,
+		MaxLockout:         time.Hour,
+	})
+
+	throttle.Failure("192.0.2.1", "alice")
+	throttle.Failure("192.0.2.1", "alice")
+	throttle.Success("Alice")
+
+	// ユーザー名の回数はリセットされるが、IPアドレスの回数は残る
+	lockouts := throttle.Failure("192.0.2.1", "alice")
+	if len(lockouts) != 0 {
+		t.Fatalf("lockouts = %+v, want none", lockouts)
+	}
+	lockouts = throttle.Failure("192.0.2.1", "bob")
+	if len(lockouts) != 1 || lockouts[0].Scope != LoginThrottleIP || lockouts[0].Key != "192.0.2.1" || lockouts[0].Failures != 4 {
+		t.Fatalf("lockouts = %+v, want ip lockout after 4 failures", lockouts)
+	}
+	if got := throttle.RetryAfter("192.0.2.1", "carol"); got != time.Minute {
+		t.Fatalf("RetryAfter from locked ip = %s, want %s", got, time.Minute)
+	}
+	if got := throttle.RetryAfter("198.51.100.1", "alice"); got != 0 {
+		t.Fatalf("RetryAfter from other ip = %s, want 0", got)
+	}
+}
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// notifyTarget 通知の配信先
+type notifyTarget interface {
+	Name() string
+	Type() string
+	// Accepts 配信先がこの通知を受け取るか判定します
+	Accepts(n models.Notification) bool
+	// Send 通知を1回送信し、HTTPステータスコード（該当しない場合は0）を返します
+	Send(ctx context.Context, n models.Notification) (int, error)
+}
+
+// notifyTargetGroup 通知のたびに配信先を展開する配信先（メールの購読者など）
+type notifyTargetGroup interface {
+	Accepts(n models.Notification) bool
+	Expand(ctx context.Context) ([]notifyTarget, error)
+}
+
+// deliveryError 再試行の可否と待ち時間を持つ送信エラー
+type deliveryError struct {
+	err        error
+	retryable  bool
+	retryAfter time.Duration // 0の場合は指数バックオフに従う
+}
+
+func (e *deliveryError) Error() string { return e.err.Error() }
+func (e *deliveryError) Unwrap() error { return e.err }
+
+// Notifier ダイジェストや新着記事を設定された配信先に送信し、配信ログを保存します
+// nilのNotifierは何も送信しません
+type Notifier struct {
+	store          *store.Store
+	targets        []notifyTarget
+	groups         []notifyTargetGroup
+	maxRetries     int
+	initialBackoff time.Duration
+	timeout        time.Duration
+}
+
+// NewNotifier 設定から配信先を組み立ててNotifierを作成します
+// Webhookはfetcherで送信します（内部アドレスへは allowed_networks で許可した場合のみ送信します）
+func NewNotifier(st *store.Store, cfg config.NotifierConfig, fetcher *Fetcher) (*Notifier, error) {
+	n := &Notifier{
+		store:          st,
+		maxRetries:     cfg.MaxRetries,
+		initialBackoff: cfg.InitialBackoff,
+		timeout:        cfg.Timeout,
+	}
+	if n.maxRetries < 0 {
+		n.maxRetries = 0
+	}
+	if n.initialBackoff <= 0 {
+		n.initialBackoff = 2 * time.Second
+	}
+	if n.timeout <= 0 {
+		n.timeout = 10 * time.Second
+	}
+
+	names := map[string]bool{}
+	for _, w := range cfg.Webhooks {
+		target, err := newWebhookTarget(w, fetcher)
+		if err != nil {
+			return nil, err
+		}
+		if names[target.Name()] {
+			return nil, fmt.Errorf("配信先の名前が重複しています: %s", target.Name())
+		}
+		names[target.Name()] = true
+		n.targets = append(n.targets, target)
+	}
+
+	if cfg.Email.Enabled {
+		group, err := newEmailTargetGroup(st, cfg.Email)
+		if err != nil {
+			return nil, err
+		}
+		n.groups = append(n.groups, group)
+	}
+	return n, nil
+}
+
+// NotifyDigest ダイジェストを配信します
+func (n *Notifier) NotifyDigest(ctx context.Context, digest models.Digest) []models.Delivery {
+	return n.Notify(ctx, models.Notification{Event: models.NotificationEventDigest, Digest: &digest})
+}
+
+// NotifyNewItems フィードの新着記事を配信します
+func (n *Notifier) NotifyNewItems(ctx context.Context, src models.FeedSource, items []models.FeedItem) []models.Delivery {
+	if len(items) == 0 {
+		return nil
+	}
+	return n.Notify(ctx, models.Notification{
+		Event:      models.NotificationEventNewItems,
+		SourceID:   src.ID,
+		SourceName: src.Name,
+		Items:      items,
+	})
+}
+
+// Notify 通知を受け取る全配信先に送信し、配信ログを返します
+func (n *Notifier) Notify(ctx context.Context, notification models.Notification) []models.Delivery {
+	if n == nil {
+		return nil
+	}
+	deliveries := []models.Delivery{}
+	for _, target := range n.targets {
+		if !target.Accepts(notification) {
+			continue
+		}
+		deliveries = append(deliveries, n.deliver(ctx, target, notification))
+	}
+	for _, group := range n.groups {
+		if !group.Accepts(notification) {
+			continue
+		}
+		targets, err := group.Expand(ctx)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "Notifier.Notify",
+				"event":    notification.Event,
+				"error":    err.Error(),
+			}).Error("配信先の取得に失敗しました")
+			continue
+		}
+		for _, target := range targets {
+			deliveries = append(deliveries, n.deliver(ctx, target, notification))
+		}
+	}
+	return deliveries
+}
+
+// deliver 1つの配信先に再試行付きで送信し、結果を配信ログに保存します
+func (n *Notifier) deliver(ctx context.Context, target notifyTarget, notification models.Notification) models.Delivery {
+	delivery := models.Delivery{
+		Target: target.Name(),
+		Type:   target.Type(),
+		Event:  notification.Event,
+		Ref:    notification.Ref(),
+		Status: models.DeliveryStatusFailed,
+	}
+
+	backoff := n.initialBackoff
+	for attempt := 0; attempt <= n.maxRetries; attempt++ {
+		delivery.Attempts = attempt + 1
+
+		sendCtx, cancel := context.WithTimeout(ctx, n.timeout)
+		statusCode, err := target.Send(sendCtx, notification)
+		cancel()
+		delivery.StatusCode = statusCode
+
+		if err == nil {
+			delivery.Status = models.DeliveryStatusSuccess
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = err.Error()
+
+		var de *deliveryError
+		if errors.As(err, &de) && !de.retryable {
+			break
+		}
+		if attempt == n.maxRetries {
+			break
+		}
+
+		wait := backoff
+		if de != nil && de.retryAfter > 0 {
+			wait = de.retryAfter
+		}
+		logrus.WithFields(logrus.Fields{
+			"function": "Notifier.deliver",
+			"target":   target.Name(),
+			"attempt":  delivery.Attempts,
+			"wait":     wait.String(),
+			"error":    err.Error(),
+		}).Warn("通知の送信に失敗したため再試行します")
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		if ctx.Err() != nil {
+			delivery.Error = ctx.Err().Error()
+			break
+		}
+		backoff *= 2
+	}
+
+	delivery.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	if n.store != nil {
+		id, err := n.store.SaveDelivery(delivery)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "Notifier.deliver",
+				"target":   target.Name(),
+				"error":    err.Error(),
+			}).Warn("配信ログの保存に失敗しました")
+		}
+		delivery.ID = id
+	}
+
+	fields := logrus.Fields{
+		"function":   "Notifier.deliver",
+		"target":     target.Name(),
+		"event":      delivery.Event,
+		"ref":        delivery.Ref,
+		"attempts":   delivery.Attempts,
+		"statusCode": delivery.StatusCode,
+	}
+	if delivery.Status == models.DeliveryStatusSuccess {
+		logrus.WithFields(fields).Info("通知を送信しました")
+	} else {
+		fields["error"] = delivery.Error
+		logrus.WithFields(fields).Error("通知の送信に失敗しました")
+	}
+	return delivery
+}
+
+// parseRetryAfter Retry-Afterヘッダー（秒数）を解釈します（最大1分）
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	if seconds > 60 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// containsOrEmpty リストが空、または値を含む場合にtrueを返します
+func containsOrEmpty(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
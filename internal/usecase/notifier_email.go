@@ -0,0 +1,309 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+)
+
+// SMTPの接続方式
+const (
+	EmailSecuritySTARTTLS = "starttls"
+	EmailSecurityTLS      = "tls"
+	EmailSecurityNone     = "none"
+)
+
+// emailTargetGroup 購読者ごとにダイジェストをメールで送る配信先
+type emailTargetGroup struct {
+	store    *store.Store
+	cfg      config.EmailConfig
+	from     *mail.Address
+	password string
+}
+
+func newEmailTargetGroup(st *store.Store, cfg config.EmailConfig) (*emailTargetGroup, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("メール配信にはhostの指定が必要です")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	switch cfg.Security {
+	case "":
+		cfg.Security = EmailSecuritySTARTTLS
+	case EmailSecuritySTARTTLS, EmailSecurityTLS, EmailSecurityNone:
+	default:
+		return nil, fmt.Errorf("メール配信のsecurityはstarttls/tls/noneのいずれかを指定してください: %s", cfg.Security)
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("メール配信には配信停止リンク用のbase_urlの指定が必要です")
+	}
+	if cfg.TopRepos <= 0 {
+		cfg.TopRepos = 10
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("メール配信のfromが不正です: %w", err)
+	}
+
+	g := &emailTargetGroup{
+		store: st,
+		cfg:   cfg,
+		from:  from,
+	}
+	if cfg.PasswordEnv != "" {
+		g.password = os.Getenv(cfg.PasswordEnv)
+	}
+	return g, nil
+}
+
+// Accepts メールはダイジェストのみ配信します
+func (g *emailTargetGroup) Accepts(n models.Notification) bool {
+	return n.Event == models.NotificationEventDigest && n.Digest != nil
+}
+
+// Expand 配信中の購読者ごとの配信先を返します
+func (g *emailTargetGroup) Expand(ctx context.Context) ([]notifyTarget, error) {
+	subscribers, err := g.store.ListSubscribers(true)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]notifyTarget, 0, len(subscribers))
+	for _, sub := range subscribers {
+		targets = append(targets, &emailRecipient{group: g, subscriber: sub})
+	}
+	return targets, nil
+}
+
+// emailRecipient 購読者1人分の配信先
+type emailRecipient struct {
+	group      *emailTargetGroup
+	subscriber models.Subscriber
+}
+
+func (r *emailRecipient) Name() string                       { return "email:" + r.subscriber.Email }
+func (r *emailRecipient) Type() string                       { return "email" }
+func (r *emailRecipient) Accepts(n models.Notification) bool { return r.group.Accepts(n) }
+
+func (r *emailRecipient) Send(ctx context.Context, n models.Notification) (int, error) {
+	msg, err := r.group.buildMessage(*n.Digest, r.subscriber)
+	if err != nil {
+		return 0, &deliveryError{err: err}
+	}
+	if err := r.group.send(ctx, r.subscriber.Email, msg); err != nil {
+		return smtpDeliveryError(err)
+	}
+	return 0, nil
+}
+
+// smtpDeliveryError 送信エラーに応答コードと再試行の可否を付けて返します
+// 接続の失敗・切断と4xx応答（一時的なエラー）のみ再試行し、認証の失敗や5xx応答（宛先不明など）は再試行しません
+func smtpDeliveryError(err error) (int, error) {
+	var de *deliveryError
+	if errors.As(err, &de) {
+		return 0, err
+	}
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		return tpErr.Code, &deliveryError{err: err, retryable: tpErr.Code >= 400 && tpErr.Code < 500}
+	}
+	var netErr net.Error
+	retryable := errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	return 0, &deliveryError{err: err, retryable: retryable}
+}
+
+// unsubscribeURL 購読者の配信停止リンクを返します
+func (g *emailTargetGroup) unsubscribeURL(sub models.Subscriber) string {
+	return strings.TrimRight(g.cfg.BaseURL, "/") + "/api/unsubscribe?token=" + sub.Token
+}
+
+// emailHTMLTemplate ダイジェストメールのHTML本文
+var emailHTMLTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head><meta charset="UTF-8"><title>{{.Title}}</title></head>
+<body style="font-family: sans-serif; line-height: 1.6; color: #222; max-width: 720px; margin: 0 auto;">
+<h1 style="font-size: 20px;">{{.Title}}</h1>
+{{.Summary}}
+{{if .Repos}}
+<h2 style="font-size: 16px;">GitHub daily trends</h2>
+<table style="border-collapse: collapse; width: 100%;">
+{{range .Repos}}<tr>
+<td style="padding: 4px 8px; vertical-align: top;">{{.Rank}}</td>
+<td style="padding: 4px 8px; vertical-align: top;"><a href="https://github.com/{{.FullName}}">{{.FullName}}</a>{{if .Language}} <small>[{{.Language}}]</small>{{end}}<br><small>{{.Description}}</small></td>
+<td style="padding: 4px 8px; vertical-align: top; white-space: nowrap;">★{{.Stars}}</td>
+</tr>{{end}}
+</table>
+{{end}}
+<hr>
+<p style="font-size: 12px; color: #666;">model: {{.Model}} / 配信停止は<a href="{{.UnsubscribeURL}}">こちら</a></p>
+</body>
+</html>
+`))
+
+// buildMessage ダイジェストをHTMLとテキストのmultipart/alternativeメールに変換します
+func (g *emailTargetGroup) buildMessage(digest models.Digest, sub models.Subscriber) ([]byte, error) {
+	title := "トレンドダイジェスト " + digest.Date
+	unsubscribeURL := g.unsubscribeURL(sub)
+
+	var repos []models.TrendingSnapshotEntry
+	if digest.Input != nil {
+		repos = digest.Input.GitHubTrending
+	}
+	if len(repos) > g.cfg.TopRepos {
+		repos = repos[:g.cfg.TopRepos]
+	}
+
+	// テキスト本文
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s\n\n%s\n", title, digest.Summary)
+	if len(repos) > 0 {
+		text.WriteString("\n## GitHub daily trends\n")
+		for _, e := range repos {
+			fmt.Fprintf(&text, "%d. %s [%s] ★%d https://github.com/%s\n   %s\n", e.Rank, e.FullName, e.Language, e.Stars, e.FullName, e.Description)
+		}
+	}
+	fmt.Fprintf(&text, "\n--\nmodel: %s\n配信停止: %s\n", digest.Model, unsubscribeURL)
+
+	// HTML本文
+	summary, err := MarkdownToHTML(digest.Summary)
+	if err != nil {
+		return nil, err
+	}
+	var html bytes.Buffer
+	err = emailHTMLTemplate.Execute(&html, map[string]interface{}{
+		"Title":          title,
+		"Summary":        template.HTML(summary),
+		"Repos":          repos,
+		"Model":          digest.Model,
+		"UnsubscribeURL": unsubscribeURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("メール本文の作成に失敗しました: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", text.String()},
+		{"text/html; charset=UTF-8", html.String()},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("メール本文の作成に失敗しました: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("メール本文の作成に失敗しました: %w", err)
+		}
+		qp.Close()
+	}
+	mw.Close()
+
+	messageID := make([]byte, 16)
+	if _, err := rand.Read(messageID); err != nil {
+		return nil, fmt.Errorf("Message-IDの生成に失敗しました: %w", err)
+	}
+	domain := g.from.Address[strings.LastIndex(g.from.Address, "@")+1:]
+
+	var msg bytes.Buffer
+	headers := []string{
+		"From: " + g.from.String(),
+		"To: " + sub.Email,
+		"Subject: " + mime.QEncoding.Encode("UTF-8", title),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: <" + hex.EncodeToString(messageID) + "@" + domain + ">",
+		"MIME-Version: 1.0",
+		"List-Unsubscribe: <" + unsubscribeURL + ">",
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	for _, h := range headers {
+		msg.WriteString(h + "\r\n")
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// send SMTPサーバーに接続してメールを1通送信します
+func (g *emailTargetGroup) send(ctx context.Context, to string, msg []byte) error {
+	addr := net.JoinHostPort(g.cfg.Host, strconv.Itoa(g.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: g.cfg.Host}
+
+	var conn net.Conn
+	var err error
+	if g.cfg.Security == EmailSecurityTLS {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("SMTPサーバーへの接続に失敗しました: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, g.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTPセッションの開始に失敗しました: %w", err)
+	}
+	defer client.Close()
+
+	if g.cfg.Security == EmailSecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return &deliveryError{err: fmt.Errorf("SMTPサーバーがSTARTTLSに対応していません")}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLSに失敗しました: %w", err)
+		}
+	}
+	if g.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", g.cfg.Username, g.password, g.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP認証に失敗しました: %w", err)
+		}
+	}
+
+	if err := client.Mail(g.from.Address); err != nil {
+		return fmt.Errorf("送信元の指定に失敗しました: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("宛先の指定に失敗しました: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("メール本文の送信開始に失敗しました: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("メール本文の送信に失敗しました: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("メール本文の送信に失敗しました: %w", err)
+	}
+	return client.Quit()
+}
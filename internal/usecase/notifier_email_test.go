@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+)
+
+// smtpSink 受信したメールを保存するテスト用のSMTPサーバー
+// reject に含まれる宛先は550、tempReject に含まれる宛先は451で拒否します
+// authCode が0以外の場合は AUTH PLAIN に対応し、認証にその応答コードを返します
+type smtpSink struct {
+	listener   net.Listener
+	reject     map[string]bool
+	tempReject map[string]bool
+	authCode   int
+
+	mu       sync.Mutex
+	messages map[string]*mail.Message // 宛先ごとの受信メール
+}
+
+func newSMTPSink(t *testing.T, reject ...string) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	sink := &smtpSink{listener: listener, reject: map[string]bool{}, tempReject: map[string]bool{}, messages: map[string]*mail.Message{}}
+	for _, addr := range reject {
+		sink.reject[addr] = true
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return sink
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) message(to string) *mail.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages[to]
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	var rcpt []string
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			if s.authCode != 0 {
+				tp.PrintfLine("250-localhost")
+				tp.PrintfLine("250 AUTH PLAIN")
+				continue
+			}
+			tp.PrintfLine("250 localhost")
+		case "AUTH":
+			tp.PrintfLine("%d authentication failed", s.authCode)
+		case "MAIL":
+			rcpt = nil
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			addr := strings.Trim(strings.TrimPrefix(line[len("RCPT"):], " TO:"), "<>")
+			if s.reject[addr] {
+				tp.PrintfLine("550 no such user")
+				continue
+			}
+			if s.tempReject[addr] {
+				tp.PrintfLine("451 try again later")
+				continue
+			}
+			rcpt = append(rcpt, addr)
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 end with <CRLF>.<CRLF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+			if err != nil {
+				tp.PrintfLine("554 invalid message")
+				continue
+			}
+			s.mu.Lock()
+			for _, addr := range rcpt {
+				s.messages[addr] = msg
+			}
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+// readTextPart multipart/alternative のメールからtext/plainの本文を取り出します
+func readTextPart(t *testing.T, msg *mail.Message) string {
+	t.Helper()
+	boundary := strings.TrimPrefix(msg.Header.Get("Content-Type"), "multipart/alternative; boundary=")
+	mr := multipart.NewReader(msg.Body, boundary)
+	for {
+		part, err := mr.NextRawPart()
+		if err != nil {
+			t.Fatalf("text/plainのパートがありません: %v", err)
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			body, err := io.ReadAll(quotedprintable.NewReader(bufio.NewReader(part)))
+			if err != nil {
+				t.Fatalf("本文の読み込みに失敗しました: %v", err)
+			}
+			return string(body)
+		}
+	}
+}
+
+func TestNotifierEmailDigest(t *testing.T) {
+	sink := newSMTPSink(t, "bounce@example.com")
+	st := openTestStore(t)
+	notifier, err := NewNotifier(st, config.NotifierConfig{
+		MaxRetries: 2,
+		Email: config.EmailConfig{
+			Enabled:  true,
+			Host:     "127.0.0.1",
+			Port:     sink.port(),
+			Security: EmailSecurityNone,
+			From:     "Trends <trends@example.com>",
+			BaseURL:  "https://example.com/trends-summary/",
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	alice, err := st.AddSubscriber("alice@example.com")
+	if err != nil {
+		t.Fatalf("AddSubscriber: %v", err)
+	}
+	if _, err := st.AddSubscriber("bounce@example.com"); err != nil {
+		t.Fatalf("AddSubscriber: %v", err)
+	}
+	stopped, err := st.AddSubscriber("stopped@example.com")
+	if err != nil {
+		t.Fatalf("AddSubscriber: %v", err)
+	}
+	if _, err := st.Unsubscribe(stopped.Token); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	deliveries := notifier.NotifyDigest(context.Background(), models.Digest{
+		Date:    "2024-05-01",
+		Summary: "# 今日のトレンド\n\n- item",
+		Model:   "stub",
+		Input: &models.DigestInput{GitHubTrending: []models.TrendingSnapshotEntry{
+			{Rank: 1, FullName: "owner/repo", Language: "Go", Stars: 10},
+		}},
+	})
+
+	// 配信停止済みの購読者には送らない
+	if len(deliveries) != 2 {
+		t.Fatalf("deliveries = %+v, want 2", deliveries)
+	}
+	status := map[string]models.Delivery{}
+	for _, d := range deliveries {
+		status[d.Target] = d
+	}
+	if d := status["email:alice@example.com"]; d.Status != models.DeliveryStatusSuccess || d.Attempts != 1 {
+		t.Errorf("alice delivery = %+v, want success", d)
+	}
+	// 宛先不明（5xx）は再試行しない
+	if d := status["email:bounce@example.com"]; d.Status != models.DeliveryStatusFailed || d.Attempts != 1 || d.StatusCode != 550 {
+		t.Errorf("bounce delivery = %+v, want failed after 1 attempt with 550", d)
+	}
+	if sink.message("stopped@example.com") != nil {
+		t.Error("配信停止済みの購読者にメールが送信されました")
+	}
+
+	msg := sink.message("alice@example.com")
+	if msg == nil {
+		t.Fatal("alice@example.com にメールが届いていません")
+	}
+	unsubscribeURL := "https://example.com/trends-summary/api/unsubscribe?token=" + alice.Token
+	if got := msg.Header.Get("List-Unsubscribe"); got != "<"+unsubscribeURL+">" {
+		t.Errorf("List-Unsubscribe = %q, want <%s>", got, unsubscribeURL)
+	}
+	if got := msg.Header.Get("List-Unsubscribe-Post"); got != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", got)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != "トレンドダイジェスト 2024-05-01" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	text := readTextPart(t, msg)
+	for _, want := range []string{"# 今日のトレンド", "1. owner/repo [Go] ★10", "配信停止: " + unsubscribeURL} {
+		if !strings.Contains(text, want) {
+			t.Errorf("本文に %q が含まれていません\n%s", want, text)
+		}
+	}
+
+	// 配信ログが保存されている
+	logs, err := st.ListDeliveries("", 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("配信ログ = %d件, want 2", len(logs))
+	}
+
+	// 配信停止リンクの購読者は次回から送信対象外になる
+	if _, err := st.Unsubscribe(alice.Token); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if deliveries := notifier.NotifyDigest(context.Background(), models.Digest{Date: "2024-05-02"}); len(deliveries) != 1 {
+		t.Errorf("配信停止後の deliveries = %+v, want bounce only", deliveries)
+	}
+}
+
+func TestNotifierEmailRetry(t *testing.T) {
+	// 接続できないポート
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	tests := []struct {
+		name         string
+		setup        func(sink *smtpSink) int // 接続先のポートを返す
+		username     string
+		wantAttempts int
+		wantCode     int
+	}{
+		{
+			name:         "4xx応答は再試行する",
+			setup:        func(sink *smtpSink) int { sink.tempReject["alice@example.com"] = true; return sink.port() },
+			wantAttempts: 3,
+			wantCode:     451,
+		},
+		{
+			name:         "認証の失敗は再試行しない",
+			setup:        func(sink *smtpSink) int { sink.authCode = 535; return sink.port() },
+			username:     "user",
+			wantAttempts: 1,
+			wantCode:     535,
+		},
+		{
+			name:         "接続の失敗は再試行する",
+			setup:        func(sink *smtpSink) int { return closedPort },
+			wantAttempts: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newSMTPSink(t)
+			port := tt.setup(sink)
+			st := openTestStore(t)
+			notifier, err := NewNotifier(st, config.NotifierConfig{
+				MaxRetries:     2,
+				InitialBackoff: time.Millisecond,
+				Email: config.EmailConfig{
+					Enabled:  true,
+					Host:     "127.0.0.1",
+					Port:     port,
+					Security: EmailSecurityNone,
+					Username: tt.username,
+					From:     "trends@example.com",
+					BaseURL:  "https://example.com/trends-summary/",
+				},
+			}, nil)
+			if err != nil {
+				t.Fatalf("NewNotifier: %v", err)
+			}
+			if _, err := st.AddSubscriber("alice@example.com"); err != nil {
+				t.Fatalf("AddSubscriber: %v", err)
+			}
+
+			deliveries := notifier.NotifyDigest(context.Background(), models.Digest{Date: "2024-05-01", Summary: "summary"})
+			if len(deliveries) != 1 {
+				t.Fatalf("deliveries = %+v, want 1", deliveries)
+			}
+			d := deliveries[0]
+			if d.Status != models.DeliveryStatusFailed || d.Attempts != tt.wantAttempts || d.StatusCode != tt.wantCode {
+				t.Errorf("delivery = %+v, want failed after %d attempts with %d", d, tt.wantAttempts, tt.wantCode)
+			}
+		})
+	}
+}
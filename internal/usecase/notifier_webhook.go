@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+)
+
+// Webhookの形式
+const (
+	WebhookTypeSlack   = "slack"
+	WebhookTypeDiscord = "discord"
+	WebhookTypeJSON    = "json"
+)
+
+// メッセージの上限（各サービスの制限より少し小さくしています）
+const (
+	slackSectionLength    = 2900 // section ブロックの text は3000文字まで
+	slackMaxBlocks        = 45   // 1メッセージ50ブロックまで
+	discordDescLength     = 4000 // embed の description は4096文字まで
+	discordMaxEmbeds      = 10
+	notifyItemDescLength  = 200
+	notifyMaxItemsPerPost = 20
+)
+
+// webhookTarget Webhookの配信先
+type webhookTarget struct {
+	cfg     config.WebhookConfig
+	url     string
+	fetcher *Fetcher
+}
+
+func newWebhookTarget(cfg config.WebhookConfig, fetcher *Fetcher) (*webhookTarget, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("Webhookの配信先にnameが必要です")
+	}
+	switch cfg.Type {
+	case WebhookTypeSlack, WebhookTypeDiscord, WebhookTypeJSON:
+	default:
+		return nil, fmt.Errorf("Webhook %s のtypeはslack/discord/jsonのいずれかを指定してください: %s", cfg.Name, cfg.Type)
+	}
+	for _, event := range cfg.Events {
+		if event != models.NotificationEventDigest && event != models.NotificationEventNewItems {
+			return nil, fmt.Errorf("Webhook %s のeventsはdigest/new_itemsのいずれかを指定してください: %s", cfg.Name, event)
+		}
+	}
+
+	url := cfg.URL
+	if cfg.URLEnv != "" {
+		url = os.Getenv(cfg.URLEnv)
+	}
+	if url == "" {
+		return nil, fmt.Errorf("Webhook %s のURLが設定されていません（url または url_env）", cfg.Name)
+	}
+
+	return &webhookTarget{
+		cfg: cfg,
+		url: url,
+		// 内部アドレスへの送信を防ぐため、外部コンテンツの取得と同じフェッチャーで送信する
+		fetcher: fetcher,
+	}, nil
+}
+
+func (t *webhookTarget) Name() string { return t.cfg.Name }
+func (t *webhookTarget) Type() string { return t.cfg.Type }
+
+func (t *webhookTarget) Accepts(n models.Notification) bool {
+	if !containsOrEmpty(t.cfg.Events, n.Event) {
+		return false
+	}
+	if n.Event == models.NotificationEventNewItems {
+		return containsOrEmpty(t.cfg.Sources, n.SourceID)
+	}
+	return true
+}
+
+func (t *webhookTarget) Send(ctx context.Context, n models.Notification) (int, error) {
+	var payload interface{}
+	switch t.cfg.Type {
+	case WebhookTypeSlack:
+		payload = slackPayload(n)
+	case WebhookTypeDiscord:
+		payload = discordPayload(n)
+	default:
+		if n.Digest != nil {
+			// 元データは送らない
+			digest := *n.Digest
+			digest.Input = nil
+			n.Digest = &digest
+		}
+		payload = n
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, &deliveryError{err: fmt.Errorf("ペイロードのエンコードに失敗しました: %w", err)}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, &deliveryError{err: fmt.Errorf("リクエストの作成に失敗しました: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "trends-summary-notifier")
+
+	resp, err := t.fetcher.Do(req)
+	if err != nil {
+		// 許可されていない送信先は再試行しても送信できない
+		return 0, &deliveryError{err: fmt.Errorf("Webhookの送信に失敗しました: %w", err), retryable: !errors.Is(err, ErrFetchNotAllowed)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return resp.StatusCode, &deliveryError{
+		err:        fmt.Errorf("Webhookがエラーを返しました: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(respBody))),
+		retryable:  resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// notificationTitle 通知の見出しを返します
+func notificationTitle(n models.Notification) string {
+	if n.Digest != nil {
+		return "トレンドダイジェスト " + n.Digest.Date
+	}
+	name := n.SourceName
+	if name == "" {
+		name = n.SourceID
+	}
+	return fmt.Sprintf("%s の新着記事（%d件）", name, len(n.Items))
+}
+
+// slackPayload Slack Block Kit形式のメッセージを作成します
+func slackPayload(n models.Notification) map[string]interface{} {
+	title := notificationTitle(n)
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]string{"type": "plain_text", "text": truncateRunes(title, 150)}},
+	}
+	section := func(text string) map[string]interface{} {
+		return map[string]interface{}{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": text}}
+	}
+
+	if n.Digest != nil {
+		for _, chunk := range splitRunes(markdownToSlack(slackEscaper.Replace(n.Digest.Summary)), slackSectionLength) {
+			if len(blocks) >= slackMaxBlocks {
+				break
+			}
+			blocks = append(blocks, section(chunk))
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "context",
+			"elements": []map[string]string{
+				{"type": "mrkdwn", "text": fmt.Sprintf("model: %s / sources: %s", n.Digest.Model, strings.Join(n.Digest.Sources, ", "))},
+			},
+		})
+	}
+	for i, item := range n.Items {
+		if i >= notifyMaxItemsPerPost {
+			break
+		}
+		text := "*" + slackLink(item.Link, item.Title) + "*"
+		if desc := truncateRunes(htmlToText(item.Description), notifyItemDescLength); desc != "" {
+			text += "\n" + slackEscaper.Replace(desc)
+		}
+		blocks = append(blocks, section(text))
+	}
+
+	return map[string]interface{}{
+		"text":   title, // 通知プレビュー用
+		"blocks": blocks,
+	}
+}
+
+// discordPayload Discordのembeds形式のメッセージを作成します
+func discordPayload(n models.Notification) map[string]interface{} {
+	title := notificationTitle(n)
+	embeds := []map[string]interface{}{}
+
+	if n.Digest != nil {
+		embeds = append(embeds, map[string]interface{}{
+			"title":       title,
+			"description": truncateRunes(n.Digest.Summary, discordDescLength),
+			"timestamp":   n.Digest.GeneratedAt,
+			"footer":      map[string]string{"text": "model: " + n.Digest.Model},
+		})
+	}
+	for i, item := range n.Items {
+		if i >= discordMaxEmbeds {
+			break
+		}
+		embeds = append(embeds, map[string]interface{}{
+			"title":       truncateRunes(item.Title, 250),
+			"url":         item.Link,
+			"description": truncateRunes(htmlToText(item.Description), notifyItemDescLength),
+		})
+	}
+
+	payload := map[string]interface{}{"embeds": embeds}
+	if n.Digest == nil {
+		payload["content"] = title
+	}
+	return payload
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	markdownBold    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownLink    = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+	markdownBullet  = regexp.MustCompile(`(?m)^(\s*)[-*]\s+`)
+
+	// slackEscaper mrkdwnで制御文字として扱われる記号をエスケープします
+	slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	// slackURLEscaper リンク先とテキストの区切りとして扱われる "|" をパーセントエンコードします
+	slackURLEscaper = strings.NewReplacer("|", "%7C")
+)
+
+// markdownToSlack LLMが返すMarkdownをSlackのmrkdwn記法に変換します
+func markdownToSlack(s string) string {
+	s = markdownBullet.ReplaceAllString(s, "$1• ")
+	s = markdownHeading.ReplaceAllStringFunc(s, func(line string) string {
+		heading := markdownHeading.FindStringSubmatch(line)[1]
+		return "*" + strings.Trim(heading, "* ") + "*"
+	})
+	s = markdownBold.ReplaceAllString(s, "*$1*")
+	s = markdownLink.ReplaceAllStringFunc(s, func(link string) string {
+		m := markdownLink.FindStringSubmatch(link)
+		return "<" + slackURLEscaper.Replace(m[2]) + "|" + m[1] + ">"
+	})
+	return s
+}
+
+// slackLink リンク先と表示テキストをエスケープしてmrkdwnのリンクを作成します（リンク先がない場合はテキストのみ）
+func slackLink(link, text string) string {
+	text = slackEscaper.Replace(text)
+	if link == "" {
+		return text
+	}
+	return "<" + slackURLEscaper.Replace(slackEscaper.Replace(link)) + "|" + text + ">"
+}
+
+// splitRunes 文字数がmax以下になるよう、できるだけ改行位置で分割します
+func splitRunes(s string, max int) []string {
+	var chunks []string
+	runes := []rune(s)
+	for len(runes) > max {
+		cut := max
+		for i := max; i > max/2; i-- {
+			if runes[i] == '\n' {
+				cut = i
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), "\n"))
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
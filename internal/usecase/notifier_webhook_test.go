@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+)
+
+// webhookServer 決まった順にステータスコードを返し、受信したペイロードを保存するテスト用のWebhook
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int // 先頭から順に返す（使い切った後は200）
+	payloads []map[string]interface{}
+	times    []time.Time
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.payloads = append(s.payloads, payload)
+		s.times = append(s.times, time.Now())
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+		w.Write([]byte("status " + http.StatusText(status)))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) received() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.payloads...)
+}
+
+// newTestNotifier ローカルのWebhookに送信できるフェッチャーでNotifierを作成します
+func newTestNotifier(t *testing.T, webhooks ...config.WebhookConfig) (*Notifier, *store.Store) {
+	t.Helper()
+	fetcher, err := NewFetcher(config.FetcherConfig{AllowedNetworks: []string{"127.0.0.1/32"}})
+	if err != nil {
+		t.Fatalf("NewFetcher: %v", err)
+	}
+	st := openTestStore(t)
+	notifier, err := NewNotifier(st, config.NotifierConfig{
+		MaxRetries:     2,
+		InitialBackoff: 20 * time.Millisecond,
+		Timeout:        5 * time.Second,
+		Webhooks:       webhooks,
+	}, fetcher)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	return notifier, st
+}
+
+// marshalPayload 比較しやすいよう、HTMLの記号をエスケープせずにJSONへ変換します
+func marshalPayload(t *testing.T, payload map[string]interface{}) string {
+	t.Helper()
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(payload); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return b.String()
+}
+
+var testDigest = models.Digest{
+	Date:        "2024-05-01",
+	Sources:     []string{"hn", "github"},
+	Summary:     "## 注目\n\n- **Go** 1.23 & [release](https://go.dev/doc/go1.23?a=1|b)",
+	Model:       "stub-model",
+	GeneratedAt: "2024-05-01T09:00:00Z",
+	Input:       &models.DigestInput{},
+}
+
+var testItems = []models.FeedItem{
+	{Title: "A <b> & C", Link: "https://example.com/a?x=1&y=<2>|3", Description: "<p>desc</p>"},
+	{Title: "No link"},
+}
+
+func TestWebhookPayloads(t *testing.T) {
+	slack := newWebhookServer(t)
+	discord := newWebhookServer(t)
+	generic := newWebhookServer(t)
+	notifier, st := newTestNotifier(t,
+		config.WebhookConfig{Name: "slack", Type: WebhookTypeSlack, URL: slack.URL},
+		config.WebhookConfig{Name: "discord", Type: WebhookTypeDiscord, URL: discord.URL},
+		config.WebhookConfig{Name: "json", Type: WebhookTypeJSON, URL: generic.URL, Events: []string{models.NotificationEventDigest}},
+	)
+
+	deliveries := notifier.NotifyDigest(context.Background(), testDigest)
+	deliveries = append(deliveries, notifier.NotifyNewItems(context.Background(), models.FeedSource{ID: "hn", Name: "Hacker News"}, testItems)...)
+	// jsonは digest のみを受け取る
+	if len(deliveries) != 5 {
+		t.Fatalf("deliveries = %+v, want 5", deliveries)
+	}
+	for _, d := range deliveries {
+		if d.Status != models.DeliveryStatusSuccess || d.Attempts != 1 || d.StatusCode != http.StatusOK {
+			t.Errorf("delivery = %+v, want success", d)
+		}
+	}
+
+	// Slack: mrkdwnに変換し、記号とリンクをエスケープする
+	payloads := slack.received()
+	if len(payloads) != 2 {
+		t.Fatalf("slack payloads = %d, want 2", len(payloads))
+	}
+	digestJSON := marshalPayload(t, payloads[0])
+	for _, want := range []string{
+		`"text":"トレンドダイジェスト 2024-05-01"`,
+		`*注目*`,
+		`• *Go* 1.23 &amp; <https://go.dev/doc/go1.23?a=1%7Cb|release>`,
+		`model: stub-model / sources: hn, github`,
+	} {
+		if !strings.Contains(digestJSON, want) {
+			t.Errorf("slack digest payload does not contain %s\n%s", want, digestJSON)
+		}
+	}
+	itemsJSON := marshalPayload(t, payloads[1])
+	for _, want := range []string{
+		`Hacker News の新着記事（2件）`,
+		`*<https://example.com/a?x=1&amp;y=&lt;2&gt;%7C3|A &lt;b&gt; &amp; C>*\ndesc`,
+		`"text":"*No link*"`,
+	} {
+		if !strings.Contains(itemsJSON, want) {
+			t.Errorf("slack items payload does not contain %s\n%s", want, itemsJSON)
+		}
+	}
+
+	// Discord: embedsで送る
+	payloads = discord.received()
+	if len(payloads) != 2 {
+		t.Fatalf("discord payloads = %d, want 2", len(payloads))
+	}
+	embeds := payloads[0]["embeds"].([]interface{})
+	if embed := embeds[0].(map[string]interface{}); embed["title"] != "トレンドダイジェスト 2024-05-01" || embed["description"] != testDigest.Summary {
+		t.Errorf("discord digest embed = %+v", embed)
+	}
+	if payloads[1]["content"] != "Hacker News の新着記事（2件）" {
+		t.Errorf("discord items content = %v", payloads[1]["content"])
+	}
+	embeds = payloads[1]["embeds"].([]interface{})
+	if embed := embeds[0].(map[string]interface{}); len(embeds) != 2 || embed["url"] != testItems[0].Link || embed["description"] != "desc" {
+		t.Errorf("discord items embeds = %+v", embeds)
+	}
+
+	// JSON: 通知をそのまま送る（ダイジェストの元データは除く）
+	payloads = generic.received()
+	if len(payloads) != 1 {
+		t.Fatalf("json payloads = %d, want 1", len(payloads))
+	}
+	digest := payloads[0]["digest"].(map[string]interface{})
+	if payloads[0]["event"] != models.NotificationEventDigest || digest["summary"] != testDigest.Summary {
+		t.Errorf("json payload = %+v", payloads[0])
+	}
+	if _, ok := digest["input"]; ok {
+		t.Error("json payload contains digest input")
+	}
+
+	if saved, err := st.ListDeliveries("", 10); err != nil || len(saved) != 5 {
+		t.Errorf("配信ログ = %d件 (%v), want 5", len(saved), err)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantStatus   string
+		wantAttempts int
+		wantCode     int
+	}{
+		{"5xxと429は再試行する", []int{503, 429}, models.DeliveryStatusSuccess, 3, 200},
+		{"再試行の上限で失敗する", []int{500, 502, 500, 500}, models.DeliveryStatusFailed, 3, 500},
+		{"4xxは再試行しない", []int{400}, models.DeliveryStatusFailed, 1, 400},
+		{"404は再試行しない", []int{404}, models.DeliveryStatusFailed, 1, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebhookServer(t, tt.statuses...)
+			notifier, st := newTestNotifier(t, config.WebhookConfig{Name: "hook", Type: WebhookTypeJSON, URL: server.URL})
+
+			deliveries := notifier.NotifyDigest(context.Background(), testDigest)
+			if len(deliveries) != 1 {
+				t.Fatalf("deliveries = %+v, want 1", deliveries)
+			}
+			d := deliveries[0]
+			if d.Status != tt.wantStatus || d.Attempts != tt.wantAttempts || d.StatusCode != tt.wantCode {
+				t.Errorf("delivery = %+v, want %s after %d attempts with %d", d, tt.wantStatus, tt.wantAttempts, tt.wantCode)
+			}
+			if tt.wantStatus == models.DeliveryStatusFailed && !strings.Contains(d.Error, "status=") {
+				t.Errorf("Error = %q, want status in error", d.Error)
+			}
+			if got := len(server.received()); got != tt.wantAttempts {
+				t.Errorf("requests = %d, want %d", got, tt.wantAttempts)
+			}
+
+			// 再試行の間隔は2倍ずつ増える（20ms → 40ms）
+			server.mu.Lock()
+			for i := 1; i < len(server.times); i++ {
+				want := 20 * time.Millisecond << (i - 1)
+				if gap := server.times[i].Sub(server.times[i-1]); gap < want {
+					t.Errorf("retry %d after %s, want >= %s", i, gap, want)
+				}
+			}
+			server.mu.Unlock()
+
+			saved, err := st.ListDeliveries("hook", 10)
+			if err != nil {
+				t.Fatalf("ListDeliveries: %v", err)
+			}
+			if len(saved) != 1 || saved[0].Status != tt.wantStatus || saved[0].Attempts != tt.wantAttempts || saved[0].Ref != testDigest.Date {
+				t.Errorf("配信ログ = %+v", saved)
+			}
+		})
+	}
+}
+
+func TestWebhookBlockedAddress(t *testing.T) {
+	server := newWebhookServer(t)
+	fetcher, err := NewFetcher(config.FetcherConfig{})
+	if err != nil {
+		t.Fatalf("NewFetcher: %v", err)
+	}
+	notifier, err := NewNotifier(nil, config.NotifierConfig{
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+		Webhooks:       []config.WebhookConfig{{Name: "hook", Type: WebhookTypeJSON, URL: server.URL}},
+	}, fetcher)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	// 内部アドレスへは送信せず、再試行もしない
+	deliveries := notifier.NotifyDigest(context.Background(), testDigest)
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliveryStatusFailed || deliveries[0].Attempts != 1 {
+		t.Fatalf("deliveries = %+v, want failed after 1 attempt", deliveries)
+	}
+	if got := len(server.received()); got != 0 {
+		t.Errorf("requests = %d, want 0", got)
+	}
+}
+
+func TestWebhookAccepts(t *testing.T) {
+	notifier, _ := newTestNotifier(t,
+		config.WebhookConfig{Name: "digest", Type: WebhookTypeJSON, URL: "http://127.0.0.1/", Events: []string{models.NotificationEventDigest}},
+		config.WebhookConfig{Name: "hn", Type: WebhookTypeJSON, URL: "http://127.0.0.1/", Events: []string{models.NotificationEventNewItems}, Sources: []string{"hn"}},
+		config.WebhookConfig{Name: "all", Type: WebhookTypeJSON, URL: "http://127.0.0.1/"},
+	)
+	tests := []struct {
+		notification models.Notification
+		want         string
+	}{
+		{models.Notification{Event: models.NotificationEventDigest, Digest: &testDigest}, "digest,all"},
+		{models.Notification{Event: models.NotificationEventNewItems, SourceID: "hn"}, "hn,all"},
+		{models.Notification{Event: models.NotificationEventNewItems, SourceID: "aws"}, "all"},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, target := range notifier.targets {
+			if target.Accepts(tt.notification) {
+				names = append(names, target.Name())
+			}
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Errorf("%s/%s: targets = %s, want %s", tt.notification.Event, tt.notification.SourceID, got, tt.want)
+		}
+	}
+}
+
+func TestNewWebhookTargetValidates(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.WebhookConfig
+	}{
+		{"nameが空", config.WebhookConfig{Type: WebhookTypeSlack, URL: "https://example.com"}},
+		{"typeが不正", config.WebhookConfig{Name: "a", Type: "teams", URL: "https://example.com"}},
+		{"eventsが不正", config.WebhookConfig{Name: "a", Type: WebhookTypeSlack, URL: "https://example.com", Events: []string{"other"}}},
+		{"URLが未設定", config.WebhookConfig{Name: "a", Type: WebhookTypeSlack, URLEnv: "TEST_UNSET_WEBHOOK_URL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newWebhookTarget(tt.cfg, nil); err == nil {
+				t.Fatal("err = nil, want error")
+			}
+		})
+	}
+
+	// 配信先の名前は重複できない
+	_, err := NewNotifier(nil, config.NotifierConfig{Webhooks: []config.WebhookConfig{
+		{Name: "a", Type: WebhookTypeSlack, URL: "https://example.com/1"},
+		{Name: "a", Type: WebhookTypeDiscord, URL: "https://example.com/2"},
+	}}, nil)
+	if err == nil {
+		t.Error("重複した名前: err = nil, want error")
+	}
+}
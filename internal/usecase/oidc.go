@@ -0,0 +1,343 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// oidcHTTPTimeout IdPへのリクエスト（ディスカバリー・トークン交換・鍵の取得）のタイムアウト
+const oidcHTTPTimeout = 10 * time.Second
+
+var (
+	// ErrOIDCInvalidState ログイン開始時のstateと一致しない・期限切れの場合のエラー
+	ErrOIDCInvalidState = errors.New("ログイン要求が無効か期限切れです。もう一度ログインしてください")
+	// ErrOIDCNotAllowed 許可されたドメイン・グループに該当しない場合のエラー
+	ErrOIDCNotAllowed = errors.New("このアカウントにはログインが許可されていません")
+	// ErrOIDCUserNotFound 対応するローカルユーザーが存在しない場合のエラー（auto_create: false）
+	ErrOIDCUserNotFound = errors.New("このアカウントに対応するユーザーが登録されていません")
+)
+
+// OIDCIdentity IDトークン（とUserInfo）から取り出したIdPのアカウント情報
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string // username_claim の値
+	Groups        []string
+}
+
+// OIDCClient OIDCの認可コードフロー（PKCE）でログインするクライアント
+// IdPのディスカバリーは初回のログイン時に行い、成功するまで毎回再試行します
+type OIDCClient struct {
+	cfg          config.OIDCConfig
+	clientSecret string
+	httpClient   *http.Client
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// NewOIDCClient 設定を検証してOIDCクライアントを作成します
+func NewOIDCClient(cfg config.OIDCConfig) (*OIDCClient, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDCにはissuer・client_id・redirect_urlの指定が必要です")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "SSO"
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "email"
+	}
+	switch {
+	case cfg.DefaultRole == "":
+		cfg.DefaultRole = models.RoleViewer
+	case !models.ValidRole(cfg.DefaultRole):
+		return nil, fmt.Errorf("OIDCのdefault_roleは %s のいずれかを指定してください: %s", strings.Join(models.Roles, ", "), cfg.DefaultRole)
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if len(cfg.Scopes) == 0 || !containsOrEmpty(cfg.Scopes, oidc.ScopeOpenID) {
+		cfg.Scopes = append([]string{oidc.ScopeOpenID}, cfg.Scopes...)
+	}
+	for i, domain := range cfg.AllowedDomains {
+		cfg.AllowedDomains[i] = strings.ToLower(strings.TrimPrefix(domain, "@"))
+	}
+
+	c := &OIDCClient{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: oidcHTTPTimeout},
+	}
+	if cfg.ClientSecretEnv != "" {
+		c.clientSecret = os.Getenv(cfg.ClientSecretEnv)
+	}
+	return c, nil
+}
+
+// Name ログイン画面に表示するIdPの名前を返します
+func (c *OIDCClient) Name() string {
+	return c.cfg.Name
+}
+
+// Begin ログインを開始し、IdPの認可URLと、コールバックまでブラウザに保持させる値を返します
+// 保持させる値は "state.nonce.code_verifier" の形式です
+func (c *OIDCClient) Begin() (string, string, error) {
+	conf, _, err := c.oauth2Config()
+	if err != nil {
+		return "", "", err
+	}
+	state, err := randomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL := conf.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	return authURL, state + "." + nonce + "." + verifier, nil
+}
+
+// Finish コールバックのstateを検証して認可コードをトークンに交換し、IDトークンのアカウント情報を返します
+func (c *OIDCClient) Finish(ctx context.Context, saved, state, code string) (OIDCIdentity, error) {
+	parts := strings.Split(saved, ".")
+	if len(parts) != 3 || state == "" || code == "" || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(state)) != 1 {
+		return OIDCIdentity{}, ErrOIDCInvalidState
+	}
+	nonce, verifier := parts[1], parts[2]
+
+	conf, provider, err := c.oauth2Config()
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	ctx = oidc.ClientContext(ctx, c.httpClient)
+	token, err := conf.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("認可コードの交換に失敗しました: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return OIDCIdentity{}, fmt.Errorf("トークンレスポンスにIDトークンが含まれていません")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: c.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("IDトークンの検証に失敗しました: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return OIDCIdentity{}, fmt.Errorf("IDトークンのnonceが一致しません")
+	}
+
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return OIDCIdentity{}, fmt.Errorf("IDトークンのクレームの読み込みに失敗しました: %w", err)
+	}
+	// IDトークンに含めないIdPもあるため、足りないクレームはUserInfoから補う
+	if _, ok := claims[c.cfg.UsernameClaim]; !ok && provider.UserInfoEndpoint() != "" {
+		if err := c.mergeUserInfo(ctx, provider, token, idToken.Subject, claims); err != nil {
+			return OIDCIdentity{}, err
+		}
+	}
+
+	identity := OIDCIdentity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claimString(claims, "email"),
+		EmailVerified: claimBool(claims, "email_verified"),
+		Username:      claimString(claims, c.cfg.UsernameClaim),
+		Groups:        claimStrings(claims, c.cfg.GroupsClaim),
+	}
+	if c.cfg.UsernameClaim == "email" {
+		identity.Username = strings.ToLower(identity.Username)
+	}
+	return identity, nil
+}
+
+// ResolveUser IdPのアカウントを許可設定で確認し、対応するローカルユーザーを返します
+// 紐付け済みでなければ、確認済みのメールアドレスと同名の既存ユーザーにのみ紐付けます
+// （preferred_username などIdPの利用者が変更できるクレームでは既存ユーザーに紐付けません）
+// 該当するユーザーがいない場合、auto_create であれば username_claim の値で作成します
+func (c *OIDCClient) ResolveUser(st *store.Store, identity OIDCIdentity) (*models.User, error) {
+	if err := c.checkAllowed(identity); err != nil {
+		return nil, err
+	}
+
+	user, err := st.GetUserByIdentity(identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil && identity.EmailVerified && identity.Email != "" {
+		user, err = st.GetUserByUsername(strings.ToLower(identity.Email))
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "ResolveUser",
+				"username": user.Username,
+				"issuer":   identity.Issuer,
+			}).Info("確認済みのメールアドレスでOIDCのアカウントを既存のユーザーに紐付けます")
+		}
+	}
+	if user == nil {
+		if !c.cfg.AutoCreate {
+			return nil, ErrOIDCUserNotFound
+		}
+		if c.cfg.UsernameClaim == "email" && !identity.EmailVerified {
+			return nil, fmt.Errorf("%w: メールアドレスが確認されていません", ErrOIDCNotAllowed)
+		}
+		if err := ValidateUsername(identity.Username); err != nil {
+			return nil, fmt.Errorf("%w: %s の値をユーザー名に使用できません", ErrOIDCNotAllowed, c.cfg.UsernameClaim)
+		}
+		// パスワードは設定しない（パスワードでのログインはできない）
+		created, err := st.CreateUser(identity.Username, "", c.cfg.DefaultRole)
+		if errors.Is(err, store.ErrUserExists) {
+			// 同名の既存ユーザーには紐付けない（管理者がユーザー名をメールアドレスに変更するなどで対応）
+			return nil, fmt.Errorf("%w: ユーザー名 %s は既に使用されています", ErrOIDCNotAllowed, identity.Username)
+		}
+		if err != nil {
+			return nil, err
+		}
+		user = &created
+		logrus.WithFields(logrus.Fields{
+			"function": "ResolveUser",
+			"username": user.Username,
+			"role":     user.Role,
+			"issuer":   identity.Issuer,
+		}).Info("OIDCの初回ログインでユーザーを作成しました")
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	if err := st.LinkUserIdentity(user.ID, identity.Issuer, identity.Subject, identity.Email); err != nil {
+		return nil, err
+	}
+	if err := st.TouchUserLogin(user.ID); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "ResolveUser",
+			"userID":   user.ID,
+			"error":    err.Error(),
+		}).Warn("最終ログイン日時の更新に失敗しました")
+	}
+	return user, nil
+}
+
+// checkAllowed 許可されたメールドメイン・グループに該当するか確認します
+func (c *OIDCClient) checkAllowed(identity OIDCIdentity) error {
+	if len(c.cfg.AllowedDomains) > 0 {
+		at := strings.LastIndex(identity.Email, "@")
+		if at < 0 || !identity.EmailVerified {
+			return fmt.Errorf("%w: 確認済みのメールアドレスがありません", ErrOIDCNotAllowed)
+		}
+		domain := strings.ToLower(identity.Email[at+1:])
+		if !containsOrEmpty(c.cfg.AllowedDomains, domain) {
+			return fmt.Errorf("%w: ドメイン %s", ErrOIDCNotAllowed, domain)
+		}
+	}
+	if len(c.cfg.AllowedGroups) > 0 {
+		for _, group := range identity.Groups {
+			if containsOrEmpty(c.cfg.AllowedGroups, group) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: 許可されたグループに所属していません", ErrOIDCNotAllowed)
+	}
+	return nil
+}
+
+// oauth2Config IdPのディスカバリーを行い（初回のみ）、OAuth2の設定を返します
+func (c *OIDCClient) oauth2Config() (*oauth2.Config, *oidc.Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider == nil {
+		// 鍵セットの取得にも使われるため、リクエストのcontextではなく無期限のcontextを渡す
+		ctx := oidc.ClientContext(context.Background(), c.httpClient)
+		provider, err := oidc.NewProvider(ctx, c.cfg.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("OIDCプロバイダーの情報の取得に失敗しました: %w", err)
+		}
+		c.provider = provider
+	}
+
+	return &oauth2.Config{
+		ClientID:     c.cfg.ClientID,
+		ClientSecret: c.clientSecret,
+		Endpoint:     c.provider.Endpoint(),
+		RedirectURL:  c.cfg.RedirectURL,
+		Scopes:       c.cfg.Scopes,
+	}, c.provider, nil
+}
+
+// mergeUserInfo UserInfoエンドポイントのクレームのうちclaimsにないものを追加します
+func (c *OIDCClient) mergeUserInfo(ctx context.Context, provider *oidc.Provider, token *oauth2.Token, subject string, claims map[string]interface{}) error {
+	info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+	if err != nil {
+		return fmt.Errorf("UserInfoの取得に失敗しました: %w", err)
+	}
+	if info.Subject != subject {
+		return fmt.Errorf("UserInfoのsubがIDトークンと一致しません")
+	}
+	extra := map[string]interface{}{}
+	if err := info.Claims(&extra); err != nil {
+		return fmt.Errorf("UserInfoのクレームの読み込みに失敗しました: %w", err)
+	}
+	for k, v := range extra {
+		if _, ok := claims[k]; !ok {
+			claims[k] = v
+		}
+	}
+	return nil
+}
+
+// claimString 文字列のクレームを返します（存在しない・文字列でない場合は空）
+func claimString(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return strings.TrimSpace(s)
+}
+
+// claimBool 真偽値のクレームを返します（文字列の "true" を返すIdPにも対応）
+func claimBool(claims map[string]interface{}, name string) bool {
+	switch v := claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// claimStrings 文字列の配列のクレームを返します（単一の文字列も1要素として扱う）
+func claimStrings(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"trends-summary/internal/config"
+	"trends-summary/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP 認可コード + PKCE のトークン交換とIDトークンの署名を行うテスト用のIdP
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+// mockAuthorization 発行した認可コードに対応する認可リクエストとIDトークンのクレーム
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+const (
+	mockClientID     = "trends-summary"
+	mockClientSecret = "secret"
+	mockKeyID        = "test-key"
+)
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	idp := &mockIdP{key: key, codes: map[string]mockAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": mockKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize 認可URLのPKCEのチャレンジとnonceを記録し、claimsのIDトークンを返す認可コードを発行します
+func (idp *mockIdP) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (state, code string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("認可URLが不正です: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != mockClientID || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("認可URLのパラメータが不正です: %s", authURL)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	code = "code-" + q.Get("state")
+	idp.codes[code] = mockAuthorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: claims}
+	return q.Get("state"), code
+}
+
+// token 認可コードとPKCEのcode_verifierを検証してIDトークンを発行します
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+	if clientID != mockClientID || clientSecret != mockClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	idp.mu.Lock()
+	auth, ok := idp.codes[r.FormValue("code")]
+	delete(idp.codes, r.FormValue("code"))
+	idp.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   mockClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = mockKeyID
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func newTestOIDCClient(t *testing.T, idp *mockIdP, cfg config.OIDCConfig) *OIDCClient {
+	t.Helper()
+	t.Setenv("TEST_OIDC_CLIENT_SECRET", mockClientSecret)
+	cfg.Issuer = idp.server.URL
+	cfg.ClientID = mockClientID
+	cfg.ClientSecretEnv = "TEST_OIDC_CLIENT_SECRET"
+	cfg.RedirectURL = "http://localhost/trends-summary/api/oidc/callback"
+	client, err := NewOIDCClient(cfg)
+	if err != nil {
+		t.Fatalf("NewOIDCClient: %v", err)
+	}
+	return client
+}
+
+func TestOIDCClientFinish(t *testing.T) {
+	idp := newMockIdP(t)
+	client := newTestOIDCClient(t, idp, config.OIDCConfig{Scopes: []string{"openid", "email"}})
+	claims := jwt.MapClaims{"sub": "user-1", "email": "Alice@Example.com", "email_verified": true, "groups": []string{"dev"}}
+
+	tests := []struct {
+		name string
+		// tamper コールバックの値・保持させた値を改ざんします
+		tamper  func(state, code, saved string) (string, string, string)
+		claims  jwt.MapClaims
+		wantErr error
+		wantMsg string // エラーメッセージに含まれるべき文字列
+	}{
+		{
+			name:   "成功",
+			tamper: func(state, code, saved string) (string, string, string) { return state, code, saved },
+		},
+		{
+			name:    "stateが一致しない",
+			tamper:  func(state, code, saved string) (string, string, string) { return "other", code, saved },
+			wantErr: ErrOIDCInvalidState,
+		},
+		{
+			name:    "保持させた値が不正",
+			tamper:  func(state, code, saved string) (string, string, string) { return state, code, "broken" },
+			wantErr: ErrOIDCInvalidState,
+		},
+		{
+			name: "PKCEのcode_verifierが一致しない",
+			tamper: func(state, code, saved string) (string, string, string) {
+				parts := strings.Split(saved, ".")
+				return state, code, parts[0] + "." + parts[1] + ".wrong-verifier-wrong-verifier-wrong-verifier"
+			},
+			wantMsg: "invalid_grant",
+		},
+		{
+			name: "nonceが一致しない",
+			tamper: func(state, code, saved string) (string, string, string) {
+				parts := strings.Split(saved, ".")
+				return state, code, parts[0] + ".other-nonce." + parts[2]
+			},
+			wantMsg: "nonce",
+		},
+		{
+			name:    "IdPが別のnonceのIDトークンを返す",
+			tamper:  func(state, code, saved string) (string, string, string) { return state, code, saved },
+			claims:  jwt.MapClaims{"nonce": "replayed"},
+			wantMsg: "nonce",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, saved, err := client.Begin()
+			if err != nil {
+				t.Fatalf("Begin: %v", err)
+			}
+			tokenClaims := jwt.MapClaims{}
+			for k, v := range claims {
+				tokenClaims[k] = v
+			}
+			for k, v := range tt.claims {
+				tokenClaims[k] = v
+			}
+			state, code := idp.authorize(t, authURL, tokenClaims)
+			state, code, saved = tt.tamper(state, code, saved)
+
+			identity, err := client.Finish(context.Background(), saved, state, code)
+			switch {
+			case tt.wantMsg != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+					t.Fatalf("err = %v, want error containing %q", err, tt.wantMsg)
+				}
+				return
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case err != nil:
+				t.Fatalf("Finish: %v", err)
+			}
+
+			want := OIDCIdentity{
+				Issuer:        idp.server.URL,
+				Subject:       "user-1",
+				Email:         "Alice@Example.com",
+				EmailVerified: true,
+				Username:      "alice@example.com",
+				Groups:        []string{"dev"},
+			}
+			if identity.Issuer != want.Issuer || identity.Subject != want.Subject || identity.Email != want.Email ||
+				identity.EmailVerified != want.EmailVerified || identity.Username != want.Username ||
+				strings.Join(identity.Groups, ",") != strings.Join(want.Groups, ",") {
+				t.Errorf("identity = %+v, want %+v", identity, want)
+			}
+		})
+	}
+}
+
+func TestOIDCClientResolveUser(t *testing.T) {
+	idp := newMockIdP(t)
+	identity := func(subject, email string, verified bool, username string, groups ...string) OIDCIdentity {
+		return OIDCIdentity{Issuer: idp.server.URL, Subject: subject, Email: email, EmailVerified: verified, Username: username, Groups: groups}
+	}
+
+	tests := []struct {
+		name     string
+		cfg      config.OIDCConfig
+		existing []string // 事前に作成するローカルユーザー
+		identity OIDCIdentity
+		wantUser string
+		wantRole string
+		wantErr  error
+	}{
+		{
+			name:     "確認済みのメールアドレスで既存ユーザーに紐付ける",
+			existing: []string{"alice@example.com"},
+			identity: identity("s1", "Alice@example.com", true, "alice@example.com"),
+			wantUser: "alice@example.com",
+			wantRole: models.RoleSummarizer,
+		},
+		{
+			name:     "未確認のメールアドレスでは紐付けない",
+			existing: []string{"alice@example.com"},
+			identity: identity("s1", "alice@example.com", false, "alice@example.com"),
+			wantErr:  ErrOIDCUserNotFound,
+		},
+		{
+			name:     "preferred_usernameでは既存ユーザーに紐付けない",
+			cfg:      config.OIDCConfig{UsernameClaim: "preferred_username"},
+			existing: []string{"admin"},
+			identity: identity("s1", "attacker@evil.test", true, "admin"),
+			wantErr:  ErrOIDCUserNotFound,
+		},
+		{
+			name:     "auto_createでも同名の既存ユーザーには紐付けない",
+			cfg:      config.OIDCConfig{UsernameClaim: "preferred_username", AutoCreate: true},
+			existing: []string{"admin"},
+			identity: identity("s1", "attacker@evil.test", true, "admin"),
+			wantErr:  ErrOIDCNotAllowed,
+		},
+		{
+			name:     "auto_createでユーザーを作成する",
+			cfg:      config.OIDCConfig{UsernameClaim: "preferred_username", AutoCreate: true, DefaultRole: models.RoleViewer},
+			identity: identity("s1", "", false, "bob"),
+			wantUser: "bob",
+			wantRole: models.RoleViewer,
+		},
+		{
+			name:     "auto_createでもusername_claimがemailの場合は確認済みが必要",
+			cfg:      config.OIDCConfig{AutoCreate: true},
+			identity: identity("s1", "bob@example.com", false, "bob@example.com"),
+			wantErr:  ErrOIDCNotAllowed,
+		},
+		{
+			name:     "許可されたドメイン",
+			cfg:      config.OIDCConfig{AllowedDomains: []string{"@Example.com"}, AutoCreate: true},
+			identity: identity("s1", "carol@example.com", true, "carol@example.com"),
+			wantUser: "carol@example.com",
+			wantRole: models.RoleViewer,
+		},
+		{
+			name:     "許可されていないドメイン",
+			cfg:      config.OIDCConfig{AllowedDomains: []string{"example.com"}, AutoCreate: true},
+			identity: identity("s1", "carol@other.test", true, "carol@other.test"),
+			wantErr:  ErrOIDCNotAllowed,
+		},
+		{
+			name:     "許可されたドメインでも未確認のメールアドレス",
+			cfg:      config.OIDCConfig{AllowedDomains: []string{"example.com"}, AutoCreate: true},
+			identity: identity("s1", "carol@example.com", false, "carol@example.com"),
+			wantErr:  ErrOIDCNotAllowed,
+		},
+		{
+			name:     "許可されたグループ",
+			cfg:      config.OIDCConfig{AllowedGroups: []string{"admins", "dev"}, AutoCreate: true},
+			identity: identity("s1", "dave@example.com", true, "dave@example.com", "dev"),
+			wantUser: "dave@example.com",
+			wantRole: models.RoleViewer,
+		},
+		{
+			name:     "許可されたグループに所属していない",
+			cfg:      config.OIDCConfig{AllowedGroups: []string{"admins"}, AutoCreate: true},
+			identity: identity("s1", "dave@example.com", true, "dave@example.com", "dev"),
+			wantErr:  ErrOIDCNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := openTestStore(t)
+			for _, username := range tt.existing {
+				if _, err := st.CreateUser(username, "", models.RoleSummarizer); err != nil {
+					t.Fatalf("CreateUser: %v", err)
+				}
+			}
+			client := newTestOIDCClient(t, idp, tt.cfg)
+
+			user, err := client.ResolveUser(st, tt.identity)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveUser: %v", err)
+			}
+			if user.Username != tt.wantUser || user.Role != tt.wantRole {
+				t.Errorf("user = %s (%s), want %s (%s)", user.Username, user.Role, tt.wantUser, tt.wantRole)
+			}
+
+			// 紐付け後はissuer+subで同じユーザーになる（メールアドレスが変わっても）
+			tt.identity.Email, tt.identity.EmailVerified = "changed@example.net", false
+			tt.cfg.AllowedDomains = nil
+			again, err := newTestOIDCClient(t, idp, tt.cfg).ResolveUser(st, tt.identity)
+			if err != nil {
+				t.Fatalf("2回目の ResolveUser: %v", err)
+			}
+			if again.ID != user.ID {
+				t.Errorf("2回目のユーザー = %d, want %d", again.ID, user.ID)
+			}
+		})
+	}
+}
+
+// TestOIDCClientLoginFlow モックのIdPでログインを開始してからローカルユーザーを返すまでの一連の流れ
+func TestOIDCClientLoginFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	st := openTestStore(t)
+	client := newTestOIDCClient(t, idp, config.OIDCConfig{
+		AllowedDomains: []string{"example.com"},
+		AllowedGroups:  []string{"dev"},
+		AutoCreate:     true,
+		DefaultRole:    models.RoleSummarizer,
+	})
+
+	authURL, saved, err := client.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	state, code := idp.authorize(t, authURL, jwt.MapClaims{
+		"sub": "user-1", "email": "erin@example.com", "email_verified": "true", "groups": "dev",
+	})
+	identity, err := client.Finish(context.Background(), saved, state, code)
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	user, err := client.ResolveUser(st, identity)
+	if err != nil {
+		t.Fatalf("ResolveUser: %v", err)
+	}
+	if user.Username != "erin@example.com" || user.Role != models.RoleSummarizer {
+		t.Errorf("user = %s (%s), want erin@example.com (summarizer)", user.Username, user.Role)
+	}
+
+	// 認可コードは1回しか使えない
+	if _, err := client.Finish(context.Background(), saved, state, code); err == nil {
+		t.Error("認可コードの再利用: err = nil, want error")
+	}
+}
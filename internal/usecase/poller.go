@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FeedPoller 登録済みの全フィードを定期的に取得してストアに保存します
+type FeedPoller struct {
+	registry *FeedRegistry
+	store    *store.Store
+	fetcher  *Fetcher
+	notifier *Notifier
+	interval time.Duration
+}
+
+// NewFeedPoller フィードポーラーを作成します
+// notifierがnilでない場合は新着記事を配信します
+func NewFeedPoller(registry *FeedRegistry, st *store.Store, fetcher *Fetcher, notifier *Notifier, interval time.Duration) *FeedPoller {
+	if interval <= 0 {
+		interval = 30 * time.Minute
+	}
+	return &FeedPoller{
+		registry: registry,
+		store:    st,
+		fetcher:  fetcher,
+		notifier: notifier,
+		interval: interval,
+	}
+}
+
+// Start 起動直後に1回取得し、以降はintervalごとに取得します（ctxのキャンセルで停止）
+func (p *FeedPoller) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		p.PollAll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.PollAll(ctx)
+			}
+		}
+	}()
+}
+
+// PollAll 全フィードを取得してストアに保存します
+func (p *FeedPoller) PollAll(ctx context.Context) {
+	start := time.Now()
+	totalNew := 0
+	for _, src := range p.registry.List() {
+		// 初回取得（ストアが空）の記事は新着として通知しない
+		existing, err := p.store.ListArticles(src.ID, 1)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "FeedPoller.PollAll",
+				"sourceID": src.ID,
+				"error":    err.Error(),
+			}).Warn("記事の取得に失敗しました")
+			continue
+		}
+
+		inserted, err := RefreshFeed(ctx, p.fetcher, p.store, src)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "FeedPoller.PollAll",
+				"sourceID": src.ID,
+				"error":    err.Error(),
+			}).Warn("フィードの定期取得に失敗しました")
+			continue
+		}
+		totalNew += len(inserted)
+		if len(existing) > 0 {
+			p.notifier.NotifyNewItems(ctx, src, inserted)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"function":    "FeedPoller.PollAll",
+		"newItems":    totalNew,
+		"elapsedTime": time.Since(start).String(),
+	}).Info("フィードの定期取得が完了しました")
+}
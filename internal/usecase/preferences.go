@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 表示設定の上限
+const (
+	maxPreferenceKeywords        = 30
+	maxPreferenceKeywordLength   = 50
+	maxPreferenceGitHubLanguages = 5
+)
+
+// githubLanguagePattern GitHub Trendingの言語指定（URLのパス、例: go, c++, jupyter-notebook）
+var githubLanguagePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9+#.\-]{0,39}$`)
+
+// NormalizePreferences 表示設定を検証し、前後の空白と重複を取り除きます
+func NormalizePreferences(registry *FeedRegistry, prefs models.UserPreferences) (models.UserPreferences, error) {
+	switch prefs.Language {
+	case "", models.PreferenceLanguageEN, models.PreferenceLanguageJA:
+	default:
+		return prefs, fmt.Errorf("languageはen/jaのいずれかを指定してください")
+	}
+
+	sources := uniqueStrings(prefs.Sources, false)
+	for _, id := range sources {
+		if _, ok := registry.Get(id); !ok {
+			return prefs, fmt.Errorf("フィードソース %s が登録されていません", id)
+		}
+	}
+
+	keywords := uniqueStrings(prefs.Keywords, false)
+	if len(keywords) > maxPreferenceKeywords {
+		return prefs, fmt.Errorf("keywordsは%d件以内で指定してください", maxPreferenceKeywords)
+	}
+	for _, k := range keywords {
+		if utf8.RuneCountInString(k) > maxPreferenceKeywordLength {
+			return prefs, fmt.Errorf("keywordsは1件%d文字以内で指定してください", maxPreferenceKeywordLength)
+		}
+	}
+
+	languages := uniqueStrings(prefs.GitHubLanguages, true)
+	if len(languages) > maxPreferenceGitHubLanguages {
+		return prefs, fmt.Errorf("githubLanguagesは%d件以内で指定してください", maxPreferenceGitHubLanguages)
+	}
+	for _, l := range languages {
+		if !githubLanguagePattern.MatchString(l) {
+			return prefs, fmt.Errorf("githubLanguagesの言語名が不正です: %s", l)
+		}
+	}
+
+	return models.UserPreferences{
+		Sources:         sources,
+		Language:        prefs.Language,
+		Keywords:        keywords,
+		GitHubLanguages: languages,
+	}, nil
+}
+
+// uniqueStrings 空白を除去して空文字と重複を取り除きます（lowerがtrueの場合は小文字にそろえます）
+func uniqueStrings(list []string, lower bool) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if lower {
+			v = strings.ToLower(v)
+		}
+		if v == "" || seen[strings.ToLower(v)] {
+			continue
+		}
+		seen[strings.ToLower(v)] = true
+		result = append(result, v)
+	}
+	return result
+}
+
+// FilterSources 表示設定で有効なフィードソースのみを返します
+func FilterSources(prefs models.UserPreferences, sources []models.FeedSource) []models.FeedSource {
+	result := []models.FeedSource{}
+	for _, src := range sources {
+		if containsOrEmpty(prefs.Sources, src.ID) {
+			result = append(result, src)
+		}
+	}
+	return result
+}
+
+// FilterItemsByKeywords タイトルまたは説明にキーワードのいずれかを含む記事を返します（大文字小文字は区別しない）
+func FilterItemsByKeywords(items []models.FeedItem, keywords []string) []models.FeedItem {
+	if len(keywords) == 0 {
+		return items
+	}
+	result := []models.FeedItem{}
+	for _, item := range items {
+		text := strings.ToLower(item.Title + "\n" + htmlToText(item.Description))
+		for _, k := range keywords {
+			if strings.Contains(text, strings.ToLower(k)) {
+				result = append(result, item)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// matchesGitHubLanguage GitHub Trendingの表示上の言語名（例: Jupyter Notebook）が言語指定に一致するか判定します
+func matchesGitHubLanguage(name string, languages []string) bool {
+	if len(languages) == 0 {
+		return true
+	}
+	slug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+	for _, l := range languages {
+		if slug == l {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildPersonalDigestInput 表示設定に合わせて指定日のダイジェストの元データを集めます
+// 言語設定に応じてInfoQの言語版を選び、キーワードとGitHubの言語で絞り込みます
+// q は ParseDigestQuery で検証済みの条件を渡します（ソースは表示設定から決めるため使いません）
+func BuildPersonalDigestInput(ctx context.Context, fetcher *Fetcher, st *store.Store, registry *FeedRegistry, q models.DigestQuery, prefs models.UserPreferences) (models.DigestInput, error) {
+	input := models.DigestInput{Date: q.Date, Sources: []string{}}
+	day, err := time.ParseInLocation(snapshotDateLayout, q.Date, time.Local)
+	if err != nil {
+		return input, fmt.Errorf("日付の解析に失敗しました: %w", err)
+	}
+
+	warn := func(source string, err error) {
+		// 1つのソースが失敗しても残りのソースでダイジェストを作成する
+		logrus.WithFields(logrus.Fields{
+			"function": "BuildPersonalDigestInput",
+			"date":     q.Date,
+			"source":   source,
+			"error":    err.Error(),
+		}).Warn("ダイジェストの元データの取得に失敗しました")
+	}
+
+	if infoq, ok := registry.LanguageVariant(models.DigestSourceInfoQ, prefs.Language); ok && containsOrEmpty(prefs.Sources, infoq.ID) {
+		input.Sources = append(input.Sources, models.DigestSourceInfoQ)
+		limit := digestInfoQLimit
+		if len(prefs.Keywords) > 0 {
+			// キーワードで絞り込む分、多めに読み込む
+			limit *= 3
+		}
+		items, err := digestArticles(ctx, fetcher, st, registry, infoq.ID, day, digestInfoQDays, limit)
+		if err != nil {
+			warn(infoq.ID, err)
+		}
+		items = FilterItemsByKeywords(items, prefs.Keywords)
+		if len(items) > digestInfoQLimit {
+			items = items[:digestInfoQLimit]
+		}
+		input.InfoQ = items
+	}
+
+	input.Sources = append(input.Sources, models.DigestSourceGitHubTrending)
+	trending, err := digestTrending(ctx, fetcher, st, q.Date)
+	if err != nil {
+		warn(models.DigestSourceGitHubTrending, err)
+	}
+	for _, e := range trending {
+		if matchesGitHubLanguage(e.Language, prefs.GitHubLanguages) {
+			input.GitHubTrending = append(input.GitHubTrending, e)
+		}
+	}
+
+	if containsOrEmpty(prefs.Sources, models.DigestSourceGolangWeekly) {
+		input.Sources = append(input.Sources, models.DigestSourceGolangWeekly)
+		input.GolangWeekly, err = digestArticles(ctx, fetcher, st, registry, models.DigestSourceGolangWeekly, day, digestGolangWeeklyDays, 1)
+		if err != nil {
+			warn(models.DigestSourceGolangWeekly, err)
+		}
+	}
+
+	if len(input.InfoQ) == 0 && len(input.GitHubTrending) == 0 && len(input.GolangWeekly) == 0 {
+		return input, ErrDigestNoData
+	}
+	return input, nil
+}
+
+// BuildPersonalDigestPrompt 表示設定のキーワードと言語を反映したダイジェストのプロンプトを組み立てます
+func BuildPersonalDigestPrompt(input models.DigestInput, prefs models.UserPreferences) string {
+	var b strings.Builder
+	b.WriteString(BuildDigestPrompt(input))
+	if len(prefs.Keywords) > 0 {
+		fmt.Fprintf(&b, "\n次のトピックに関連する内容を優先して取り上げてください: %s\n", strings.Join(prefs.Keywords, ", "))
+	}
+	if prefs.Language == models.PreferenceLanguageEN {
+		b.WriteString("\nPlease answer in English.\n")
+	}
+	return b.String()
+}
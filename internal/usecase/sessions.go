@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrInvalidRefreshToken リフレッシュトークンが無効・失効済み・期限切れの場合のエラー
+	ErrInvalidRefreshToken = errors.New("リフレッシュトークンが無効です")
+	// ErrRefreshTokenReused 置き換え済みのリフレッシュトークンが使われ、セッションを失効させた場合のエラー
+	// （errors.Is で ErrInvalidRefreshToken にも一致します）
+	ErrRefreshTokenReused = fmt.Errorf("%w（使用済みのトークンのためセッションを失効させました）", ErrInvalidRefreshToken)
+)
+
+// refreshReuseGrace 直前のリフレッシュトークンを置き換えてから受け付ける期間
+// 同じCookieを共有する複数のタブが同時にリフレッシュした場合に、後から届いたリクエストでセッションを失効させないため
+var refreshReuseGrace = 10 * time.Second
+
+// StartSession ログインしたユーザーのセッションを作成し、リフレッシュトークンを返します
+// リフレッシュトークンは "セッションID.シークレット" の形式で、シークレットはハッシュのみ保存します
+func StartSession(st *store.Store, userID int64, userAgent, ip string, ttl time.Duration) (models.Session, string, error) {
+	if err := st.PurgeExpiredSessions(); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "StartSession",
+			"error":    err.Error(),
+		}).Warn("期限切れセッションの削除に失敗しました")
+	}
+
+	id, err := randomToken(16)
+	if err != nil {
+		return models.Session{}, "", err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return models.Session{}, "", err
+	}
+
+	now := time.Now().UTC()
+	session := models.Session{
+		ID:          id,
+		UserID:      userID,
+		RefreshHash: hashToken(secret),
+		UserAgent:   truncateRunes(userAgent, 256),
+		IP:          ip,
+		CreatedAt:   now.Format(time.RFC3339),
+		LastUsedAt:  now.Format(time.RFC3339),
+		ExpiresAt:   now.Add(ttl).Format(time.RFC3339),
+	}
+	if err := st.CreateSession(session); err != nil {
+		return models.Session{}, "", err
+	}
+	return session, id + "." + secret, nil
+}
+
+// RefreshSession リフレッシュトークンを検証して新しいトークンに置き換え、セッションとユーザーを返します
+// 直前に置き換えたトークンは refreshReuseGrace の間だけ受け付け、置き換えずに空のトークンを返します
+// （新しいトークンは先に完了したリフレッシュでCookieに設定済みのため）
+// それ以外の置き換え済みのトークンが使われた場合は漏洩したものとみなしてセッションを失効させ、
+// 失効させたセッションと ErrRefreshTokenReused を返します
+func RefreshSession(st *store.Store, refreshToken string, ttl time.Duration) (models.Session, *models.User, string, error) {
+	session, secret, err := lookupSession(st, refreshToken)
+	if err != nil {
+		return models.Session{}, nil, "", err
+	}
+	hash := hashToken(secret)
+	rotatedJustNow := session.PrevRefreshHash == hash && withinRefreshReuseGrace(session.RotatedAt)
+	if session.RefreshHash != hash && !rotatedJustNow {
+		if _, err := st.RevokeSession(session.UserID, session.ID); err != nil {
+			return models.Session{}, nil, "", err
+		}
+		logrus.WithFields(logrus.Fields{
+			"function":  "RefreshSession",
+			"sessionID": session.ID,
+			"userID":    session.UserID,
+		}).Warn("使用済みのリフレッシュトークンが使われたためセッションを失効させました")
+		return *session, nil, "", ErrRefreshTokenReused
+	}
+
+	user, err := st.GetUser(session.UserID)
+	if err != nil {
+		return models.Session{}, nil, "", err
+	}
+	if user == nil {
+		return models.Session{}, nil, "", ErrInvalidRefreshToken
+	}
+	if user.Disabled {
+		return models.Session{}, nil, "", ErrUserDisabled
+	}
+	if rotatedJustNow {
+		logrus.WithFields(logrus.Fields{
+			"function":  "RefreshSession",
+			"sessionID": session.ID,
+			"userID":    session.UserID,
+		}).Info("直前に置き換えたリフレッシュトークンのため置き換えずに受け付けました")
+		return *session, user, "", nil
+	}
+
+	newSecret, err := randomToken(32)
+	if err != nil {
+		return models.Session{}, nil, "", err
+	}
+	session.RefreshHash = hashToken(newSecret)
+	session.ExpiresAt = time.Now().UTC().Add(ttl).Format(time.RFC3339)
+	rotated, err := st.RotateSession(session.ID, hash, session.RefreshHash, session.ExpiresAt)
+	if err != nil {
+		return models.Session{}, nil, "", err
+	}
+	if !rotated {
+		// 同じトークンでの同時リフレッシュに負けた、または直前に失効した
+		return models.Session{}, nil, "", ErrInvalidRefreshToken
+	}
+	return *session, user, session.ID + "." + newSecret, nil
+}
+
+// withinRefreshReuseGrace 直前のリフレッシュトークンを置き換えてから refreshReuseGrace 以内か判定します
+func withinRefreshReuseGrace(rotatedAt string) bool {
+	t, err := time.Parse(time.RFC3339, rotatedAt)
+	if err != nil {
+		return false
+	}
+	return time.Since(t) < refreshReuseGrace
+}
+
+// EndSession リフレッシュトークンのセッションを失効させ、失効させたセッションを返します（ログアウト）
+func EndSession(st *store.Store, refreshToken string) (models.Session, error) {
+	session, secret, err := lookupSession(st, refreshToken)
+	if err != nil {
+		return models.Session{}, err
+	}
+	if session.RefreshHash != hashToken(secret) {
+		return models.Session{}, ErrInvalidRefreshToken
+	}
+	if _, err := st.RevokeSession(session.UserID, session.ID); err != nil {
+		return models.Session{}, err
+	}
+	return *session, nil
+}
+
+// lookupSession リフレッシュトークンのセッションを取得します（失効済み・期限切れはエラー）
+func lookupSession(st *store.Store, refreshToken string) (*models.Session, string, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || id == "" || secret == "" {
+		return nil, "", ErrInvalidRefreshToken
+	}
+	session, err := st.GetSession(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if session == nil || session.RevokedAt != "" || session.ExpiresAt <= time.Now().UTC().Format(time.RFC3339) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+	return session, secret, nil
+}
+
+// randomToken nバイトの乱数を16進文字列で返します
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("トークンの生成に失敗しました: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken トークンのSHA-256を16進文字列で返します
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"trends-summary/internal/models"
+)
+
+func TestRefreshSession(t *testing.T) {
+	st := openTestStore(t)
+	user, err := st.CreateUser("alice", "", models.RoleViewer)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	session, first, err := StartSession(st, user.ID, "test", "192.0.2.1", time.Hour)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+
+	_, got, second, err := RefreshSession(st, first, time.Hour)
+	if err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+	if got.ID != user.ID || second == "" || second == first {
+		t.Fatalf("RefreshSession = user %d, token %q, want new token for user %d", got.ID, second, user.ID)
+	}
+
+	// 別のタブが同時に送った直前のトークンは、置き換えずに受け付ける
+	refreshed, got, token, err := RefreshSession(st, first, time.Hour)
+	if err != nil {
+		t.Fatalf("直前のトークンでの RefreshSession: %v", err)
+	}
+	if refreshed.ID != session.ID || got.ID != user.ID || token != "" {
+		t.Fatalf("直前のトークンでの RefreshSession = session %s, user %d, token %q, want same session without new token", refreshed.ID, got.ID, token)
+	}
+
+	// 現在のトークンは引き続き使える
+	_, _, third, err := RefreshSession(st, second, time.Hour)
+	if err != nil {
+		t.Fatalf("現在のトークンでの RefreshSession: %v", err)
+	}
+
+	// 2つ前のトークンは再利用とみなしてセッションを失効させる
+	if _, _, _, err := RefreshSession(st, first, time.Hour); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("2つ前のトークンでの RefreshSession: err = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, _, _, err := RefreshSession(st, third, time.Hour); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("失効後の RefreshSession: err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshSessionReuseAfterGrace(t *testing.T) {
+	grace := refreshReuseGrace
+	refreshReuseGrace = 0
+	t.Cleanup(func() { refreshReuseGrace = grace })
+
+	st := openTestStore(t)
+	user, err := st.CreateUser("alice", "", models.RoleViewer)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	_, first, err := StartSession(st, user.ID, "test", "192.0.2.1", time.Hour)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	_, _, second, err := RefreshSession(st, first, time.Hour)
+	if err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+
+	// 猶予期間を過ぎた直前のトークンは再利用とみなす
+	session, _, _, err := RefreshSession(st, first, time.Hour)
+	if !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("猶予期間後の RefreshSession: err = %v, want ErrRefreshTokenReused", err)
+	}
+	if session.UserID != user.ID {
+		t.Errorf("失効させたセッションのユーザー = %d, want %d", session.UserID, user.ID)
+	}
+	if _, _, _, err := RefreshSession(st, second, time.Hour); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("失効後の RefreshSession: err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshSessionDisabledUser(t *testing.T) {
+	st := openTestStore(t)
+	user, err := st.CreateUser("alice", "", models.RoleViewer)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	_, first, err := StartSession(st, user.ID, "test", "192.0.2.1", time.Hour)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if _, _, _, err := RefreshSession(st, first, time.Hour); err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+	user.Disabled = true
+	if err := st.UpdateUser(user); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	// 猶予期間内の直前のトークンでも無効化されたユーザーは受け付けない
+	if _, _, _, err := RefreshSession(st, first, time.Hour); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("err = %v, want ErrUserDisabled", err)
+	}
+}
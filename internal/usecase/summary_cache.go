@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+)
+
+// SummaryCache AI要約の永続キャッシュ
+// キーは (エンドポイント, 正規化済みURL/リポジトリ, プロンプトバージョン, モデル, 要約対象コンテンツのハッシュ) です
+type SummaryCache struct {
+	store *store.Store
+	ttl   time.Duration
+}
+
+// NewSummaryCache 要約キャッシュを作成します
+func NewSummaryCache(st *store.Store, ttl time.Duration) *SummaryCache {
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+	return &SummaryCache{store: st, ttl: ttl}
+}
+
+// NewEntry キャッシュキーを計算したエントリを作成します（Summaryは未設定）
+func (c *SummaryCache) NewEntry(endpoint, target, promptVersion, model, content string) models.SummaryCacheEntry {
+	contentHash := sha256.Sum256([]byte(content))
+	e := models.SummaryCacheEntry{
+		Endpoint:      endpoint,
+		Target:        NormalizeURL(target),
+		PromptVersion: promptVersion,
+		Model:         model,
+		ContentHash:   hex.EncodeToString(contentHash[:]),
+	}
+	key := sha256.Sum256([]byte(strings.Join([]string{e.Endpoint, e.Target, e.PromptVersion, e.Model, e.ContentHash}, "\x00")))
+	e.Key = hex.EncodeToString(key[:])
+	return e
+}
+
+// Get 有効期限内のキャッシュを返します（存在しない場合はnil）
+func (c *SummaryCache) Get(e models.SummaryCacheEntry) (*models.SummaryCacheEntry, error) {
+	return c.store.GetSummaryCache(e.Key)
+}
+
+// Put 要約をキャッシュに保存し、保存したエントリを返します
+func (c *SummaryCache) Put(e models.SummaryCacheEntry, summary string) (models.SummaryCacheEntry, error) {
+	now := time.Now().UTC()
+	e.Summary = summary
+	e.CachedAt = now.Format(time.RFC3339)
+	e.ExpiresAt = now.Add(c.ttl).Format(time.RFC3339)
+	return e, c.store.SaveSummaryCache(e)
+}
+
+// NormalizeURL キャッシュキー用にURLを正規化します
+// スキーム・ホストの小文字化、フラグメントと utm_* パラメータの除去、末尾スラッシュの除去、クエリのソートを行います
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(raw)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode() // Encodeはキー順にソートされる
+
+	return u.String()
+}
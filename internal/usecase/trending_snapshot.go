@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// snapshotDateLayout スナップショットの日付形式（サーバーのローカルタイムゾーン）
+const snapshotDateLayout = "2006-01-02"
+
+// Today スナップショットの日付として使う今日の日付を返します
+func Today() string {
+	return time.Now().Format(snapshotDateLayout)
+}
+
+// SaveTrendingSnapshot 取得したトレンドを今日のスナップショットとして保存します
+// スナップショットは TrendingSnapshotJob が1日1回保存します（表示のたびに置き換えると日ごとの比較がぶれるため）
+func SaveTrendingSnapshot(st *store.Store, scope string, repos []models.TrendingRepository) error {
+	date := Today()
+	return st.SaveTrendingSnapshot(date, scope, trendingSnapshotEntries(date, scope, repos))
+}
+
+// trendingSnapshotEntries 取得したトレンドをスナップショットの形式に変換します
+func trendingSnapshotEntries(date, scope string, repos []models.TrendingRepository) []models.TrendingSnapshotEntry {
+	entries := make([]models.TrendingSnapshotEntry, 0, len(repos))
+	for _, repo := range repos {
+		entries = append(entries, models.TrendingSnapshotEntry{
+			Date:        date,
+			Scope:       scope,
+			Rank:        repo.Rank,
+			FullName:    repo.Name,
+			Description: repo.Description,
+			Language:    repo.Language,
+			Stars:       repo.Stars,
+		})
+	}
+	return entries
+}
+
+// DiffTrendingSnapshot 指定日（空の場合は最新）のスナップショットを直前のスナップショットと比較します
+func DiffTrendingSnapshot(st *store.Store, scope, date string) (models.TrendingDiff, error) {
+	if date == "" {
+		date = Today()
+	}
+	diff := models.TrendingDiff{
+		Scope:      scope,
+		NewEntries: []models.TrendingSnapshotEntry{},
+		DroppedOut: []models.TrendingSnapshotEntry{},
+		Climbing:   []models.TrendingRankChange{},
+		Falling:    []models.TrendingRankChange{},
+	}
+
+	currentDate, err := st.LatestTrendingSnapshotDate(scope, date)
+	if err != nil || currentDate == "" {
+		return diff, err
+	}
+	diff.Date = currentDate
+
+	current, err := st.TrendingSnapshot(currentDate, scope)
+	if err != nil {
+		return diff, err
+	}
+
+	prevLimit, _ := time.Parse(snapshotDateLayout, currentDate)
+	previousDate, err := st.LatestTrendingSnapshotDate(scope, prevLimit.AddDate(0, 0, -1).Format(snapshotDateLayout))
+	if err != nil {
+		return diff, err
+	}
+	diff.PreviousDate = previousDate
+
+	var previous []models.TrendingSnapshotEntry
+	if previousDate != "" {
+		if previous, err = st.TrendingSnapshot(previousDate, scope); err != nil {
+			return diff, err
+		}
+	}
+
+	previousRanks := make(map[string]models.TrendingSnapshotEntry, len(previous))
+	for _, e := range previous {
+		previousRanks[e.FullName] = e
+	}
+	currentRanks := make(map[string]bool, len(current))
+
+	for _, e := range current {
+		currentRanks[e.FullName] = true
+		prev, ok := previousRanks[e.FullName]
+		switch {
+		case !ok:
+			diff.NewEntries = append(diff.NewEntries, e)
+		case prev.Rank != e.Rank:
+			change := models.TrendingRankChange{
+				FullName:     e.FullName,
+				Rank:         e.Rank,
+				PreviousRank: prev.Rank,
+				Change:       prev.Rank - e.Rank,
+				Stars:        e.Stars,
+			}
+			if change.Change > 0 {
+				diff.Climbing = append(diff.Climbing, change)
+			} else {
+				diff.Falling = append(diff.Falling, change)
+			}
+		}
+	}
+	for _, e := range previous {
+		if !currentRanks[e.FullName] {
+			diff.DroppedOut = append(diff.DroppedOut, e)
+		}
+	}
+
+	// 変動の大きい順に並べる
+	sort.SliceStable(diff.Climbing, func(i, j int) bool { return diff.Climbing[i].Change > diff.Climbing[j].Change })
+	sort.SliceStable(diff.Falling, func(i, j int) bool { return diff.Falling[i].Change < diff.Falling[j].Change })
+
+	return diff, nil
+}
+
+// TrendingSnapshotJob 設定されたスコープのトレンドを1日1回スナップショットとして保存します
+type TrendingSnapshotJob struct {
+	store    *store.Store
+	fetcher  *Fetcher
+	scopes   []string
+	interval time.Duration
+}
+
+// NewTrendingSnapshotJob スナップショットジョブを作成します
+// scopesの各要素はGitHub Trendingの言語（空文字は全言語）です
+func NewTrendingSnapshotJob(st *store.Store, fetcher *Fetcher, scopes []string, interval time.Duration) *TrendingSnapshotJob {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &TrendingSnapshotJob{
+		store:    st,
+		fetcher:  fetcher,
+		scopes:   scopes,
+		interval: interval,
+	}
+}
+
+// Start intervalごとに今日のスナップショットの有無を確認し、未保存のスコープを取得します
+func (j *TrendingSnapshotJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.RunOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+// RunOnce 今日のスナップショットが未保存のスコープを取得して保存します
+func (j *TrendingSnapshotJob) RunOnce(ctx context.Context) {
+	for _, scope := range j.scopes {
+		exists, err := j.store.HasTrendingSnapshot(Today(), scope)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "TrendingSnapshotJob.RunOnce",
+				"scope":    scope,
+				"error":    err.Error(),
+			}).Warn("トレンドのスナップショットの確認に失敗しました")
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		repos, err := ScrapeGitHubTrending(ctx, j.fetcher, models.TrendingQuery{Language: scope, Since: models.TrendingSinceDaily})
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "TrendingSnapshotJob.RunOnce",
+				"scope":    scope,
+				"error":    err.Error(),
+			}).Warn("GitHub Trendingの取得に失敗しました")
+			continue
+		}
+		if err := SaveTrendingSnapshot(j.store, scope, repos); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"function": "TrendingSnapshotJob.RunOnce",
+				"scope":    scope,
+				"error":    err.Error(),
+			}).Warn("トレンドのスナップショット保存に失敗しました")
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"function":          "TrendingSnapshotJob.RunOnce",
+			"scope":             scope,
+			"repositoriesCount": len(repos),
+		}).Info("トレンドのスナップショットを保存しました")
+	}
+}
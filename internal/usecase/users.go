@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minPasswordLength パスワードの最小文字数
+const minPasswordLength = 8
+
+var (
+	// ErrInvalidCredentials ユーザー名またはパスワードが正しくない場合のエラー
+	ErrInvalidCredentials = errors.New("ユーザー名またはパスワードが正しくありません")
+	// ErrUserDisabled 無効化されたユーザーがログインしようとした場合のエラー
+	ErrUserDisabled = errors.New("このユーザーは無効化されています")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._@-]{1,64}$`)
+
+// dummyHash 存在しないユーザーでもパスワード照合と同じ時間をかけるためのハッシュ
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// ValidateUsername ユーザー名の形式を検証します
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("ユーザー名は英数字と . _ @ - の64文字以内で指定してください")
+	}
+	return nil
+}
+
+// HashPassword パスワードの長さを検証してbcryptでハッシュ化します
+func HashPassword(password string) (string, error) {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return "", fmt.Errorf("パスワードは%d文字以上で指定してください", minPasswordLength)
+	}
+	if len(password) > 72 {
+		return "", fmt.Errorf("パスワードは72バイト以内で指定してください")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("パスワードのハッシュ化に失敗しました: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword パスワードがハッシュと一致するか確認します
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// Authenticate ユーザー名とパスワードを照合し、ログインしたユーザーを返します
+// ユーザーの有無やパスワード未設定（シングルサインオン専用）が応答時間から分からないよう、
+// どの場合もbcryptの照合を1回行います
+func Authenticate(st *store.Store, username, password string) (*models.User, error) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+
+	user, err := st.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	if err := st.TouchUserLogin(user.ID); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"function": "Authenticate",
+			"userID":   user.ID,
+			"error":    err.Error(),
+		}).Warn("最終ログイン日時の更新に失敗しました")
+	}
+	return user, nil
+}
+
+// BootstrapAdmin 環境変数のユーザー名・パスワードで初期管理者を作成します
+// 同名のユーザーが既に存在する場合は何もしません（以降のパスワード変更はAPIで行います）
+func BootstrapAdmin(st *store.Store, username, password string) error {
+	if username == "" || password == "" {
+		return nil
+	}
+	existing, err := st.GetUserByUsername(username)
+	if err != nil || existing != nil {
+		return err
+	}
+
+	if err := ValidateUsername(username); err != nil {
+		return err
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if _, err := st.CreateUser(username, hash, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"function": "BootstrapAdmin",
+		"username": username,
+	}).Info("初期管理者を作成しました")
+	return nil
+}
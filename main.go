@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"strings"
 	"time"
+	"trends-summary/internal/config"
 	"trends-summary/internal/handlers"
 	"trends-summary/internal/middleware"
+	"trends-summary/internal/models"
+	"trends-summary/internal/store"
+	"trends-summary/internal/usecase"
 
 	"github.com/labstack/echo/v4" // バージョンを指定
 	"github.com/sirupsen/logrus"
@@ -24,8 +32,108 @@ func main() {
 	// ログレベルを設定（例: Infoレベル）
 	logrus.SetLevel(logrus.InfoLevel)
 
+	// 設定ファイルの読み込み
+	cfg, err := config.Load()
+	if err != nil {
+		logrus.WithError(err).Fatal("設定の読み込みに失敗しました")
+	}
+
+	// フィードソースのレジストリを作成
+	feedRegistry, err := usecase.NewFeedRegistry(cfg.Sources)
+	if err != nil {
+		logrus.WithError(err).Fatal("フィードソース定義が不正です")
+	}
+	handlers.SetFeedRegistry(feedRegistry)
+
+	// AI要約に使用するLLMプロバイダー
+	llmRouter, err := usecase.NewLLMRouterFromConfig(cfg.LLM)
+	if err != nil {
+		logrus.WithError(err).Fatal("LLMプロバイダー定義が不正です")
+	}
+	handlers.SetLLMRouter(llmRouter)
+
+	// 記事・フィードなど外部のコンテンツの取得（内部アドレスへの接続を拒否する）
+	fetcher, err := usecase.NewFetcher(cfg.Fetcher)
+	if err != nil {
+		logrus.WithError(err).Fatal("fetcherの設定が不正です")
+	}
+	handlers.SetFetcher(fetcher)
+	contentExtractor, err := usecase.NewContentExtractor(cfg.Extraction)
+	if err != nil {
+		logrus.WithError(err).Fatal("本文抽出の設定が不正です")
+	}
+	handlers.SetContentExtractor(contentExtractor)
+
+	// 記事ストア（SQLite）を開く
+	st, err := store.Open(cfg.Store.Path)
+	if err != nil {
+		logrus.WithError(err).Fatal("ストアのオープンに失敗しました")
+	}
+	defer st.Close()
+	handlers.SetStore(st, cfg.Store.ListLimit)
+	middleware.SetUserStore(st)
+	middleware.SetAccessTokenTTL(cfg.Auth.AccessTokenTTL)
+	handlers.SetRefreshTokenTTL(cfg.Auth.RefreshTokenTTL)
+	handlers.SetLoginThrottle(usecase.NewLoginThrottle(cfg.Auth.LoginThrottle))
+	handlers.SetAuditLog(usecase.NewAuditLog(st, cfg.Auth.AuditRetention))
+
+	// 環境変数のユーザー名・パスワードは初期管理者の作成にのみ使用する
+	if err := usecase.BootstrapAdmin(st, os.Getenv("AUTH_USERNAME"), os.Getenv("AUTH_PASSWORD")); err != nil {
+		logrus.WithError(err).Fatal("初期管理者の作成に失敗しました")
+	}
+	if users, err := st.ListUsers(); err == nil && len(users) == 0 {
+		logrus.Warn("ユーザーが登録されていません。AUTH_USERNAME と AUTH_PASSWORD を設定して初期管理者を作成してください")
+	}
+	// OIDCによるシングルサインオン
+	if cfg.OIDC.Enabled {
+		oidcClient, err := usecase.NewOIDCClient(cfg.OIDC)
+		if err != nil {
+			logrus.WithError(err).Fatal("OIDCの設定が不正です")
+		}
+		handlers.SetOIDCClient(oidcClient)
+	}
+	if cfg.SummaryCache.Enabled {
+		handlers.SetSummaryCache(usecase.NewSummaryCache(st, cfg.SummaryCache.TTL))
+	}
+	// AI要約の利用量と推定コストの記録・上限
+	aiUsage := usecase.NewAIUsageTracker(st, cfg.AIUsage, cfg.LLM)
+	llmRouter.SetUsageTracker(aiUsage)
+	handlers.SetAIUsageTracker(aiUsage)
+
+	handlers.SetOutgoingFeed(cfg.OutgoingFeed.Title, cfg.OutgoingFeed.Description, cfg.OutgoingFeed.Limit)
+
+	// ダイジェスト・新着記事の通知先
+	notifier, err := usecase.NewNotifier(st, cfg.Notifier, fetcher)
+	if err != nil {
+		logrus.WithError(err).Fatal("通知先の設定が不正です")
+	}
+	handlers.SetNotifier(notifier)
+
+	// フィードのバックグラウンド取得を開始
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.Poller.Enabled {
+		usecase.NewFeedPoller(feedRegistry, st, fetcher, notifier, cfg.Poller.Interval).Start(ctx)
+	}
+	if cfg.Trending.SnapshotEnabled {
+		usecase.NewTrendingSnapshotJob(st, fetcher, cfg.Trending.Scopes, cfg.Trending.CheckInterval).Start(ctx)
+	}
+	if cfg.Digest.Enabled {
+		digestJob, err := usecase.NewDigestJob(st, feedRegistry, fetcher, llmRouter, notifier, cfg.Digest.At, cfg.Digest.Sources, cfg.Digest.CheckInterval)
+		if err != nil {
+			logrus.WithError(err).Fatal("ダイジェストの設定が不正です")
+		}
+		digestJob.Start(ctx)
+	}
+
 	e := echo.New()
 
+	// クライアントのIPアドレスの取得方法（X-Forwarded-Forは信頼するプロキシ経由の場合のみ使う）
+	e.IPExtractor, err = clientIPExtractor(cfg.Auth.TrustedProxies)
+	if err != nil {
+		logrus.WithError(err).Fatal("信頼するプロキシの設定が不正です")
+	}
+
 	// サーバータイムアウトの設定
 	e.Server.ReadTimeout = 30 * time.Second
 	e.Server.WriteTimeout = 60 * time.Second
@@ -34,37 +142,123 @@ func main() {
 	// 認証不要のエンドポイント
 	e.POST("/trends-summary/api/login", handlers.Login)
 	e.POST("/trends-summary/api/logout", handlers.Logout)
+	e.POST("/trends-summary/api/refresh", handlers.Refresh)
+	e.GET("/trends-summary/api/login-options", handlers.LoginOptions)
+	e.GET("/trends-summary/api/oidc/login", handlers.OIDCLogin)
+	e.GET("/trends-summary/api/oidc/callback", handlers.OIDCCallback)
+	e.GET("/trends-summary/api/unsubscribe", handlers.UnsubscribeConfirm)
+	e.POST("/trends-summary/api/unsubscribe", handlers.Unsubscribe)
+
+	// 全ソースの集約フィード（フィードリーダー用にトークンでのアクセスも許可）
+	var feedToken string
+	if cfg.OutgoingFeed.TokenEnv != "" {
+		feedToken = os.Getenv(cfg.OutgoingFeed.TokenEnv)
+	}
+	feedAuth := middleware.FeedTokenAuth(feedToken)
+	e.GET("/trends-summary/feed.xml", handlers.OutgoingFeed("rss"), feedAuth)
+	e.GET("/trends-summary/feed.atom", handlers.OutgoingFeed("atom"), feedAuth)
+	e.GET("/trends-summary/feed.json", handlers.OutgoingFeed("json"), feedAuth)
 
-	// 認証が必要なAPIグループ
+	// 認証が必要なAPIグループ（viewer以上のすべてのユーザー）
 	api := e.Group("/trends-summary", middleware.AuthMiddleware)
 	api.Use(middleware.AuthMiddleware)
 
+	// APIトークンでのアクセスに要求するスコープ（Cookieのログインセッションは制限なし）
+	readFeeds := middleware.RequireScope(models.APITokenScopeReadFeeds)
+
+	// AI要約（LLMの利用枠を消費するため summarizer 以上）
+	summarizer := api.Group("", middleware.RequireRole(models.RoleSummarizer), middleware.RequireScope(models.APITokenScopeRunAISummary))
+
+	// ユーザー・購読者・配信の管理（admin のみ）
+	admin := api.Group("", middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(models.APITokenScopeAdmin))
+
+	// ロールのグループが登録した未定義パス用のルートを戻し、存在しないパスはロールに関係なく404にする
+	api.RouteNotFound("", echo.NotFoundHandler)
+	api.RouteNotFound("/*", echo.NotFoundHandler)
+
 	// 認証状態確認
 	api.GET("/api/check-auth", handlers.CheckAuth)
 
+	// フィードソース（設定ファイルのレジストリから提供）
+	api.GET("/feeds", handlers.FeedSources, readFeeds)
+	api.GET("/feeds/:id", handlers.FeedContent, readFeeds)
+
+	// 収集済みデータの全文検索
+	api.GET("/api/search", handlers.Search, readFeeds)
+
 	// RSSフィード用のエンドポイント（英語版）
-	api.GET("/rss", handlers.Index)      // JSONレスポンスを返すエンドポイント
-	api.GET("/rss-ja", handlers.IndexJA) // 日本語版
+	api.GET("/rss", handlers.FeedAlias("infoq"), readFeeds)       // JSONレスポンスを返すエンドポイント
+	api.GET("/rss-ja", handlers.FeedAlias("infoq-ja"), readFeeds) // 日本語版
 
 	// GitHubトレンド用のエンドポイント
-	api.GET("/github-trending", handlers.GitHubTrendingHandler)
-	api.GET("/golang-repository-trending", handlers.GolangRepsitoryTrendingHandler)
-	api.GET("/tiobe-graph", handlers.TiobeGraph)
-	api.GET("/ai-article-summary", handlers.AIArticleSummary)
-	api.GET("/ai-repository-summary", handlers.AIRepositorySummary)
-	api.GET("/golang-weekly-content", handlers.GolangWeeklyContent)
+	api.GET("/github-trending", handlers.GitHubTrendingHandler, readFeeds)
+	api.GET("/golang-repository-trending", handlers.GolangRepsitoryTrendingHandler, readFeeds)
+	api.GET("/github-trending/developers", handlers.GitHubTrendingDevelopersHandler, readFeeds)
+	api.GET("/api/trending/history", handlers.TrendingHistory, readFeeds)
+	api.GET("/api/trending/diff", handlers.TrendingDiff, readFeeds)
+	api.GET("/tiobe-graph", handlers.TiobeGraph, readFeeds)
+	summarizer.GET("/ai-article-summary", handlers.AIArticleSummary)
+	summarizer.GET("/ai-repository-summary", handlers.AIRepositorySummary)
+	summarizer.GET("/ai-article-summary/stream", handlers.AIArticleSummaryStream)
+	summarizer.GET("/ai-repository-summary/stream", handlers.AIRepositorySummaryStream)
+	api.GET("/golang-weekly-content", handlers.FeedAlias("golang-weekly"), readFeeds)
 
 	// クラウドRSSフィード（英語版）
-	api.GET("/google-cloud-content", handlers.GoogleCloudContent)
-	api.GET("/aws-content", handlers.AWSContent)
-	api.GET("/azure-content", handlers.AzureContent)
+	api.GET("/google-cloud-content", handlers.FeedAlias("google-cloud"), readFeeds)
+	api.GET("/aws-content", handlers.FeedAlias("aws"), readFeeds)
+	api.GET("/azure-content", handlers.FeedAlias("azure"), readFeeds)
 
 	// クラウドRSSフィード（日本語版）
-	api.GET("/google-cloud-content-ja", handlers.GoogleCloudContentJA)
-	api.GET("/aws-content-ja", handlers.AWSContentJA)
-	api.GET("/azure-content-ja", handlers.AzureContentJA)
+	api.GET("/google-cloud-content-ja", handlers.FeedAlias("google-cloud-ja"), readFeeds)
+	api.GET("/aws-content-ja", handlers.FeedAlias("aws-ja"), readFeeds)
+	api.GET("/azure-content-ja", handlers.FeedAlias("azure-ja"), readFeeds)
+
+	summarizer.GET("/ai-trends-summary", handlers.AITrendsSummary)
+	summarizer.POST("/ai-trends-summary", handlers.AITrendsSummary)
+	summarizer.GET("/ai-trends-summary/stream", handlers.AITrendsSummaryStream)
+	summarizer.POST("/ai-trends-summary/stream", handlers.AITrendsSummaryStream)
+
+	// 保存済みの日次ダイジェスト
+	api.GET("/api/digests", handlers.Digests, readFeeds)
+	api.GET("/api/digests/:date", handlers.DigestByDate, readFeeds)
+	admin.POST("/api/digests/:date/deliver", handlers.DeliverDigest)
+	admin.GET("/api/deliveries", handlers.Deliveries)
+
+	// ダイジェストのメール購読者
+	admin.GET("/api/subscribers", handlers.Subscribers)
+	admin.POST("/api/subscribers", handlers.AddSubscriber)
+	admin.DELETE("/api/subscribers/:id", handlers.DeleteSubscriber)
+
+	// 自分のパスワード変更
+	api.PUT("/api/me/password", handlers.ChangePassword, middleware.RequireSession)
 
-	api.POST("/ai-trends-summary", handlers.AITrendsSummary)
+	// 自分のログインセッションの一覧・失効
+	api.GET("/api/sessions", handlers.Sessions, middleware.RequireSession)
+	api.DELETE("/api/sessions", handlers.RevokeOtherSessions, middleware.RequireSession)
+	api.DELETE("/api/sessions/:id", handlers.RevokeSession, middleware.RequireSession)
+
+	// 自分のAPIトークンの発行・一覧・失効（Authorization: Bearer で使用）
+	api.GET("/api/tokens", handlers.APITokens, middleware.RequireSession)
+	api.POST("/api/tokens", handlers.CreateAPIToken, middleware.RequireSession)
+	api.DELETE("/api/tokens/:id", handlers.RevokeAPIToken, middleware.RequireSession)
+
+	// 自分の表示設定と、表示設定に合わせたダイジェスト（設定の変更はログインした画面からのみ）
+	api.GET("/api/me/preferences", handlers.Preferences, readFeeds)
+	api.PUT("/api/me/preferences", handlers.UpdatePreferences, middleware.RequireSession)
+	summarizer.GET("/api/me/digest", handlers.PersonalDigest)
+	summarizer.GET("/api/me/digest/stream", handlers.PersonalDigestStream)
+
+	// 認証イベントの監査ログ
+	admin.GET("/api/audit", handlers.AuditEvents)
+
+	// AI要約の利用量
+	api.GET("/api/me/ai-usage", handlers.MyAIUsage, readFeeds)
+	admin.GET("/api/ai-usage", handlers.AIUsage)
+
+	// ユーザー管理
+	admin.GET("/api/users", handlers.Users)
+	admin.POST("/api/users", handlers.CreateUser)
+	admin.PATCH("/api/users/:id", handlers.UpdateUser)
 
 	// 静的ファイルを提供（ワイルドカードの前に配置することが重要）
 	e.Static("/trends-summary/assets", "static/assets")
@@ -79,3 +273,21 @@ func main() {
 	// サーバーの起動
 	e.Logger.Fatal(e.Start(":8080"))
 }
+
+// clientIPExtractor 信頼するプロキシのCIDRからクライアントのIPアドレスの取得方法を作成します
+// 空の場合は接続元のアドレスを使い、X-Forwarded-For・X-Real-IPは無視します
+func clientIPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	// 既定で信頼されるループバック・リンクローカル・プライベートアドレスも指定したものだけにする
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range trustedProxies {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("auth.trusted_proxiesが不正です: %w", err)
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}